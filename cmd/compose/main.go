@@ -0,0 +1,134 @@
+// Command compose validates the subgraph schemas of the monorepo and writes
+// the supergraph SDL the gateway loads with GATEWAY_SUPERGRAPH.
+//
+//	go run ./cmd/compose -url users=http://localhost:8081/query \
+//		-url products=http://localhost:8082/query -o supergraph.graphqls
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go_fed/federation"
+)
+
+// urlFlags collects repeated -url name=url flags
+type urlFlags map[string]string
+
+func (u urlFlags) String() string {
+	pairs := make([]string, 0, len(u))
+	for name, url := range u {
+		pairs = append(pairs, name+"="+url)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (u urlFlags) Set(value string) error {
+	name, url, ok := strings.Cut(value, "=")
+	if !ok || name == "" || url == "" {
+		return fmt.Errorf("expected name=url, got %q", value)
+	}
+	u[name] = url
+	return nil
+}
+
+func main() {
+	urls := urlFlags{}
+	root := flag.String("root", "services", "directory searched for schema.graphqls files")
+	output := flag.String("o", "-", "file the supergraph SDL is written to, - for stdout")
+	flag.Var(urls, "url", "subgraph routing URL as name=url (repeatable)")
+	flag.Parse()
+
+	subgraphs, err := findSubgraphs(*root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "compose: %v\n", err)
+		os.Exit(2)
+	}
+	if len(subgraphs) == 0 {
+		fmt.Fprintf(os.Stderr, "compose: no schema.graphqls found under %s\n", *root)
+		os.Exit(2)
+	}
+
+	for _, subgraph := range subgraphs {
+		subgraph.URL = urls[subgraph.Name]
+		if subgraph.URL == "" {
+			fmt.Fprintf(os.Stderr, "compose: warning: no -url for subgraph %s\n", subgraph.Name)
+		}
+	}
+
+	supergraph, err := federation.Compose(subgraphs)
+	if err != nil {
+		var conflicts federation.Conflicts
+		if errors.As(err, &conflicts) {
+			for _, conflict := range conflicts {
+				fmt.Fprintln(os.Stderr, conflict)
+			}
+			fmt.Fprintf(os.Stderr, "compose: %d conflict(s) found\n", len(conflicts))
+		} else {
+			fmt.Fprintf(os.Stderr, "compose: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	sdl, err := supergraph.SupergraphSDL()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "compose: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "-" {
+		fmt.Print(sdl)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(sdl), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "compose: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "compose: wrote supergraph with %d subgraph(s) to %s\n", len(subgraphs), *output)
+}
+
+// findSubgraphs reads every schema.graphqls under root. The subgraph is
+// named after its service directory, e.g. services/users/graph -> users.
+func findSubgraphs(root string) ([]*federation.Subgraph, error) {
+	var subgraphs []*federation.Subgraph
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "schema.graphqls" {
+			return nil
+		}
+
+		sdl, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		dir := filepath.Dir(path)
+		if filepath.Base(dir) == "graph" {
+			dir = filepath.Dir(dir)
+		}
+		subgraphs = append(subgraphs, &federation.Subgraph{
+			Name: filepath.Base(dir),
+			SDL:  string(sdl),
+			File: path,
+		})
+		return nil
+	})
+
+	return subgraphs, err
+}
@@ -0,0 +1,65 @@
+// Command registry runs the schema registry subgraphs push their SDL to.
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"go_fed/logger"
+	"go_fed/registry"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultPort = "4001"
+
+func main() {
+	port := os.Getenv("REGISTRY_PORT")
+	if port == "" {
+		port = defaultPort
+	}
+
+	logger := logger.SetupLogger("schema-registry")
+
+	dataPath := os.Getenv("REGISTRY_DATA")
+	reg, err := registry.NewRegistry(dataPath)
+	if err != nil {
+		logger.WithError(err).WithField("path", dataPath).Fatal("Failed to load registry")
+	}
+	for _, version := range reg.Subgraphs() {
+		registry.SchemaVersions.WithLabelValues(version.Subgraph).Set(float64(version.Version))
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", registry.NewHandler(reg, logger))
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "healthy",
+			"timestamp": time.Now(),
+			"service":   "schema-registry",
+			"version":   "1.0.0",
+		})
+	})
+
+	logger.WithFields(logrus.Fields{
+		"port":      port,
+		"data_path": dataPath,
+		"subgraphs": len(reg.Subgraphs()),
+		"endpoints": []string{
+			"http://localhost:" + port + "/subgraphs (Latest schemas)",
+			"http://localhost:" + port + "/subgraphs/{name}/schemas (Push and history)",
+			"http://localhost:" + port + "/subgraphs/{name}/diff (Schema diff)",
+			"http://localhost:" + port + "/supergraph (Composed supergraph)",
+			"http://localhost:" + port + "/metrics (Prometheus Metrics)",
+		},
+	}).Info("Schema registry starting")
+
+	if err := http.ListenAndServe(":"+port, mux); err != nil {
+		logger.WithError(err).Fatal("Failed to start server")
+	}
+}
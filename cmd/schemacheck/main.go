@@ -0,0 +1,150 @@
+// Command schemacheck compares two versions of a subgraph schema and sorts
+// the changes into breaking, dangerous and safe.
+//
+//	go run ./cmd/schemacheck HEAD~1:services/products/graph/schema.graphqls \
+//		services/products/graph/schema.graphqls
+//
+// Each version is a file path or a git revision and path (rev:path). With
+// -usage, changes are weighed against recorded operations and only breaking
+// changes used by some operation fail the check.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"go_fed/schemadiff"
+)
+
+func main() {
+	usagePath := flag.String("usage", "", "JSON file with recorded operations [{name, query, count}]")
+	asJSON := flag.Bool("json", false, "print the changes as JSON")
+	failOn := flag.String("fail-on", "breaking", "exit with status 1 on: breaking, dangerous or none")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: schemacheck [flags] OLD NEW\n\nOLD and NEW are files or git rev:path specs.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	oldSDL, err := readSchema(flag.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+	newSDL, err := readSchema(flag.Arg(1))
+	if err != nil {
+		fatal(err)
+	}
+
+	changes, err := schemadiff.Compare(oldSDL, newSDL)
+	if err != nil {
+		fatal(err)
+	}
+
+	weighed := false
+	if *usagePath != "" {
+		operations, err := schemadiff.LoadOperations(*usagePath)
+		if err != nil {
+			fatal(err)
+		}
+		invalid, err := schemadiff.Weigh(oldSDL, changes, operations)
+		if err != nil {
+			fatal(err)
+		}
+		for _, err := range invalid {
+			fmt.Fprintf(os.Stderr, "schemacheck: skipping operation not valid against %s: %v\n", flag.Arg(0), err)
+		}
+		weighed = true
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(changes); err != nil {
+			fatal(err)
+		}
+	} else {
+		printChanges(changes, weighed)
+	}
+
+	if failed(changes, *failOn, weighed) {
+		os.Exit(1)
+	}
+}
+
+// readSchema reads a file, or rev:path from git when the file does not exist
+func readSchema(spec string) (string, error) {
+	if data, err := os.ReadFile(spec); err == nil {
+		return string(data), nil
+	}
+
+	rev, path, ok := strings.Cut(spec, ":")
+	if !ok {
+		return "", fmt.Errorf("%s: no such file", spec)
+	}
+	out, err := exec.Command("git", "show", rev+":"+strings.TrimPrefix(path, "./")).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git show %s: %s", spec, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git show %s: %w", spec, err)
+	}
+	return string(out), nil
+}
+
+func printChanges(changes []*schemadiff.Change, weighed bool) {
+	if len(changes) == 0 {
+		fmt.Println("No changes")
+		return
+	}
+
+	counts := make(map[schemadiff.Criticality]int)
+	for _, change := range changes {
+		counts[change.Criticality]++
+
+		line := fmt.Sprintf("%-9s %-26s %s", change.Criticality, change.Type, change.Message)
+		if weighed && change.Usage != nil {
+			if len(change.Usage.Operations) == 0 {
+				line += " [unused]"
+			} else {
+				line += fmt.Sprintf(" [%d operation(s), %d call(s): %s]", len(change.Usage.Operations),
+					change.Usage.Count, strings.Join(change.Usage.Operations, ", "))
+			}
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Printf("\n%d breaking, %d dangerous, %d safe\n",
+		counts[schemadiff.Breaking], counts[schemadiff.Dangerous], counts[schemadiff.Safe])
+}
+
+func failed(changes []*schemadiff.Change, failOn string, weighed bool) bool {
+	switch failOn {
+	case "none":
+		return false
+	case "dangerous":
+		for _, change := range changes {
+			if change.Criticality == schemadiff.Dangerous {
+				return true
+			}
+		}
+	}
+
+	if weighed {
+		return schemadiff.HasUsedBreaking(changes)
+	}
+	return schemadiff.HasBreaking(changes)
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "schemacheck: %v\n", err)
+	os.Exit(2)
+}
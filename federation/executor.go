@@ -0,0 +1,512 @@
+package federation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// forwardedHeaders are copied from the client request to every subgraph
+var forwardedHeaders = []string{"X-Trace-ID", "Authorization"}
+
+// subgraphResponse is the GraphQL response returned by a subgraph
+type subgraphResponse struct {
+	Data   map[string]any `json:"data"`
+	Errors gqlerror.List  `json:"errors"`
+}
+
+// executor runs a query plan and merges every fetch into a single response
+type executor struct {
+	client     *http.Client
+	supergraph *Supergraph
+	variables  map[string]any
+	header     http.Header
+
+	mu     sync.Mutex
+	data   map[string]any
+	errors gqlerror.List
+}
+
+func newExecutor(client *http.Client, supergraph *Supergraph, variables map[string]any, header http.Header) *executor {
+	return &executor{
+		client:     client,
+		supergraph: supergraph,
+		variables:  variables,
+		header:     header,
+		data:       make(map[string]any),
+	}
+}
+
+// run executes fetches in parallel, or one by one when sequential is set
+func (e *executor) run(ctx context.Context, fetches []*Fetch, sequential bool) {
+	if sequential {
+		for _, fetch := range fetches {
+			e.execute(ctx, fetch)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, fetch := range fetches {
+		wg.Add(1)
+		go func(fetch *Fetch) {
+			defer wg.Done()
+			e.execute(ctx, fetch)
+		}(fetch)
+	}
+	wg.Wait()
+}
+
+// execute sends a fetch, merges its result and then runs its children
+func (e *executor) execute(ctx context.Context, fetch *Fetch) {
+	variables := make(map[string]any, len(fetch.Variables)+1)
+	for _, name := range fetch.Variables {
+		if value, ok := e.variables[name]; ok {
+			variables[name] = value
+		}
+	}
+
+	if !fetch.IsEntityFetch() {
+		resp, err := e.send(ctx, fetch, variables)
+		if err != nil {
+			e.addError(err, nil, fetch.Subgraph)
+			return
+		}
+
+		e.mu.Lock()
+		deepMerge(e.data, resp.Data)
+		e.mu.Unlock()
+		e.addErrors(resp.Errors, fetch.Subgraph, nil)
+
+		e.run(ctx, fetch.Children, false)
+		return
+	}
+
+	reps, targets := e.representations(fetch)
+	if len(reps) == 0 {
+		return
+	}
+	variables["representations"] = reps
+
+	resp, err := e.send(ctx, fetch, variables)
+	if err != nil {
+		for _, group := range targets {
+			e.addError(err, group[0].path, fetch.Subgraph)
+		}
+		return
+	}
+
+	entities, _ := resp.Data["_entities"].([]any)
+
+	e.mu.Lock()
+	for i, entity := range entities {
+		result, ok := entity.(map[string]any)
+		if !ok || i >= len(targets) {
+			continue
+		}
+		for _, target := range targets[i] {
+			deepMerge(target.object, result)
+		}
+	}
+	e.mu.Unlock()
+
+	e.addErrors(resp.Errors, fetch.Subgraph, func(index int) ast.Path {
+		if index < len(targets) {
+			return targets[index][0].path
+		}
+		return nil
+	})
+
+	e.run(ctx, fetch.Children, false)
+}
+
+// entityTarget is an object of the response waiting for entity fields
+type entityTarget struct {
+	object map[string]any
+	path   ast.Path
+}
+
+// representations collects the entity representations found at fetch.Path.
+// Identical representations are sent once and merged into every target.
+func (e *executor) representations(fetch *Fetch) ([]map[string]any, [][]entityTarget) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var found []entityTarget
+	collectObjects(e.data, fetch.Path, nil, &found)
+
+	key, _ := e.supergraph.Key(fetch.TypeName, fetch.Subgraph)
+
+	var reps []map[string]any
+	var targets [][]entityTarget
+	index := make(map[string]int)
+
+	for _, target := range found {
+		typeName, _ := target.object["__typename"].(string)
+		if typeName == "" {
+			typeName = fetch.TypeName
+		}
+		if typeName != fetch.TypeName {
+			continue
+		}
+
+		rep := projectKey(target.object, key)
+		rep["__typename"] = typeName
+
+		id, err := json.Marshal(rep)
+		if err != nil {
+			continue
+		}
+		if i, ok := index[string(id)]; ok {
+			targets[i] = append(targets[i], target)
+			continue
+		}
+
+		index[string(id)] = len(reps)
+		reps = append(reps, rep)
+		targets = append(targets, []entityTarget{target})
+	}
+
+	return reps, targets
+}
+
+// send posts the fetch document to its subgraph
+func (e *executor) send(ctx context.Context, fetch *Fetch, variables map[string]any) (*subgraphResponse, error) {
+	subgraph := e.supergraph.Subgraph(fetch.Subgraph)
+	if subgraph == nil {
+		return nil, fmt.Errorf("unknown subgraph %q", fetch.Subgraph)
+	}
+
+	start := time.Now()
+	resp, err := postGraphQL(ctx, e.client, subgraph.URL, fetch.Query, variables, e.header)
+	RecordSubgraphFetch(fetch.Subgraph, time.Since(start), err)
+	if err != nil {
+		return nil, fmt.Errorf("subgraph %s unavailable: %w", fetch.Subgraph, err)
+	}
+
+	return resp, nil
+}
+
+// postGraphQL sends a GraphQL request and decodes the response
+func postGraphQL(ctx context.Context, client *http.Client, url, query string, variables map[string]any, header http.Header) (*subgraphResponse, error) {
+	body, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for _, name := range forwardedHeaders {
+		if value := header.Get(name); value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out subgraphResponse
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&out); err != nil {
+		return nil, fmt.Errorf("decoding response (status %d): %w", resp.StatusCode, err)
+	}
+	if out.Data == nil && len(out.Errors) == 0 && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return &out, nil
+}
+
+// addError records a gateway error at path
+func (e *executor) addError(err error, path ast.Path, subgraph string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.errors = append(e.errors, &gqlerror.Error{
+		Message: err.Error(),
+		Path:    path,
+		Extensions: map[string]any{
+			"code":        "SUBGRAPH_UNAVAILABLE",
+			"serviceName": subgraph,
+		},
+	})
+}
+
+// addErrors records subgraph errors. Entity errors are rewritten from
+// _entities paths to the path of the object in the gateway response.
+func (e *executor) addErrors(errs gqlerror.List, subgraph string, entityPath func(index int) ast.Path) {
+	if len(errs) == 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, err := range errs {
+		if entityPath != nil {
+			err.Path = rewriteEntityPath(err.Path, entityPath)
+		}
+		if err.Extensions == nil {
+			err.Extensions = make(map[string]any)
+		}
+		err.Extensions["serviceName"] = subgraph
+		e.errors = append(e.errors, err)
+	}
+}
+
+func rewriteEntityPath(path ast.Path, entityPath func(index int) ast.Path) ast.Path {
+	if len(path) < 2 {
+		return nil
+	}
+	if name, ok := path[0].(ast.PathName); !ok || name != "_entities" {
+		return nil
+	}
+	index, ok := path[1].(ast.PathIndex)
+	if !ok {
+		return nil
+	}
+
+	base := entityPath(int(index))
+	return append(append(ast.Path{}, base...), path[2:]...)
+}
+
+// collectObjects walks value along path, flattening lists, and gathers
+// the objects found at the end of it
+func collectObjects(value any, path []string, current ast.Path, out *[]entityTarget) {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(path) == 0 {
+			*out = append(*out, entityTarget{object: v, path: current})
+			return
+		}
+		next := append(append(ast.Path{}, current...), ast.PathName(path[0]))
+		collectObjects(v[path[0]], path[1:], next, out)
+	case []any:
+		for i, item := range v {
+			next := append(append(ast.Path{}, current...), ast.PathIndex(i))
+			collectObjects(item, path, next, out)
+		}
+	}
+}
+
+// projectKey copies the key fields of object into a representation
+func projectKey(object map[string]any, key ast.SelectionSet) map[string]any {
+	rep := make(map[string]any)
+	for _, sel := range key {
+		field, ok := sel.(*ast.Field)
+		if !ok {
+			continue
+		}
+		value := object[field.Name]
+		if nested, ok := value.(map[string]any); ok && len(field.SelectionSet) > 0 {
+			value = projectKey(nested, field.SelectionSet)
+		}
+		rep[field.Name] = value
+	}
+	return rep
+}
+
+// deepMerge merges src into dst, recursing into nested objects and lists
+func deepMerge(dst, src map[string]any) {
+	for key, value := range src {
+		existing, ok := dst[key]
+		if !ok || existing == nil {
+			dst[key] = value
+			continue
+		}
+
+		switch v := value.(type) {
+		case map[string]any:
+			if target, ok := existing.(map[string]any); ok {
+				deepMerge(target, v)
+				continue
+			}
+		case []any:
+			if target, ok := existing.([]any); ok && len(target) == len(v) {
+				for i := range v {
+					src, srcOK := v[i].(map[string]any)
+					dst, dstOK := target[i].(map[string]any)
+					if srcOK && dstOK {
+						deepMerge(dst, src)
+					} else if target[i] == nil {
+						target[i] = v[i]
+					}
+				}
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+// complete shapes the merged data into the selection set the client asked
+// for, dropping the fields the gateway added and propagating nulls
+func (e *executor) complete(schema *ast.Schema, doc *ast.QueryDocument, def *ast.Definition, set ast.SelectionSet, object map[string]any, path ast.Path) (*orderedMap, bool) {
+	if def.IsAbstractType() {
+		if typeName, ok := object["__typename"].(string); ok && schema.Types[typeName] != nil {
+			def = schema.Types[typeName]
+		}
+	}
+
+	out := newOrderedMap()
+	for _, field := range collectFields(schema, doc, def, set, e.variables) {
+		fieldPath := append(append(ast.Path{}, path...), ast.PathName(field.Alias))
+
+		if field.Name == "__typename" {
+			out.Set(field.Alias, def.Name)
+			continue
+		}
+		if strings.HasPrefix(field.Name, "__") {
+			out.Set(field.Alias, object[field.Alias])
+			continue
+		}
+		if field.Definition == nil {
+			continue
+		}
+
+		value, ok := e.completeValue(schema, doc, field.Definition.Type, field.SelectionSet, object[field.Alias], fieldPath)
+		if !ok {
+			return nil, false
+		}
+		out.Set(field.Alias, value)
+	}
+
+	return out, true
+}
+
+// completeValue shapes a single value. It returns false when a null reached
+// a non-null position and has to propagate to the parent.
+func (e *executor) completeValue(schema *ast.Schema, doc *ast.QueryDocument, typ *ast.Type, set ast.SelectionSet, value any, path ast.Path) (any, bool) {
+	if value == nil {
+		if typ.NonNull {
+			e.nullError(path)
+			return nil, false
+		}
+		return nil, true
+	}
+
+	if typ.Elem != nil {
+		list, ok := value.([]any)
+		if !ok {
+			return nil, !typ.NonNull
+		}
+		out := make([]any, len(list))
+		for i, item := range list {
+			completed, ok := e.completeValue(schema, doc, typ.Elem, set, item, append(append(ast.Path{}, path...), ast.PathIndex(i)))
+			if !ok {
+				return nil, !typ.NonNull
+			}
+			out[i] = completed
+		}
+		return out, true
+	}
+
+	if len(set) == 0 {
+		return value, true
+	}
+
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, !typ.NonNull
+	}
+	completed, ok := e.complete(schema, doc, schema.Types[typ.Name()], set, object, path)
+	if !ok {
+		return nil, !typ.NonNull
+	}
+	return completed, true
+}
+
+// nullError reports a null in a non-null position, unless a subgraph error
+// already explains it
+func (e *executor) nullError(path ast.Path) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, err := range e.errors {
+		if len(err.Path) == 0 || hasPathPrefix(path, err.Path) || hasPathPrefix(err.Path, path) {
+			return
+		}
+	}
+	e.errors = append(e.errors, &gqlerror.Error{
+		Message: "Cannot return null for non-nullable field",
+		Path:    path,
+	})
+}
+
+func hasPathPrefix(path, prefix ast.Path) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if fmt.Sprint(path[i]) != fmt.Sprint(prefix[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// orderedMap is a JSON object that keeps the field order of the query
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]any)}
+}
+
+// Set adds or replaces a key, keeping its first position
+func (m *orderedMap) Set(key string, value any) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Get returns the value stored under key
+func (m *orderedMap) Get(key string) (any, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+// MarshalJSON encodes the map in insertion order
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
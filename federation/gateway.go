@@ -0,0 +1,211 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// ErrNotReady is returned while no supergraph has been composed yet
+var ErrNotReady = errors.New("supergraph not composed yet")
+
+// Request is a GraphQL request sent by a client
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response is the GraphQL response returned to the client
+type Response struct {
+	Data   any           `json:"data"`
+	Errors gqlerror.List `json:"errors,omitempty"`
+}
+
+// Gateway plans client operations over the supergraph and executes them
+// against the subgraphs
+type Gateway struct {
+	client     *http.Client
+	subgraphs  []*Subgraph
+	supergraph atomic.Pointer[Supergraph]
+}
+
+// NewGateway creates a gateway for the given subgraphs (name and URL)
+func NewGateway(subgraphs []*Subgraph, client *http.Client) *Gateway {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Gateway{
+		client:    client,
+		subgraphs: subgraphs,
+	}
+}
+
+// Supergraph returns the currently active supergraph, if any
+func (g *Gateway) Supergraph() *Supergraph {
+	return g.supergraph.Load()
+}
+
+// SetSupergraph replaces the active supergraph
+func (g *Gateway) SetSupergraph(supergraph *Supergraph) {
+	g.supergraph.Store(supergraph)
+}
+
+// Load fetches every subgraph SDL in parallel and composes a new supergraph.
+// The active supergraph is kept when any subgraph fails.
+func (g *Gateway) Load(ctx context.Context) error {
+	subgraphs := make([]*Subgraph, len(g.subgraphs))
+	errs := make([]error, len(g.subgraphs))
+
+	var wg sync.WaitGroup
+	for i, subgraph := range g.subgraphs {
+		wg.Add(1)
+		go func(i int, subgraph *Subgraph) {
+			defer wg.Done()
+
+			sdl, err := FetchSDL(ctx, g.client, subgraph.URL)
+			if err != nil {
+				errs[i] = fmt.Errorf("fetching %s schema: %w", subgraph.Name, err)
+				return
+			}
+			subgraphs[i] = &Subgraph{Name: subgraph.Name, URL: subgraph.URL, SDL: sdl}
+		}(i, subgraph)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	supergraph, err := Compose(subgraphs)
+	if err != nil {
+		return err
+	}
+
+	g.SetSupergraph(supergraph)
+	return nil
+}
+
+// FetchSDL reads the SDL a subgraph serves through _service { sdl }
+func FetchSDL(ctx context.Context, client *http.Client, url string) (string, error) {
+	resp, err := postGraphQL(ctx, client, url, "query { _service { sdl } }", nil, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Errors) > 0 {
+		return "", resp.Errors
+	}
+
+	service, _ := resp.Data["_service"].(map[string]any)
+	sdl, _ := service["sdl"].(string)
+	if sdl == "" {
+		return "", fmt.Errorf("subgraph at %s returned an empty SDL", url)
+	}
+	return sdl, nil
+}
+
+// Execute validates, plans and runs a client request
+func (g *Gateway) Execute(ctx context.Context, req *Request, header http.Header) *Response {
+	supergraph := g.Supergraph()
+	if supergraph == nil {
+		return &Response{Errors: gqlerror.List{gqlerror.Errorf("%s", ErrNotReady)}}
+	}
+
+	doc, errs := gqlparser.LoadQuery(supergraph.Schema, req.Query)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+
+	op := doc.Operations.ForName(req.OperationName)
+	if op == nil {
+		if req.OperationName == "" && len(doc.Operations) > 1 {
+			return &Response{Errors: gqlerror.List{gqlerror.Errorf("operation name is required when the document has several operations")}}
+		}
+		return &Response{Errors: gqlerror.List{gqlerror.Errorf("operation %q not found", req.OperationName)}}
+	}
+	if op.Operation == ast.Subscription {
+		return &Response{Errors: gqlerror.List{gqlerror.Errorf("subscriptions are not supported by the gateway")}}
+	}
+
+	variables, err := validator.VariableValues(supergraph.Schema, op, req.Variables)
+	if err != nil {
+		return &Response{Errors: gqlerror.List{gqlerror.WrapIfUnwrapped(err)}}
+	}
+
+	plan, err := Plan(supergraph, doc, op, variables)
+	if err != nil {
+		return &Response{Errors: gqlerror.List{gqlerror.Errorf("%s", err)}}
+	}
+
+	exec := newExecutor(g.client, supergraph, variables, header)
+	exec.run(ctx, plan.Fetches, plan.Sequential)
+
+	root := rootType(supergraph.Schema, op.Operation)
+	intro := &introspector{schema: supergraph.Schema, doc: doc, variables: variables}
+	for _, field := range collectFields(supergraph.Schema, doc, root, op.SelectionSet, variables) {
+		if strings.HasPrefix(field.Name, "__") && field.Name != "__typename" {
+			exec.data[field.Alias] = intro.resolve(field)
+		}
+	}
+
+	data, ok := exec.complete(supergraph.Schema, doc, root, op.SelectionSet, exec.data, nil)
+	resp := &Response{Errors: exec.errors}
+	if ok {
+		resp.Data = data
+	}
+	return resp
+}
+
+// ServeHTTP implements the GraphQL over HTTP endpoint of the gateway
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Request
+
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if raw := r.URL.Query().Get("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				writeResponse(w, http.StatusBadRequest, &Response{Errors: gqlerror.List{gqlerror.Errorf("invalid variables: %s", err)}})
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeResponse(w, http.StatusBadRequest, &Response{Errors: gqlerror.List{gqlerror.Errorf("invalid request body: %s", err)}})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if req.Query == "" {
+		writeResponse(w, http.StatusBadRequest, &Response{Errors: gqlerror.List{gqlerror.Errorf("query is required")}})
+		return
+	}
+
+	if g.Supergraph() == nil {
+		writeResponse(w, http.StatusServiceUnavailable, &Response{Errors: gqlerror.List{gqlerror.Errorf("%s", ErrNotReady)}})
+		return
+	}
+
+	writeResponse(w, http.StatusOK, g.Execute(r.Context(), &req, r.Header))
+}
+
+func writeResponse(w http.ResponseWriter, status int, resp *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,182 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeSubgraph serves _service and hands every other query to resolve
+func fakeSubgraph(t *testing.T, sdl string, resolve func(req Request) map[string]any) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid subgraph request: %v", err)
+			return
+		}
+
+		var data map[string]any
+		if strings.Contains(req.Query, "_service") {
+			data = map[string]any{"_service": map[string]any{"sdl": sdl}}
+		} else {
+			data = resolve(req)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestGateway(t *testing.T, entityCalls *int32) *Gateway {
+	t.Helper()
+
+	users := map[string]map[string]any{
+		"1": {"id": "1", "name": "Alice", "email": "alice@example.com"},
+		"2": {"id": "2", "name": "Bob", "email": "bob@example.com"},
+	}
+
+	usersServer := fakeSubgraph(t, usersSDL, func(req Request) map[string]any {
+		if strings.Contains(req.Query, "_entities") {
+			atomic.AddInt32(entityCalls, 1)
+			reps, _ := req.Variables["representations"].([]any)
+			entities := make([]any, len(reps))
+			for i, rep := range reps {
+				id, _ := rep.(map[string]any)["id"].(string)
+				if user, ok := users[id]; ok {
+					entities[i] = map[string]any{"__typename": "User", "name": user["name"], "email": user["email"]}
+				}
+			}
+			return map[string]any{"_entities": entities}
+		}
+		return map[string]any{"users": []any{users["1"], users["2"]}}
+	})
+
+	productsServer := fakeSubgraph(t, productsSDL, func(req Request) map[string]any {
+		return map[string]any{"products": []any{
+			map[string]any{"name": "iPhone 15 Pro", "owner": map[string]any{"__typename": "User", "id": "1"}},
+			map[string]any{"name": "MacBook Air", "owner": map[string]any{"__typename": "User", "id": "2"}},
+			map[string]any{"name": "AirPods", "owner": map[string]any{"__typename": "User", "id": "1"}},
+		}}
+	})
+
+	gateway := NewGateway([]*Subgraph{
+		{Name: "users", URL: usersServer.URL},
+		{Name: "products", URL: productsServer.URL},
+	}, nil)
+	if err := gateway.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return gateway
+}
+
+func TestGatewayResolvesOwnerThroughEntities(t *testing.T) {
+	var entityCalls int32
+	gateway := newTestGateway(t, &entityCalls)
+
+	resp := gateway.Execute(context.Background(), &Request{
+		Query: `{ products { name owner { id name } } }`,
+	}, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", resp.Errors)
+	}
+
+	body, err := json.Marshal(resp.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"products":[` +
+		`{"name":"iPhone 15 Pro","owner":{"id":"1","name":"Alice"}},` +
+		`{"name":"MacBook Air","owner":{"id":"2","name":"Bob"}},` +
+		`{"name":"AirPods","owner":{"id":"1","name":"Alice"}}]}`
+	if string(body) != want {
+		t.Errorf("data = %s\nwant   %s", body, want)
+	}
+
+	if calls := atomic.LoadInt32(&entityCalls); calls != 1 {
+		t.Errorf("expected owners to be batched in 1 _entities call, got %d", calls)
+	}
+}
+
+func TestGatewayServeHTTP(t *testing.T) {
+	var entityCalls int32
+	gateway := newTestGateway(t, &entityCalls)
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/json",
+		strings.NewReader(`{"query":"{ users { name } __typename }"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Data struct {
+			Users []struct {
+				Name string `json:"name"`
+			} `json:"users"`
+			Typename string `json:"__typename"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Data.Users) != 2 || out.Data.Typename != "Query" {
+		t.Errorf("unexpected response %+v", out.Data)
+	}
+}
+
+func TestGatewayIntrospection(t *testing.T) {
+	var entityCalls int32
+	gateway := newTestGateway(t, &entityCalls)
+
+	resp := gateway.Execute(context.Background(), &Request{
+		Query: `{ __type(name: "Product") { name fields { name } } }`,
+	}, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", resp.Errors)
+	}
+
+	body, _ := json.Marshal(resp.Data)
+	if !strings.Contains(string(body), `"name":"owner"`) {
+		t.Errorf("introspection missing Product.owner: %s", body)
+	}
+}
+
+func TestGatewayNotReady(t *testing.T) {
+	gateway := NewGateway(nil, nil)
+
+	resp := gateway.Execute(context.Background(), &Request{Query: `{ users { id } }`}, nil)
+	if len(resp.Errors) != 1 || resp.Data != nil {
+		t.Errorf("expected a not ready error, got %+v", resp)
+	}
+}
+
+func TestGatewaySubgraphUnavailable(t *testing.T) {
+	var entityCalls int32
+	gateway := newTestGateway(t, &entityCalls)
+
+	// Point products at a closed server after composing
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	gateway.Supergraph().Subgraph("products").URL = closed.URL
+
+	resp := gateway.Execute(context.Background(), &Request{
+		Query: `{ users { name } products { name } }`,
+	}, nil)
+	if len(resp.Errors) == 0 {
+		t.Fatal("expected an error for the unavailable subgraph")
+	}
+	if code := resp.Errors[0].Extensions["code"]; code != "SUBGRAPH_UNAVAILABLE" {
+		t.Errorf("error code = %v, want SUBGRAPH_UNAVAILABLE", code)
+	}
+}
@@ -0,0 +1,208 @@
+package federation
+
+import (
+	"github.com/99designs/gqlgen/graphql/introspection"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// introspector answers __schema and __type against the supergraph API
+// schema, so clients and playgrounds can explore the composed graph
+type introspector struct {
+	schema    *ast.Schema
+	doc       *ast.QueryDocument
+	variables map[string]any
+}
+
+// resolve answers a root introspection field
+func (i *introspector) resolve(field *ast.Field) any {
+	switch field.Name {
+	case "__schema":
+		return i.schemaObject(field.SelectionSet, introspection.WrapSchema(i.schema))
+	case "__type":
+		name, _ := field.ArgumentMap(i.variables)["name"].(string)
+		return i.typeObject(field.SelectionSet, introspection.WrapTypeFromDef(i.schema, i.schema.Types[name]))
+	}
+	return nil
+}
+
+func (i *introspector) object(set ast.SelectionSet, typeName string, resolve func(field *ast.Field) any) *orderedMap {
+	out := newOrderedMap()
+	for _, field := range collectFields(i.schema, i.doc, i.schema.Types[typeName], set, i.variables) {
+		if field.Name == "__typename" {
+			out.Set(field.Alias, typeName)
+			continue
+		}
+		out.Set(field.Alias, resolve(field))
+	}
+	return out
+}
+
+func (i *introspector) schemaObject(set ast.SelectionSet, s *introspection.Schema) any {
+	return i.object(set, "__Schema", func(field *ast.Field) any {
+		switch field.Name {
+		case "description":
+			return s.Description()
+		case "types":
+			types := s.Types()
+			out := make([]any, len(types))
+			for n := range types {
+				out[n] = i.typeObject(field.SelectionSet, &types[n])
+			}
+			return out
+		case "queryType":
+			return i.typeObject(field.SelectionSet, s.QueryType())
+		case "mutationType":
+			return i.typeObject(field.SelectionSet, s.MutationType())
+		case "subscriptionType":
+			return i.typeObject(field.SelectionSet, s.SubscriptionType())
+		case "directives":
+			directives := s.Directives()
+			out := make([]any, len(directives))
+			for n := range directives {
+				out[n] = i.directiveObject(field.SelectionSet, &directives[n])
+			}
+			return out
+		}
+		return nil
+	})
+}
+
+func (i *introspector) typeObject(set ast.SelectionSet, t *introspection.Type) any {
+	if t == nil {
+		return nil
+	}
+	return i.object(set, "__Type", func(field *ast.Field) any {
+		args := field.ArgumentMap(i.variables)
+		includeDeprecated, _ := args["includeDeprecated"].(bool)
+
+		switch field.Name {
+		case "kind":
+			return t.Kind()
+		case "name":
+			return t.Name()
+		case "description":
+			return t.Description()
+		case "specifiedByURL":
+			return t.SpecifiedByURL()
+		case "isOneOf":
+			return t.IsOneOf()
+		case "fields":
+			if t.Kind() != "OBJECT" && t.Kind() != "INTERFACE" {
+				return nil
+			}
+			fields := t.Fields(includeDeprecated)
+			out := make([]any, len(fields))
+			for n := range fields {
+				out[n] = i.fieldObject(field.SelectionSet, &fields[n])
+			}
+			return out
+		case "interfaces", "possibleTypes":
+			types := t.Interfaces()
+			if field.Name == "possibleTypes" {
+				types = t.PossibleTypes()
+			}
+			if types == nil {
+				return nil
+			}
+			out := make([]any, len(types))
+			for n := range types {
+				out[n] = i.typeObject(field.SelectionSet, &types[n])
+			}
+			return out
+		case "enumValues":
+			if t.Kind() != "ENUM" {
+				return nil
+			}
+			values := t.EnumValues(includeDeprecated)
+			out := make([]any, len(values))
+			for n := range values {
+				value := values[n]
+				out[n] = i.object(field.SelectionSet, "__EnumValue", func(field *ast.Field) any {
+					switch field.Name {
+					case "name":
+						return value.Name
+					case "description":
+						return value.Description()
+					case "isDeprecated":
+						return value.IsDeprecated()
+					case "deprecationReason":
+						return value.DeprecationReason()
+					}
+					return nil
+				})
+			}
+			return out
+		case "inputFields":
+			if t.Kind() != "INPUT_OBJECT" {
+				return nil
+			}
+			return i.inputValues(field.SelectionSet, t.InputFields())
+		case "ofType":
+			return i.typeObject(field.SelectionSet, t.OfType())
+		}
+		return nil
+	})
+}
+
+func (i *introspector) fieldObject(set ast.SelectionSet, f *introspection.Field) any {
+	return i.object(set, "__Field", func(field *ast.Field) any {
+		switch field.Name {
+		case "name":
+			return f.Name
+		case "description":
+			return f.Description()
+		case "args":
+			return i.inputValues(field.SelectionSet, f.Args)
+		case "type":
+			return i.typeObject(field.SelectionSet, f.Type)
+		case "isDeprecated":
+			return f.IsDeprecated()
+		case "deprecationReason":
+			return f.DeprecationReason()
+		}
+		return nil
+	})
+}
+
+func (i *introspector) inputValues(set ast.SelectionSet, values []introspection.InputValue) any {
+	out := make([]any, len(values))
+	for n := range values {
+		value := values[n]
+		out[n] = i.object(set, "__InputValue", func(field *ast.Field) any {
+			switch field.Name {
+			case "name":
+				return value.Name
+			case "description":
+				return value.Description()
+			case "type":
+				return i.typeObject(field.SelectionSet, value.Type)
+			case "defaultValue":
+				return value.DefaultValue
+			case "isDeprecated":
+				return value.IsDeprecated()
+			case "deprecationReason":
+				return value.DeprecationReason()
+			}
+			return nil
+		})
+	}
+	return out
+}
+
+func (i *introspector) directiveObject(set ast.SelectionSet, d *introspection.Directive) any {
+	return i.object(set, "__Directive", func(field *ast.Field) any {
+		switch field.Name {
+		case "name":
+			return d.Name
+		case "description":
+			return d.Description()
+		case "locations":
+			return d.Locations
+		case "args":
+			return i.inputValues(field.SelectionSet, d.Args)
+		case "isRepeatable":
+			return d.IsRepeatable
+		}
+		return nil
+	})
+}
@@ -0,0 +1,227 @@
+package federation
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// joinDefinitions declare the directives that carry routing metadata in a
+// supergraph SDL, following the shape of the Apollo join spec
+const joinDefinitions = `directive @join__graph(name: String!, url: String!) on ENUM_VALUE
+
+directive @join__type(graph: join__Graph!, key: join__FieldSet, resolvable: Boolean = true) repeatable on OBJECT | INTERFACE | UNION | ENUM | INPUT_OBJECT | SCALAR
+
+directive @join__field(graph: join__Graph) repeatable on FIELD_DEFINITION | INPUT_FIELD_DEFINITION
+
+scalar join__FieldSet
+`
+
+// SupergraphSDL prints the API schema annotated with join directives, so
+// a gateway can load the routing metadata without asking the subgraphs
+func (s *Supergraph) SupergraphSDL() (string, error) {
+	doc, err := parser.ParseSchema(&ast.Source{Name: "supergraph", Input: s.SDL})
+	if err != nil {
+		return "", err
+	}
+
+	for _, def := range doc.Definitions {
+		graphs := s.graphs[def.Name]
+		for _, graph := range graphs {
+			args := ast.ArgumentList{joinArgument("graph", ast.EnumValue, graphEnumName(graph))}
+			if key, ok := s.keys[def.Name][graph]; ok {
+				args = append(args, joinArgument("key", ast.StringValue, fieldSetString(key)))
+			}
+			def.Directives = append(def.Directives, &ast.Directive{Name: "join__type", Arguments: args})
+		}
+
+		if def.Kind != ast.Object && def.Kind != ast.Interface {
+			continue
+		}
+
+		// Fields only carry @join__field when some of the subgraphs that
+		// declare the type cannot resolve them
+		for _, field := range def.Fields {
+			owners := s.owners[def.Name][field.Name]
+			if len(owners) == len(graphs) {
+				continue
+			}
+			for _, owner := range owners {
+				field.Directives = append(field.Directives, &ast.Directive{
+					Name:      "join__field",
+					Arguments: ast.ArgumentList{joinArgument("graph", ast.EnumValue, graphEnumName(owner))},
+				})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(joinDefinitions)
+	buf.WriteString("\nenum join__Graph {\n")
+	for _, subgraph := range s.Subgraphs() {
+		fmt.Fprintf(&buf, "\t%s @join__graph(name: %s, url: %s)\n",
+			graphEnumName(subgraph.Name), strconv.Quote(subgraph.Name), strconv.Quote(subgraph.URL))
+	}
+	buf.WriteString("}\n\n")
+	formatter.NewFormatter(&buf).FormatSchemaDocument(doc)
+
+	return buf.String(), nil
+}
+
+// ParseSupergraph loads a supergraph SDL written by SupergraphSDL
+func ParseSupergraph(sdl string) (*Supergraph, error) {
+	doc, err := parser.ParseSchema(&ast.Source{Name: "supergraph", Input: sdl})
+	if err != nil {
+		return nil, fmt.Errorf("parsing supergraph: %w", err)
+	}
+
+	sg := newSupergraph()
+	graphNames := make(map[string]string)
+
+	graphEnum := doc.Definitions.ForName("join__Graph")
+	if graphEnum == nil || len(graphEnum.EnumValues) == 0 {
+		return nil, fmt.Errorf("supergraph does not declare any join__Graph")
+	}
+	for _, value := range graphEnum.EnumValues {
+		dir := value.Directives.ForName("join__graph")
+		if dir == nil {
+			return nil, fmt.Errorf("join__Graph.%s is missing @join__graph", value.Name)
+		}
+		name := stringArgument(dir, "name")
+		if name == "" {
+			return nil, fmt.Errorf("join__Graph.%s has no name", value.Name)
+		}
+		graphNames[value.Name] = name
+		sg.subgraphs[name] = &Subgraph{Name: name, URL: stringArgument(dir, "url")}
+		sg.order = append(sg.order, name)
+	}
+
+	api := &ast.SchemaDocument{}
+	for _, def := range doc.Definitions {
+		if strings.HasPrefix(def.Name, "join__") {
+			continue
+		}
+
+		for _, dir := range def.Directives.ForNames("join__type") {
+			graph, err := joinGraph(dir, graphNames)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", def.Name, err)
+			}
+			sg.graphs[def.Name] = appendUnique(sg.graphs[def.Name], graph)
+
+			key := stringArgument(dir, "key")
+			resolvable := dir.Arguments.ForName("resolvable")
+			if key == "" || (resolvable != nil && resolvable.Value != nil && resolvable.Value.Raw == "false") {
+				continue
+			}
+			set, err := ParseFieldSet(key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid key on %s: %w", def.Name, err)
+			}
+			if sg.keys[def.Name] == nil {
+				sg.keys[def.Name] = make(map[string]ast.SelectionSet)
+			}
+			sg.keys[def.Name][graph] = set
+		}
+		def.Directives = withoutJoinDirectives(def.Directives)
+
+		if def.Kind == ast.Object || def.Kind == ast.Interface {
+			sg.owners[def.Name] = make(map[string][]string)
+			for _, field := range def.Fields {
+				owners := sg.graphs[def.Name]
+				if dirs := field.Directives.ForNames("join__field"); len(dirs) > 0 {
+					owners = nil
+					for _, dir := range dirs {
+						graph, err := joinGraph(dir, graphNames)
+						if err != nil {
+							return nil, fmt.Errorf("%s.%s: %w", def.Name, field.Name, err)
+						}
+						owners = appendUnique(owners, graph)
+					}
+				}
+				sg.owners[def.Name][field.Name] = owners
+				field.Directives = withoutJoinDirectives(field.Directives)
+			}
+		}
+
+		api.Definitions = append(api.Definitions, def)
+	}
+
+	for _, dir := range doc.Directives {
+		if !strings.HasPrefix(dir.Name, "join__") {
+			api.Directives = append(api.Directives, dir)
+		}
+	}
+
+	if err := sg.load(api); err != nil {
+		return nil, err
+	}
+	return sg, nil
+}
+
+func joinGraph(dir *ast.Directive, graphNames map[string]string) (string, error) {
+	arg := dir.Arguments.ForName("graph")
+	if arg == nil || arg.Value == nil {
+		return "", fmt.Errorf("@%s is missing graph", dir.Name)
+	}
+	graph, ok := graphNames[arg.Value.Raw]
+	if !ok {
+		return "", fmt.Errorf("@%s references unknown graph %s", dir.Name, arg.Value.Raw)
+	}
+	return graph, nil
+}
+
+func joinArgument(name string, kind ast.ValueKind, raw string) *ast.Argument {
+	return &ast.Argument{Name: name, Value: &ast.Value{Kind: kind, Raw: raw}}
+}
+
+func stringArgument(dir *ast.Directive, name string) string {
+	arg := dir.Arguments.ForName(name)
+	if arg == nil || arg.Value == nil {
+		return ""
+	}
+	return arg.Value.Raw
+}
+
+func withoutJoinDirectives(list ast.DirectiveList) ast.DirectiveList {
+	var out ast.DirectiveList
+	for _, dir := range list {
+		if !strings.HasPrefix(dir.Name, "join__") {
+			out = append(out, dir)
+		}
+	}
+	return out
+}
+
+// graphEnumName turns a subgraph name into a join__Graph value
+func graphEnumName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+}
+
+// fieldSetString prints a field set back to the @key fields syntax
+func fieldSetString(set ast.SelectionSet) string {
+	parts := make([]string, 0, len(set))
+	for _, sel := range set {
+		field, ok := sel.(*ast.Field)
+		if !ok {
+			continue
+		}
+		if len(field.SelectionSet) > 0 {
+			parts = append(parts, field.Name+" { "+fieldSetString(field.SelectionSet)+" }")
+			continue
+		}
+		parts = append(parts, field.Name)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,64 @@
+package federation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSupergraphSDLRoundTrip(t *testing.T) {
+	composed, err := Compose(testSubgraphs())
+	if err != nil {
+		t.Fatalf("Compose() error = %v", err)
+	}
+
+	sdl, err := composed.SupergraphSDL()
+	if err != nil {
+		t.Fatalf("SupergraphSDL() error = %v", err)
+	}
+	for _, want := range []string{
+		`USERS @join__graph(name: "users", url: "http://users")`,
+		`@join__type(graph: USERS, key: "id")`,
+		`name: String! @join__field(graph: USERS)`,
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("supergraph SDL missing %q:\n%s", want, sdl)
+		}
+	}
+
+	loaded, err := ParseSupergraph(sdl)
+	if err != nil {
+		t.Fatalf("ParseSupergraph() error = %v", err)
+	}
+
+	if loaded.SDL != composed.SDL {
+		t.Errorf("API schema changed after round trip:\n%s\nwant:\n%s", loaded.SDL, composed.SDL)
+	}
+	if subgraph := loaded.Subgraph("products"); subgraph == nil || subgraph.URL != "http://products" {
+		t.Errorf("products subgraph = %+v", subgraph)
+	}
+	for _, field := range []string{"id", "name", "email"} {
+		if got, want := loaded.Owners("User", field), composed.Owners("User", field); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("Owners(User, %s) = %v, want %v", field, got, want)
+		}
+	}
+	if _, ok := loaded.Key("User", "users"); !ok {
+		t.Error("users key on User lost in round trip")
+	}
+	if _, ok := loaded.Key("User", "products"); ok {
+		t.Error("products must not resolve User entities after round trip")
+	}
+}
+
+func TestParseSupergraphErrors(t *testing.T) {
+	tests := map[string]string{
+		"no graphs":     `type Query { a: String }`,
+		"unknown graph": joinDefinitions + `enum join__Graph { A @join__graph(name: "a", url: "http://a") } type Query @join__type(graph: B) { a: String }`,
+	}
+	for name, sdl := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseSupergraph(sdl); err == nil {
+				t.Error("ParseSupergraph() expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package federation
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	SubgraphFetches = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_subgraph_fetches_total",
+			Help: "Total of subgraph fetches by subgraph and status",
+		},
+		[]string{"subgraph", "status"},
+	)
+
+	SubgraphFetchDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gateway_subgraph_fetch_duration_seconds",
+			Help:    "Duration of subgraph fetches in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"subgraph"},
+	)
+)
+
+// RecordSubgraphFetch - Record a subgraph fetch in metrics
+func RecordSubgraphFetch(subgraph string, duration time.Duration, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	SubgraphFetches.WithLabelValues(subgraph, status).Inc()
+	SubgraphFetchDuration.WithLabelValues(subgraph).Observe(duration.Seconds())
+}
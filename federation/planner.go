@@ -0,0 +1,433 @@
+package federation
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+)
+
+// QueryPlan is the set of subgraph fetches needed to answer an operation
+type QueryPlan struct {
+	Operation *ast.OperationDefinition
+	// Fetches are the root fetches. Queries run them in parallel, mutations
+	// run them one after another to keep the serial execution guarantee.
+	Fetches    []*Fetch
+	Sequential bool
+}
+
+// Fetch is a single request to a subgraph. Entity fetches resolve the
+// objects found at Path through _entities once their parent has completed.
+type Fetch struct {
+	Subgraph string
+	// Path is the response path of the entities, empty for root fetches
+	Path []string
+	// TypeName is the entity type for _entities fetches
+	TypeName string
+	// Query is the document sent to the subgraph
+	Query string
+	// Variables are the operation variables forwarded to the subgraph
+	Variables []string
+	// Children run once this fetch has been merged into the response
+	Children []*Fetch
+
+	selections ast.SelectionSet
+	variables  map[string]bool
+}
+
+// IsEntityFetch reports whether the fetch goes through _entities
+func (f *Fetch) IsEntityFetch() bool {
+	return f.TypeName != ""
+}
+
+// child returns the entity fetch for (subgraph, path), creating it if needed
+func (f *Fetch) child(subgraph, typeName string, path []string) *Fetch {
+	for _, child := range f.Children {
+		if child.Subgraph == subgraph && child.TypeName == typeName && samePath(child.Path, path) {
+			return child
+		}
+	}
+
+	child := &Fetch{
+		Subgraph:  subgraph,
+		Path:      append([]string(nil), path...),
+		TypeName:  typeName,
+		variables: make(map[string]bool),
+	}
+	f.Children = append(f.Children, child)
+	return child
+}
+
+type planner struct {
+	supergraph *Supergraph
+	doc        *ast.QueryDocument
+	op         *ast.OperationDefinition
+	variables  map[string]any
+	err        error
+}
+
+// Plan builds the query plan for an operation validated against the supergraph
+func Plan(supergraph *Supergraph, doc *ast.QueryDocument, op *ast.OperationDefinition, variables map[string]any) (*QueryPlan, error) {
+	p := &planner{
+		supergraph: supergraph,
+		doc:        doc,
+		op:         op,
+		variables:  variables,
+	}
+
+	root := rootType(supergraph.Schema, op.Operation)
+	if root == nil {
+		return nil, fmt.Errorf("schema does not support %s operations", op.Operation)
+	}
+
+	plan := &QueryPlan{
+		Operation:  op,
+		Sequential: op.Operation == ast.Mutation,
+	}
+
+	var current *Fetch
+	for _, field := range collectFields(supergraph.Schema, doc, root, op.SelectionSet, variables) {
+		// Introspection and __typename are answered by the gateway itself
+		if strings.HasPrefix(field.Name, "__") {
+			continue
+		}
+
+		owners := supergraph.Owners(root.Name, field.Name)
+		if len(owners) == 0 {
+			return nil, fmt.Errorf("no subgraph resolves %s.%s", root.Name, field.Name)
+		}
+		owner := owners[0]
+
+		// Root fields are grouped per subgraph. Mutations only group
+		// consecutive fields so the execution order is preserved.
+		current = nil
+		if plan.Sequential {
+			if n := len(plan.Fetches); n > 0 && plan.Fetches[n-1].Subgraph == owner {
+				current = plan.Fetches[n-1]
+			}
+		} else {
+			for _, fetch := range plan.Fetches {
+				if fetch.Subgraph == owner {
+					current = fetch
+					break
+				}
+			}
+		}
+		if current == nil {
+			current = &Fetch{Subgraph: owner, variables: make(map[string]bool)}
+			plan.Fetches = append(plan.Fetches, current)
+		}
+
+		current.selections = append(current.selections, p.planField(current, owner, field, []string{field.Alias}))
+	}
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	for _, fetch := range plan.Fetches {
+		p.finalize(fetch)
+	}
+
+	return plan, nil
+}
+
+// planField copies a field into the subgraph selection, splitting off the
+// parts of its selection set that other subgraphs have to resolve
+func (p *planner) planField(fetch *Fetch, subgraph string, field *ast.Field, path []string) *ast.Field {
+	trackVariables(fetch, field.Arguments)
+
+	out := &ast.Field{
+		Alias:     field.Alias,
+		Name:      field.Name,
+		Arguments: field.Arguments,
+	}
+
+	if len(field.SelectionSet) > 0 && field.Definition != nil {
+		def := p.supergraph.Schema.Types[field.Definition.Type.Name()]
+		out.SelectionSet = p.planSelections(fetch, subgraph, def, field.SelectionSet, path)
+	}
+
+	return out
+}
+
+// planSelections plans the selection set of an object resolved by subgraph
+func (p *planner) planSelections(fetch *Fetch, subgraph string, parent *ast.Definition, set ast.SelectionSet, path []string) ast.SelectionSet {
+	// Abstract types are forwarded as-is; their fields must all live in
+	// the subgraph that returned them
+	if parent.IsAbstractType() {
+		out := ast.SelectionSet{&ast.Field{Alias: "__typename", Name: "__typename"}}
+		return append(out, p.copySelections(fetch, set)...)
+	}
+
+	var out ast.SelectionSet
+	for _, field := range collectFields(p.supergraph.Schema, p.doc, parent, set, p.variables) {
+		if field.Name == "__typename" {
+			out = append(out, &ast.Field{Alias: field.Alias, Name: field.Name})
+			continue
+		}
+
+		fieldPath := append(append([]string(nil), path...), field.Alias)
+
+		if p.supergraph.resolves(subgraph, parent.Name, field.Name) {
+			out = append(out, p.planField(fetch, subgraph, field, fieldPath))
+			continue
+		}
+
+		// The field belongs to another subgraph: select the entity key here
+		// and resolve the field through an _entities hop
+		target, key, err := p.supergraph.entityRoute(parent.Name, field.Name, subgraph)
+		if err != nil {
+			p.err = err
+			continue
+		}
+
+		out = appendKey(out, key)
+		child := fetch.child(target, parent.Name, path)
+		child.selections = append(child.selections, p.planField(child, target, field, fieldPath))
+	}
+
+	return out
+}
+
+// copySelections deep copies a selection set, inlining named fragments
+func (p *planner) copySelections(fetch *Fetch, set ast.SelectionSet) ast.SelectionSet {
+	var out ast.SelectionSet
+	for _, sel := range set {
+		if !shouldInclude(directivesOf(sel), p.variables) {
+			continue
+		}
+
+		switch sel := sel.(type) {
+		case *ast.Field:
+			trackVariables(fetch, sel.Arguments)
+			out = append(out, &ast.Field{
+				Alias:        sel.Alias,
+				Name:         sel.Name,
+				Arguments:    sel.Arguments,
+				SelectionSet: p.copySelections(fetch, sel.SelectionSet),
+			})
+		case *ast.InlineFragment:
+			out = append(out, &ast.InlineFragment{
+				TypeCondition: sel.TypeCondition,
+				SelectionSet:  p.copySelections(fetch, sel.SelectionSet),
+			})
+		case *ast.FragmentSpread:
+			fragment := p.doc.Fragments.ForName(sel.Name)
+			if fragment == nil {
+				continue
+			}
+			out = append(out, &ast.InlineFragment{
+				TypeCondition: fragment.TypeCondition,
+				SelectionSet:  p.copySelections(fetch, fragment.SelectionSet),
+			})
+		}
+	}
+	return out
+}
+
+// finalize renders the subgraph documents of fetch and its children
+func (p *planner) finalize(fetch *Fetch) {
+	var definitions ast.VariableDefinitionList
+	for _, def := range p.op.VariableDefinitions {
+		if fetch.variables[def.Variable] {
+			definitions = append(definitions, def)
+			fetch.Variables = append(fetch.Variables, def.Variable)
+		}
+	}
+
+	op := &ast.OperationDefinition{
+		Operation:           p.op.Operation,
+		Name:                p.op.Name,
+		VariableDefinitions: definitions,
+		SelectionSet:        fetch.selections,
+	}
+
+	if fetch.IsEntityFetch() {
+		op.Operation = ast.Query
+		op.VariableDefinitions = append(ast.VariableDefinitionList{{
+			Variable: "representations",
+			Type:     ast.NonNullListType(ast.NonNullNamedType("_Any", nil), nil),
+		}}, definitions...)
+		op.SelectionSet = ast.SelectionSet{&ast.Field{
+			Alias: "_entities",
+			Name:  "_entities",
+			Arguments: ast.ArgumentList{{
+				Name:  "representations",
+				Value: &ast.Value{Kind: ast.Variable, Raw: "representations"},
+			}},
+			SelectionSet: ast.SelectionSet{&ast.InlineFragment{
+				TypeCondition: fetch.TypeName,
+				SelectionSet:  fetch.selections,
+			}},
+		}}
+	}
+
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf, formatter.WithIndent("  ")).FormatQueryDocument(&ast.QueryDocument{
+		Operations: ast.OperationList{op},
+	})
+	fetch.Query = buf.String()
+
+	for _, child := range fetch.Children {
+		p.finalize(child)
+	}
+}
+
+// collectFields flattens the fields selected on an object type, applying
+// fragments whose type condition matches and @skip/@include directives
+func collectFields(schema *ast.Schema, doc *ast.QueryDocument, parent *ast.Definition, set ast.SelectionSet, variables map[string]any) []*ast.Field {
+	var fields []*ast.Field
+	seen := make(map[string]*ast.Field)
+
+	var walk func(set ast.SelectionSet)
+	walk = func(set ast.SelectionSet) {
+		for _, sel := range set {
+			if !shouldInclude(directivesOf(sel), variables) {
+				continue
+			}
+
+			switch sel := sel.(type) {
+			case *ast.Field:
+				// Fields with the same response key are merged
+				if existing, ok := seen[sel.Alias]; ok {
+					merged := *existing
+					merged.SelectionSet = append(append(ast.SelectionSet{}, existing.SelectionSet...), sel.SelectionSet...)
+					seen[sel.Alias] = &merged
+					for i, field := range fields {
+						if field == existing {
+							fields[i] = &merged
+						}
+					}
+					continue
+				}
+				seen[sel.Alias] = sel
+				fields = append(fields, sel)
+			case *ast.InlineFragment:
+				if typeApplies(schema, parent, sel.TypeCondition) {
+					walk(sel.SelectionSet)
+				}
+			case *ast.FragmentSpread:
+				fragment := doc.Fragments.ForName(sel.Name)
+				if fragment != nil && typeApplies(schema, parent, fragment.TypeCondition) {
+					walk(fragment.SelectionSet)
+				}
+			}
+		}
+	}
+	walk(set)
+
+	return fields
+}
+
+// typeApplies reports whether a fragment on condition applies to def
+func typeApplies(schema *ast.Schema, def *ast.Definition, condition string) bool {
+	if condition == "" || condition == def.Name {
+		return true
+	}
+	conditionDef := schema.Types[condition]
+	if conditionDef == nil || !conditionDef.IsAbstractType() {
+		return false
+	}
+	for _, possible := range schema.GetPossibleTypes(conditionDef) {
+		if possible.Name == def.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldInclude evaluates the @skip and @include directives
+func shouldInclude(directives ast.DirectiveList, variables map[string]any) bool {
+	if skip := directives.ForName("skip"); skip != nil {
+		if value, _ := skip.ArgumentMap(variables)["if"].(bool); value {
+			return false
+		}
+	}
+	if include := directives.ForName("include"); include != nil {
+		if value, _ := include.ArgumentMap(variables)["if"].(bool); !value {
+			return false
+		}
+	}
+	return true
+}
+
+func directivesOf(sel ast.Selection) ast.DirectiveList {
+	switch sel := sel.(type) {
+	case *ast.Field:
+		return sel.Directives
+	case *ast.InlineFragment:
+		return sel.Directives
+	case *ast.FragmentSpread:
+		return sel.Directives
+	}
+	return nil
+}
+
+// appendKey makes sure __typename and the key fields are selected
+func appendKey(set ast.SelectionSet, key ast.SelectionSet) ast.SelectionSet {
+	if !hasField(set, "__typename") {
+		set = append(set, &ast.Field{Alias: "__typename", Name: "__typename"})
+	}
+	for _, sel := range key {
+		field, ok := sel.(*ast.Field)
+		if !ok || hasField(set, field.Name) {
+			continue
+		}
+		set = append(set, &ast.Field{Alias: field.Name, Name: field.Name, SelectionSet: field.SelectionSet})
+	}
+	return set
+}
+
+func hasField(set ast.SelectionSet, name string) bool {
+	for _, sel := range set {
+		if field, ok := sel.(*ast.Field); ok && field.Alias == name && field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// trackVariables records the variables referenced by a field's arguments
+func trackVariables(fetch *Fetch, args ast.ArgumentList) {
+	var walk func(value *ast.Value)
+	walk = func(value *ast.Value) {
+		if value == nil {
+			return
+		}
+		if value.Kind == ast.Variable {
+			fetch.variables[value.Raw] = true
+		}
+		for _, child := range value.Children {
+			walk(child.Value)
+		}
+	}
+	for _, arg := range args {
+		walk(arg.Value)
+	}
+}
+
+func rootType(schema *ast.Schema, operation ast.Operation) *ast.Definition {
+	switch operation {
+	case ast.Mutation:
+		return schema.Mutation
+	case ast.Subscription:
+		return schema.Subscription
+	default:
+		return schema.Query
+	}
+}
+
+func samePath(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package federation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2"
+)
+
+func planQuery(t *testing.T, query string) *QueryPlan {
+	t.Helper()
+
+	supergraph, err := Compose(testSubgraphs())
+	if err != nil {
+		t.Fatalf("Compose() error = %v", err)
+	}
+	doc, errs := gqlparser.LoadQuery(supergraph.Schema, query)
+	if len(errs) > 0 {
+		t.Fatalf("LoadQuery() errors = %v", errs)
+	}
+
+	plan, err := Plan(supergraph, doc, doc.Operations[0], nil)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	return plan
+}
+
+func TestPlanGroupsRootFieldsBySubgraph(t *testing.T) {
+	plan := planQuery(t, `{ users { id } products { id } user(id: "1") { name } }`)
+
+	if len(plan.Fetches) != 2 {
+		t.Fatalf("expected 2 root fetches, got %d", len(plan.Fetches))
+	}
+	if plan.Fetches[0].Subgraph != "users" || plan.Fetches[1].Subgraph != "products" {
+		t.Errorf("unexpected fetch order: %s, %s", plan.Fetches[0].Subgraph, plan.Fetches[1].Subgraph)
+	}
+	if !strings.Contains(plan.Fetches[0].Query, "user(id: \"1\")") {
+		t.Errorf("users fetch should contain both user fields, got:\n%s", plan.Fetches[0].Query)
+	}
+}
+
+func TestPlanEntityHopForOwner(t *testing.T) {
+	plan := planQuery(t, `{ products { name owner { name email } } }`)
+
+	if len(plan.Fetches) != 1 {
+		t.Fatalf("expected 1 root fetch, got %d", len(plan.Fetches))
+	}
+	root := plan.Fetches[0]
+	if root.Subgraph != "products" {
+		t.Fatalf("root fetch subgraph = %s, want products", root.Subgraph)
+	}
+	if strings.Contains(root.Query, "email") {
+		t.Errorf("products fetch must not ask for user fields, got:\n%s", root.Query)
+	}
+	if !strings.Contains(root.Query, "__typename") {
+		t.Errorf("products fetch must select the entity key, got:\n%s", root.Query)
+	}
+
+	if len(root.Children) != 1 {
+		t.Fatalf("expected 1 entity fetch, got %d", len(root.Children))
+	}
+	entity := root.Children[0]
+	if !entity.IsEntityFetch() || entity.Subgraph != "users" || entity.TypeName != "User" {
+		t.Errorf("unexpected entity fetch %+v", entity)
+	}
+	if strings.Join(entity.Path, ".") != "products.owner" {
+		t.Errorf("entity path = %v, want products.owner", entity.Path)
+	}
+	for _, want := range []string{"_entities(representations: $representations)", "... on User", "email"} {
+		if !strings.Contains(entity.Query, want) {
+			t.Errorf("entity query missing %q:\n%s", want, entity.Query)
+		}
+	}
+}
+
+func TestPlanKeyOnlySelectionStaysLocal(t *testing.T) {
+	plan := planQuery(t, `{ products { owner { id } } }`)
+
+	if len(plan.Fetches) != 1 || len(plan.Fetches[0].Children) != 0 {
+		t.Errorf("owner { id } should be answered by products alone")
+	}
+}
@@ -0,0 +1,359 @@
+package federation
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// Subgraph describes a federated service and the SDL it serves
+type Subgraph struct {
+	Name string
+	URL  string
+	SDL  string
+	// File is the schema file the SDL was read from, used in error locations
+	File string
+}
+
+// source is the name used for positions in the subgraph SDL
+func (s *Subgraph) source() string {
+	if s.File != "" {
+		return s.File
+	}
+	return s.Name
+}
+
+// Supergraph is the composed API schema plus the routing metadata the
+// planner needs to decide which subgraph resolves each field
+type Supergraph struct {
+	// Schema is the client facing schema, without federation internals
+	Schema *ast.Schema
+	// SDL is the printed form of Schema
+	SDL string
+
+	subgraphs map[string]*Subgraph
+	order     []string
+
+	// owners maps type -> field -> subgraphs able to resolve it
+	owners map[string]map[string][]string
+	// keys maps type -> subgraph -> resolvable @key field set
+	keys map[string]map[string]ast.SelectionSet
+	// graphs maps type -> subgraphs that declare it
+	graphs map[string][]string
+}
+
+// federationDirectives are stripped from the API schema
+var federationDirectives = map[string]bool{
+	"key":              true,
+	"link":             true,
+	"shareable":        true,
+	"external":         true,
+	"requires":         true,
+	"provides":         true,
+	"extends":          true,
+	"override":         true,
+	"inaccessible":     true,
+	"tag":              true,
+	"interfaceObject":  true,
+	"composeDirective": true,
+	"authenticated":    true,
+	"requiresScopes":   true,
+	"policy":           true,
+	"entityResolver":   true,
+}
+
+// Compose merges the subgraph schemas into a single supergraph. Subgraphs
+// that fail Validate are rejected with the list of Conflicts.
+func Compose(subgraphs []*Subgraph) (*Supergraph, error) {
+	if len(subgraphs) == 0 {
+		return nil, fmt.Errorf("no subgraphs to compose")
+	}
+
+	sg := newSupergraph()
+	for _, subgraph := range subgraphs {
+		if _, exists := sg.subgraphs[subgraph.Name]; exists {
+			return nil, fmt.Errorf("duplicate subgraph name %q", subgraph.Name)
+		}
+		sg.subgraphs[subgraph.Name] = subgraph
+	}
+
+	if conflicts := Validate(subgraphs); len(conflicts) > 0 {
+		return nil, conflicts
+	}
+
+	if err := sg.merge(subgraphs); err != nil {
+		return nil, err
+	}
+	return sg, nil
+}
+
+// SubgraphSchema loads the API schema of a single subgraph, without the
+// federation types and directives and without composition checks
+func SubgraphSchema(subgraph *Subgraph) (*ast.Schema, error) {
+	sg := newSupergraph()
+	sg.subgraphs[subgraph.Name] = subgraph
+	if err := sg.merge([]*Subgraph{subgraph}); err != nil {
+		return nil, err
+	}
+	return sg.Schema, nil
+}
+
+// merge builds the API schema and routing metadata of the subgraphs
+func (s *Supergraph) merge(subgraphs []*Subgraph) error {
+	merged := &ast.SchemaDocument{}
+	byName := make(map[string]*ast.Definition)
+
+	for _, subgraph := range subgraphs {
+		s.order = append(s.order, subgraph.Name)
+
+		doc, err := parser.ParseSchema(&ast.Source{Name: subgraph.source(), Input: subgraph.SDL})
+		if err != nil {
+			return fmt.Errorf("parsing %s schema: %w", subgraph.Name, err)
+		}
+
+		definitions := append(ast.DefinitionList{}, doc.Definitions...)
+		definitions = append(definitions, doc.Extensions...)
+
+		for _, def := range definitions {
+			if IsFederationType(def.Name) {
+				continue
+			}
+
+			if err := s.recordOwnership(subgraph.Name, def); err != nil {
+				return err
+			}
+
+			existing, ok := byName[def.Name]
+			if !ok {
+				existing = &ast.Definition{
+					Kind:        def.Kind,
+					Description: def.Description,
+					Name:        def.Name,
+					Position:    def.Position,
+				}
+				byName[def.Name] = existing
+				merged.Definitions = append(merged.Definitions, existing)
+			}
+			mergeDefinition(existing, def)
+		}
+	}
+
+	return s.load(merged)
+}
+
+func newSupergraph() *Supergraph {
+	return &Supergraph{
+		subgraphs: make(map[string]*Subgraph),
+		owners:    make(map[string]map[string][]string),
+		keys:      make(map[string]map[string]ast.SelectionSet),
+		graphs:    make(map[string][]string),
+	}
+}
+
+// load prints the merged API schema and validates it
+func (s *Supergraph) load(doc *ast.SchemaDocument) error {
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf).FormatSchemaDocument(doc)
+	s.SDL = buf.String()
+
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "supergraph", Input: s.SDL})
+	if err != nil {
+		return fmt.Errorf("composing supergraph: %w", err)
+	}
+	s.Schema = schema
+	return nil
+}
+
+// Subgraph returns the subgraph registered under name
+func (s *Supergraph) Subgraph(name string) *Subgraph {
+	return s.subgraphs[name]
+}
+
+// Subgraphs returns the subgraphs in composition order
+func (s *Supergraph) Subgraphs() []*Subgraph {
+	subgraphs := make([]*Subgraph, 0, len(s.order))
+	for _, name := range s.order {
+		subgraphs = append(subgraphs, s.subgraphs[name])
+	}
+	return subgraphs
+}
+
+// Owners returns the subgraphs able to resolve typeName.fieldName
+func (s *Supergraph) Owners(typeName, fieldName string) []string {
+	return s.owners[typeName][fieldName]
+}
+
+// Key returns the field set subgraph uses to resolve typeName entities
+func (s *Supergraph) Key(typeName, subgraph string) (ast.SelectionSet, bool) {
+	key, ok := s.keys[typeName][subgraph]
+	return key, ok
+}
+
+// resolves reports whether subgraph can resolve typeName.fieldName
+func (s *Supergraph) resolves(subgraph, typeName, fieldName string) bool {
+	for _, owner := range s.owners[typeName][fieldName] {
+		if owner == subgraph {
+			return true
+		}
+	}
+	return false
+}
+
+// entityRoute finds a subgraph that resolves typeName.fieldName through
+// _entities, along with the key the current subgraph must provide for it
+func (s *Supergraph) entityRoute(typeName, fieldName, from string) (string, ast.SelectionSet, error) {
+	for _, owner := range s.owners[typeName][fieldName] {
+		key, ok := s.keys[typeName][owner]
+		if !ok {
+			continue
+		}
+		if s.providesKey(from, typeName, key) {
+			return owner, key, nil
+		}
+	}
+	return "", nil, fmt.Errorf("no subgraph can resolve %s.%s from %s", typeName, fieldName, from)
+}
+
+// providesKey reports whether subgraph can return every field of key
+func (s *Supergraph) providesKey(subgraph, typeName string, key ast.SelectionSet) bool {
+	for _, sel := range key {
+		field, ok := sel.(*ast.Field)
+		if !ok || !s.resolves(subgraph, typeName, field.Name) {
+			return false
+		}
+		if len(field.SelectionSet) == 0 {
+			continue
+		}
+		def := s.Schema.Types[typeName]
+		if def == nil || def.Fields.ForName(field.Name) == nil {
+			return false
+		}
+		if !s.providesKey(subgraph, def.Fields.ForName(field.Name).Type.Name(), field.SelectionSet) {
+			return false
+		}
+	}
+	return true
+}
+
+// recordOwnership tracks the fields and entity keys a subgraph contributes
+func (s *Supergraph) recordOwnership(subgraph string, def *ast.Definition) error {
+	s.graphs[def.Name] = appendUnique(s.graphs[def.Name], subgraph)
+
+	if def.Kind != ast.Object && def.Kind != ast.Interface {
+		return nil
+	}
+
+	for _, dir := range def.Directives.ForNames("key") {
+		fields := dir.Arguments.ForName("fields")
+		if fields == nil || fields.Value == nil {
+			return fmt.Errorf("%s: @key on %s is missing fields", subgraph, def.Name)
+		}
+		key, err := ParseFieldSet(fields.Value.Raw)
+		if err != nil {
+			return fmt.Errorf("%s: invalid @key on %s: %w", subgraph, def.Name, err)
+		}
+
+		resolvable := dir.Arguments.ForName("resolvable")
+		if resolvable != nil && resolvable.Value != nil && resolvable.Value.Raw == "false" {
+			continue
+		}
+		if s.keys[def.Name] == nil {
+			s.keys[def.Name] = make(map[string]ast.SelectionSet)
+		}
+		if _, exists := s.keys[def.Name][subgraph]; !exists {
+			s.keys[def.Name][subgraph] = key
+		}
+	}
+
+	if s.owners[def.Name] == nil {
+		s.owners[def.Name] = make(map[string][]string)
+	}
+	for _, field := range def.Fields {
+		if strings.HasPrefix(field.Name, "_") || field.Directives.ForName("external") != nil {
+			continue
+		}
+		s.owners[def.Name][field.Name] = appendUnique(s.owners[def.Name][field.Name], subgraph)
+	}
+
+	return nil
+}
+
+// mergeDefinition adds the members of src missing from dst
+func mergeDefinition(dst, src *ast.Definition) {
+	if dst.Description == "" {
+		dst.Description = src.Description
+	}
+
+	for _, field := range src.Fields {
+		if strings.HasPrefix(field.Name, "_") || dst.Fields.ForName(field.Name) != nil {
+			continue
+		}
+		copied := *field
+		copied.Directives = apiDirectives(field.Directives)
+		dst.Fields = append(dst.Fields, &copied)
+	}
+
+	for _, value := range src.EnumValues {
+		if dst.EnumValues.ForName(value.Name) == nil {
+			dst.EnumValues = append(dst.EnumValues, value)
+		}
+	}
+
+	for _, member := range src.Types {
+		if !contains(dst.Types, member) {
+			dst.Types = append(dst.Types, member)
+		}
+	}
+
+	for _, iface := range src.Interfaces {
+		if !contains(dst.Interfaces, iface) {
+			dst.Interfaces = append(dst.Interfaces, iface)
+		}
+	}
+}
+
+// apiDirectives drops federation directives from a field definition
+func apiDirectives(list ast.DirectiveList) ast.DirectiveList {
+	var out ast.DirectiveList
+	for _, dir := range list {
+		if !federationDirectives[dir.Name] {
+			out = append(out, dir)
+		}
+	}
+	return out
+}
+
+// IsFederationType reports whether a type only exists to support federation
+func IsFederationType(name string) bool {
+	switch name {
+	case "_Any", "_FieldSet", "FieldSet", "_Service", "_Entity", "Entity":
+		return true
+	}
+	return strings.HasPrefix(name, "link__") || strings.HasPrefix(name, "federation__")
+}
+
+// ParseFieldSet parses a @key fields argument such as "id" or "id sku"
+func ParseFieldSet(fields string) (ast.SelectionSet, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Name: "fieldset", Input: "{" + fields + "}"})
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Operations) != 1 || len(doc.Operations[0].SelectionSet) == 0 {
+		return nil, fmt.Errorf("empty field set %q", fields)
+	}
+	return doc.Operations[0].SelectionSet, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
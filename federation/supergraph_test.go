@@ -0,0 +1,112 @@
+package federation
+
+import (
+	"strings"
+	"testing"
+)
+
+const usersSDL = `
+extend schema
+  @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@key"])
+
+type User @key(fields: "id") {
+  id: ID!
+  name: String!
+  email: String!
+}
+
+type Query {
+  users: [User!]!
+  user(id: ID!): User
+}
+`
+
+const productsSDL = `
+extend schema
+  @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@key"])
+
+type Product @key(fields: "id") {
+  id: ID!
+  name: String!
+  price: Float!
+  owner: User!
+}
+
+type User @key(fields: "id", resolvable: false) {
+  id: ID!
+}
+
+type Query {
+  products: [Product!]!
+  product(id: ID!): Product
+}
+`
+
+func testSubgraphs() []*Subgraph {
+	return []*Subgraph{
+		{Name: "users", URL: "http://users", SDL: usersSDL},
+		{Name: "products", URL: "http://products", SDL: productsSDL},
+	}
+}
+
+func TestCompose(t *testing.T) {
+	supergraph, err := Compose(testSubgraphs())
+	if err != nil {
+		t.Fatalf("Compose() error = %v", err)
+	}
+
+	query := supergraph.Schema.Query
+	for _, field := range []string{"users", "user", "products", "product"} {
+		if query.Fields.ForName(field) == nil {
+			t.Errorf("Query.%s missing from supergraph", field)
+		}
+	}
+
+	user := supergraph.Schema.Types["User"]
+	for _, field := range []string{"id", "name", "email"} {
+		if user.Fields.ForName(field) == nil {
+			t.Errorf("User.%s missing from supergraph", field)
+		}
+	}
+
+	if strings.Contains(supergraph.SDL, "@key") || strings.Contains(supergraph.SDL, "_Any") {
+		t.Errorf("supergraph SDL leaks federation internals:\n%s", supergraph.SDL)
+	}
+
+	if owners := supergraph.Owners("User", "name"); len(owners) != 1 || owners[0] != "users" {
+		t.Errorf("Owners(User, name) = %v, want [users]", owners)
+	}
+	if owners := supergraph.Owners("User", "id"); len(owners) != 2 {
+		t.Errorf("Owners(User, id) = %v, want both subgraphs", owners)
+	}
+
+	if _, ok := supergraph.Key("User", "users"); !ok {
+		t.Error("expected users to resolve User entities")
+	}
+	if _, ok := supergraph.Key("User", "products"); ok {
+		t.Error("products declares User with resolvable: false and must not be routed to")
+	}
+}
+
+func TestComposeErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		subgraphs []*Subgraph
+	}{
+		{"no subgraphs", nil},
+		{"duplicate name", []*Subgraph{
+			{Name: "users", SDL: usersSDL},
+			{Name: "users", SDL: usersSDL},
+		}},
+		{"invalid SDL", []*Subgraph{{Name: "users", SDL: "type User {"}}},
+		{"invalid key", []*Subgraph{{Name: "users", SDL: `type User @key(fields: "{") { id: ID! } type Query { a: User }`}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compose(tt.subgraphs); err == nil {
+				t.Error("Compose() expected an error")
+			}
+		})
+	}
+}
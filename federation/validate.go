@@ -0,0 +1,404 @@
+package federation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// Location points at a definition in a subgraph schema file
+type Location struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+}
+
+// Conflict is a composition problem found while validating subgraphs
+type Conflict struct {
+	Message   string
+	Locations []Location
+}
+
+func (c *Conflict) Error() string {
+	if len(c.Locations) == 0 {
+		return c.Message
+	}
+
+	msg := c.Locations[0].String() + ": " + c.Message
+	if len(c.Locations) > 1 {
+		others := make([]string, 0, len(c.Locations)-1)
+		for _, loc := range c.Locations[1:] {
+			others = append(others, loc.String())
+		}
+		msg += " (see also " + strings.Join(others, ", ") + ")"
+	}
+	return msg
+}
+
+// Conflicts is the list of problems that prevent composition
+type Conflicts []*Conflict
+
+func (c Conflicts) Error() string {
+	msgs := make([]string, len(c))
+	for i, conflict := range c {
+		msgs[i] = conflict.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// subgraphDefinition is a type definition (or extension) in one subgraph
+type subgraphDefinition struct {
+	subgraph string
+	def      *ast.Definition
+}
+
+type schemaValidator struct {
+	conflicts Conflicts
+	// types maps subgraph -> type -> definitions and extensions
+	types map[string]map[string][]*ast.Definition
+	// shared maps type -> definitions across every subgraph
+	shared map[string][]subgraphDefinition
+	order  []string
+}
+
+// Validate checks that the subgraph schemas agree with each other: key
+// fields exist and are selectable, shared types have the same shape and
+// fields resolved by more than one subgraph are marked @shareable
+func Validate(subgraphs []*Subgraph) Conflicts {
+	v := &schemaValidator{
+		types:  make(map[string]map[string][]*ast.Definition),
+		shared: make(map[string][]subgraphDefinition),
+	}
+
+	for _, subgraph := range subgraphs {
+		doc, err := parser.ParseSchema(&ast.Source{Name: subgraph.source(), Input: subgraph.SDL})
+		if err != nil {
+			v.parseError(subgraph, err)
+			continue
+		}
+
+		types := make(map[string][]*ast.Definition)
+		definitions := append(ast.DefinitionList{}, doc.Definitions...)
+		definitions = append(definitions, doc.Extensions...)
+		for _, def := range definitions {
+			if IsFederationType(def.Name) {
+				continue
+			}
+			if _, seen := types[def.Name]; !seen {
+				v.order = appendUnique(v.order, def.Name)
+			}
+			types[def.Name] = append(types[def.Name], def)
+			v.shared[def.Name] = append(v.shared[def.Name], subgraphDefinition{subgraph.Name, def})
+		}
+		v.types[subgraph.Name] = types
+	}
+
+	for _, name := range v.order {
+		defs := v.shared[name]
+		for _, sd := range defs {
+			v.checkKeys(sd.subgraph, sd.def)
+		}
+		v.checkSharedType(name, defs)
+		v.checkEntityResolvable(name, defs)
+	}
+
+	return v.conflicts
+}
+
+func (v *schemaValidator) report(message string, positions ...*ast.Position) {
+	conflict := &Conflict{Message: message}
+	for _, pos := range positions {
+		if pos == nil {
+			continue
+		}
+		loc := Location{Line: pos.Line, Column: pos.Column}
+		if pos.Src != nil {
+			loc.File = pos.Src.Name
+		}
+		conflict.Locations = append(conflict.Locations, loc)
+	}
+	v.conflicts = append(v.conflicts, conflict)
+}
+
+func (v *schemaValidator) parseError(subgraph *Subgraph, err error) {
+	conflict := &Conflict{Message: err.Error()}
+
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) {
+		conflict.Message = gqlErr.Message
+		for _, loc := range gqlErr.Locations {
+			conflict.Locations = append(conflict.Locations, Location{File: subgraph.source(), Line: loc.Line, Column: loc.Column})
+		}
+	}
+	if len(conflict.Locations) == 0 {
+		conflict.Locations = []Location{{File: subgraph.source()}}
+	}
+	v.conflicts = append(v.conflicts, conflict)
+}
+
+// checkKeys validates every @key field set of a definition against the
+// fields the same subgraph declares
+func (v *schemaValidator) checkKeys(subgraph string, def *ast.Definition) {
+	for _, dir := range def.Directives.ForNames("key") {
+		fields := dir.Arguments.ForName("fields")
+		if fields == nil || fields.Value == nil {
+			v.report(fmt.Sprintf("@key on %s in %s is missing the fields argument", def.Name, subgraph), dir.Position)
+			continue
+		}
+
+		key, err := ParseFieldSet(fields.Value.Raw)
+		if err != nil {
+			v.report(fmt.Sprintf("@key(fields: %q) on %s in %s is not a valid field set: %s", fields.Value.Raw, def.Name, subgraph, err), fields.Value.Position)
+			continue
+		}
+		v.checkFieldSet(subgraph, def.Name, key, fields.Value.Position)
+	}
+}
+
+func (v *schemaValidator) checkFieldSet(subgraph, typeName string, set ast.SelectionSet, pos *ast.Position) {
+	for _, sel := range set {
+		selected, ok := sel.(*ast.Field)
+		if !ok {
+			v.report(fmt.Sprintf("@key on %s in %s must only select fields", typeName, subgraph), pos)
+			continue
+		}
+
+		field := v.field(subgraph, typeName, selected.Name)
+		if field == nil {
+			v.report(fmt.Sprintf("@key on %s in %s selects unknown field %q", typeName, subgraph, selected.Name), pos)
+			continue
+		}
+		if len(field.Arguments) > 0 {
+			v.report(fmt.Sprintf("@key on %s in %s selects %q, which takes arguments", typeName, subgraph, selected.Name), field.Position)
+			continue
+		}
+
+		fieldType := field.Type.Name()
+		composite := false
+		for _, def := range v.types[subgraph][fieldType] {
+			if def.Kind == ast.Object || def.Kind == ast.Interface || def.Kind == ast.Union {
+				composite = true
+			}
+		}
+
+		switch {
+		case composite && len(selected.SelectionSet) == 0:
+			v.report(fmt.Sprintf("@key on %s in %s must select subfields of %q", typeName, subgraph, selected.Name), pos)
+		case !composite && len(selected.SelectionSet) > 0:
+			v.report(fmt.Sprintf("@key on %s in %s selects subfields of leaf field %q", typeName, subgraph, selected.Name), pos)
+		case composite:
+			v.checkFieldSet(subgraph, fieldType, selected.SelectionSet, pos)
+		}
+	}
+}
+
+func (v *schemaValidator) field(subgraph, typeName, fieldName string) *ast.FieldDefinition {
+	for _, def := range v.types[subgraph][typeName] {
+		if field := def.Fields.ForName(fieldName); field != nil {
+			return field
+		}
+	}
+	return nil
+}
+
+// checkSharedType compares the definitions of a type declared by more
+// than one subgraph
+func (v *schemaValidator) checkSharedType(name string, defs []subgraphDefinition) {
+	first := defs[0]
+	for _, other := range defs[1:] {
+		if other.def.Kind != first.def.Kind {
+			v.report(fmt.Sprintf("%s is declared as %s in %s but %s in %s", name,
+				first.def.Kind, first.subgraph, other.def.Kind, other.subgraph),
+				first.def.Position, other.def.Position)
+			return
+		}
+	}
+
+	switch first.def.Kind {
+	case ast.Object, ast.Interface, ast.InputObject:
+		v.checkSharedFields(name, defs)
+	case ast.Enum:
+		v.checkSharedEnum(name, defs)
+	}
+}
+
+func (v *schemaValidator) checkSharedFields(name string, defs []subgraphDefinition) {
+	type declaration struct {
+		subgraph string
+		def      *ast.Definition
+		field    *ast.FieldDefinition
+	}
+
+	byField := make(map[string][]declaration)
+	var fieldOrder []string
+	for _, sd := range defs {
+		for _, field := range sd.def.Fields {
+			if strings.HasPrefix(field.Name, "_") {
+				continue
+			}
+			if _, seen := byField[field.Name]; !seen {
+				fieldOrder = append(fieldOrder, field.Name)
+			}
+			byField[field.Name] = append(byField[field.Name], declaration{sd.subgraph, sd.def, field})
+		}
+	}
+
+	subgraphs := make(map[string]bool)
+	for _, sd := range defs {
+		subgraphs[sd.subgraph] = true
+	}
+
+	for _, fieldName := range fieldOrder {
+		decls := byField[fieldName]
+		first := decls[0]
+
+		for _, other := range decls[1:] {
+			if other.field.Type.String() != first.field.Type.String() {
+				v.report(fmt.Sprintf("%s.%s has type %s in %s but %s in %s", name, fieldName,
+					first.field.Type, first.subgraph, other.field.Type, other.subgraph),
+					first.field.Position, other.field.Position)
+			}
+			if argumentsSignature(other.field.Arguments) != argumentsSignature(first.field.Arguments) {
+				v.report(fmt.Sprintf("%s.%s has different arguments in %s and %s", name, fieldName,
+					first.subgraph, other.subgraph),
+					first.field.Position, other.field.Position)
+			}
+		}
+
+		if first.def.Kind == ast.InputObject && len(subgraphs) > 1 {
+			declared := make(map[string]bool)
+			for _, decl := range decls {
+				declared[decl.subgraph] = true
+			}
+			for _, sd := range defs {
+				if !declared[sd.subgraph] {
+					v.report(fmt.Sprintf("input field %s.%s is declared in %s but missing in %s", name, fieldName,
+						first.subgraph, sd.subgraph),
+						first.field.Position, sd.def.Position)
+					break
+				}
+			}
+		}
+
+		if first.def.Kind != ast.Object {
+			continue
+		}
+
+		// A field resolved by several subgraphs must be shareable
+		// everywhere, unless it is part of the entity key
+		var resolvers []declaration
+		for _, decl := range decls {
+			if decl.field.Directives.ForName("external") == nil {
+				resolvers = append(resolvers, decl)
+			}
+		}
+		if len(resolvers) < 2 {
+			continue
+		}
+
+		var unshareable []string
+		var positions []*ast.Position
+		for _, decl := range resolvers {
+			positions = append(positions, decl.field.Position)
+			if !v.shareable(decl.subgraph, name, fieldName) {
+				unshareable = append(unshareable, decl.subgraph)
+			}
+		}
+		if len(unshareable) > 0 {
+			names := make([]string, len(resolvers))
+			for i, decl := range resolvers {
+				names[i] = decl.subgraph
+			}
+			v.report(fmt.Sprintf("%s.%s is resolved by %s but is not @shareable in %s", name, fieldName,
+				strings.Join(names, " and "), strings.Join(unshareable, ", ")),
+				positions...)
+		}
+	}
+}
+
+// shareable reports whether subgraph marks typeName.fieldName as shareable,
+// either directly, on the type or by using it in a @key
+func (v *schemaValidator) shareable(subgraph, typeName, fieldName string) bool {
+	for _, def := range v.types[subgraph][typeName] {
+		if def.Directives.ForName("shareable") != nil {
+			return true
+		}
+		if field := def.Fields.ForName(fieldName); field != nil && field.Directives.ForName("shareable") != nil {
+			return true
+		}
+		for _, dir := range def.Directives.ForNames("key") {
+			fields := dir.Arguments.ForName("fields")
+			if fields == nil || fields.Value == nil {
+				continue
+			}
+			key, err := ParseFieldSet(fields.Value.Raw)
+			if err == nil && hasField(key, fieldName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *schemaValidator) checkSharedEnum(name string, defs []subgraphDefinition) {
+	values := func(def *ast.Definition) string {
+		names := make([]string, len(def.EnumValues))
+		for i, value := range def.EnumValues {
+			names[i] = value.Name
+		}
+		sort.Strings(names)
+		return strings.Join(names, ", ")
+	}
+
+	first := defs[0]
+	for _, other := range defs[1:] {
+		if values(other.def) != values(first.def) {
+			v.report(fmt.Sprintf("enum %s has values [%s] in %s but [%s] in %s", name,
+				values(first.def), first.subgraph, values(other.def), other.subgraph),
+				first.def.Position, other.def.Position)
+		}
+	}
+}
+
+// checkEntityResolvable makes sure a type referenced by key can be
+// resolved by at least one subgraph
+func (v *schemaValidator) checkEntityResolvable(name string, defs []subgraphDefinition) {
+	var keyed []*ast.Position
+	for _, sd := range defs {
+		for _, dir := range sd.def.Directives.ForNames("key") {
+			keyed = append(keyed, dir.Position)
+			resolvable := dir.Arguments.ForName("resolvable")
+			if resolvable == nil || resolvable.Value == nil || resolvable.Value.Raw != "false" {
+				return
+			}
+		}
+	}
+	if len(keyed) > 0 {
+		v.report(fmt.Sprintf("entity %s is only referenced with resolvable: false, no subgraph can resolve it", name), keyed...)
+	}
+}
+
+func argumentsSignature(args ast.ArgumentDefinitionList) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = arg.Name + ": " + arg.Type.String()
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+func appendUnique(list []string, value string) []string {
+	if contains(list, value) {
+		return list
+	}
+	return append(list, value)
+}
@@ -0,0 +1,108 @@
+package federation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateRepoSchemas(t *testing.T) {
+	if conflicts := Validate(testSubgraphs()); len(conflicts) > 0 {
+		t.Errorf("unexpected conflicts:\n%s", conflicts)
+	}
+}
+
+func TestValidateConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		users   string
+		want    string
+		wantLoc string
+	}{
+		{
+			name:    "unknown key field",
+			users:   `type User @key(fields: "uuid") { id: ID! name: String! } type Query { users: [User!]! }`,
+			want:    `selects unknown field "uuid"`,
+			wantLoc: "users.graphqls:1:",
+		},
+		{
+			name:    "key field with subfields on a leaf",
+			users:   `type User @key(fields: "id { value }") { id: ID! } type Query { users: [User!]! }`,
+			want:    `subfields of leaf field "id"`,
+			wantLoc: "users.graphqls:1:",
+		},
+		{
+			name: "shared field type mismatch",
+			users: `type User @key(fields: "id") {
+  id: String!
+  name: String!
+}
+type Query { users: [User!]! }`,
+			want:    "User.id has type String! in users but ID! in products",
+			wantLoc: "users.graphqls:2:3",
+		},
+		{
+			name: "field resolved twice without @shareable",
+			users: `type User @key(fields: "id") { id: ID! name: String! }
+type Query { users: [User!]! products: [User!]! }`,
+			want:    "Query.products is resolved by users and products but is not @shareable",
+			wantLoc: "users.graphqls:2:",
+		},
+		{
+			name: "kind mismatch",
+			users: `type User @key(fields: "id") { id: ID! }
+enum Product { A }
+type Query { users: [User!]! }`,
+			want:    "Product is declared as ENUM in users but OBJECT in products",
+			wantLoc: "users.graphqls:2:",
+		},
+		{
+			name:  "entity nobody resolves",
+			users: `type Query { ping: String }`,
+			want:  "entity User is only referenced with resolvable: false",
+		},
+		{
+			name:    "syntax error",
+			users:   "type User {\n  id: ID!\n",
+			want:    "Expected Name",
+			wantLoc: "users.graphqls:3:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := Validate([]*Subgraph{
+				{Name: "users", SDL: tt.users, File: "users.graphqls"},
+				{Name: "products", SDL: productsSDL, File: "products.graphqls"},
+			})
+			if len(conflicts) == 0 {
+				t.Fatal("expected conflicts")
+			}
+
+			var found *Conflict
+			for _, conflict := range conflicts {
+				if strings.Contains(conflict.Message, tt.want) {
+					found = conflict
+				}
+			}
+			if found == nil {
+				t.Fatalf("no conflict containing %q in:\n%s", tt.want, conflicts)
+			}
+			if tt.wantLoc != "" && !strings.HasPrefix(found.Error(), tt.wantLoc) {
+				t.Errorf("conflict location = %q, want prefix %q", found.Error(), tt.wantLoc)
+			}
+		})
+	}
+}
+
+func TestComposeReturnsConflicts(t *testing.T) {
+	_, err := Compose([]*Subgraph{
+		{Name: "users", SDL: `type User @key(fields: "id") { id: Int! } type Query { users: [User] }`},
+		{Name: "products", SDL: productsSDL},
+	})
+
+	var conflicts Conflicts
+	if !errors.As(err, &conflicts) {
+		t.Fatalf("Compose() error = %v, want Conflicts", err)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go_fed/federation"
+	"go_fed/logger"
+
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPort         = "4000"
+	defaultPollInterval = 10 * time.Second
+	// federationTokenHeader matches extensions.FederationTokenHeader in the services
+	federationTokenHeader = "X-Federation-Token"
+)
+
+func main() {
+	port := os.Getenv("GATEWAY_PORT")
+	if port == "" {
+		port = defaultPort
+	}
+
+	logger := logger.SetupLogger("gateway")
+
+	subgraphs := subgraphsFromEnv()
+	client := &http.Client{Timeout: 30 * time.Second}
+	if token := os.Getenv("FEDERATION_TOKEN"); token != "" {
+		// Subgraphs in strict safelist mode only run _service and _entities with the token
+		client.Transport = tokenTransport{token: token, next: http.DefaultTransport}
+	}
+	gateway := federation.NewGateway(subgraphs, client)
+
+	if path := os.Getenv("GATEWAY_SUPERGRAPH"); path != "" {
+		// A supergraph written by cmd/compose pins the schema the gateway serves
+		if err := loadSupergraphFile(gateway, path); err != nil {
+			logger.WithError(err).WithField("path", path).Fatal("Failed to load supergraph")
+		}
+		logger.WithField("path", path).Info("Supergraph loaded from file")
+	} else {
+		// Compose in the background: subgraphs may start after the gateway, and
+		// polling picks up schema changes without restarting or changing code
+		go pollSupergraph(context.Background(), gateway, logger, pollIntervalFromEnv())
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", healthHandler(gateway))
+	mux.Handle("/metrics", promhttp.Handler())
+
+	playgroundHandler := playground.Handler("Go Fed Gateway", "/")
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Query().Get("query") == "" &&
+			strings.Contains(r.Header.Get("Accept"), "text/html") &&
+			os.Getenv("GRAPHQL_PLAYGROUND_ENABLED") != "false" {
+			playgroundHandler.ServeHTTP(w, r)
+			return
+		}
+		gateway.ServeHTTP(w, r)
+	})
+
+	names := make([]string, 0, len(subgraphs))
+	for _, subgraph := range subgraphs {
+		names = append(names, subgraph.Name+"="+subgraph.URL)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"port":      port,
+		"subgraphs": names,
+		"endpoints": []string{
+			"http://localhost:" + port + "/ (GraphQL Gateway)",
+			"http://localhost:" + port + "/healthz (Health Check)",
+			"http://localhost:" + port + "/metrics (Prometheus Metrics)",
+		},
+	}).Info("Federation gateway starting")
+
+	if err := http.ListenAndServe(":"+port, mux); err != nil {
+		logger.WithError(err).Fatal("Failed to start server")
+	}
+}
+
+// tokenTransport adds the gateway credential to every subgraph request
+type tokenTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(federationTokenHeader, t.token)
+	return t.next.RoundTrip(req)
+}
+
+// pollSupergraph composes the supergraph and keeps it up to date
+func pollSupergraph(ctx context.Context, gateway *federation.Gateway, logger *logrus.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastSDL string
+	for {
+		if err := gateway.Load(ctx); err != nil {
+			logger.WithError(err).Warn("Failed to compose supergraph")
+		} else if sdl := gateway.Supergraph().SDL; sdl != lastSDL {
+			lastSDL = sdl
+			logger.WithField("types", len(gateway.Supergraph().Schema.Types)).Info("Supergraph composed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// loadSupergraphFile activates a supergraph SDL written by cmd/compose.
+// Subgraph URLs from GATEWAY_SUBGRAPHS override the ones in the file.
+func loadSupergraphFile(gateway *federation.Gateway, path string) error {
+	sdl, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	supergraph, err := federation.ParseSupergraph(string(sdl))
+	if err != nil {
+		return err
+	}
+
+	if os.Getenv("GATEWAY_SUBGRAPHS") != "" {
+		for _, override := range subgraphsFromEnv() {
+			if subgraph := supergraph.Subgraph(override.Name); subgraph != nil {
+				subgraph.URL = override.URL
+			}
+		}
+	}
+	for _, subgraph := range supergraph.Subgraphs() {
+		if subgraph.URL == "" {
+			return fmt.Errorf("subgraph %s has no URL", subgraph.Name)
+		}
+	}
+
+	gateway.SetSupergraph(supergraph)
+	return nil
+}
+
+// subgraphsFromEnv reads GATEWAY_SUBGRAPHS ("users=http://...,products=http://...")
+// and falls back to USERS_SERVICE_URL and PRODUCTS_SERVICE_URL
+func subgraphsFromEnv() []*federation.Subgraph {
+	if raw := os.Getenv("GATEWAY_SUBGRAPHS"); raw != "" {
+		var subgraphs []*federation.Subgraph
+		for _, entry := range strings.Split(raw, ",") {
+			name, url, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok || name == "" || url == "" {
+				continue
+			}
+			subgraphs = append(subgraphs, &federation.Subgraph{Name: name, URL: url})
+		}
+		return subgraphs
+	}
+
+	usersURL := os.Getenv("USERS_SERVICE_URL")
+	if usersURL == "" {
+		usersURL = "http://localhost:8081/query"
+	}
+	productsURL := os.Getenv("PRODUCTS_SERVICE_URL")
+	if productsURL == "" {
+		productsURL = "http://localhost:8082/query"
+	}
+
+	return []*federation.Subgraph{
+		{Name: "users", URL: usersURL},
+		{Name: "products", URL: productsURL},
+	}
+}
+
+func pollIntervalFromEnv() time.Duration {
+	if interval, err := time.ParseDuration(os.Getenv("GATEWAY_POLL_INTERVAL")); err == nil && interval > 0 {
+		return interval
+	}
+	return defaultPollInterval
+}
+
+// healthHandler reports whether a supergraph has been composed
+func healthHandler(gateway *federation.Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, code := "healthy", http.StatusOK
+		if gateway.Supergraph() == nil {
+			status, code = "composing", http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    status,
+			"timestamp": time.Now(),
+			"service":   "gateway",
+			"version":   "1.0.0",
+		})
+	}
+}
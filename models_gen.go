@@ -1,6 +0,0 @@
-// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
-
-package go_fed
-
-type Query struct {
-}
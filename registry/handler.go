@@ -0,0 +1,255 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go_fed/schemadiff"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PushRequest is the body subgraphs send to register their schema
+type PushRequest struct {
+	URL string `json:"url"`
+	SDL string `json:"sdl"`
+}
+
+// PushResponse is returned for accepted pushes
+type PushResponse struct {
+	*Version
+	Created bool `json:"created"`
+}
+
+// ErrorResponse describes a rejected request
+type ErrorResponse struct {
+	Error     string      `json:"error"`
+	Conflicts []*Conflict `json:"conflicts,omitempty"`
+}
+
+// Conflict is a composition conflict returned to the pushing subgraph
+type Conflict struct {
+	Message   string   `json:"message"`
+	Locations []string `json:"locations,omitempty"`
+}
+
+// DiffResponse is the diff between two versions of a subgraph schema
+type DiffResponse struct {
+	Subgraph string     `json:"subgraph"`
+	From     int        `json:"from"`
+	To       int        `json:"to"`
+	Lines    []DiffLine `json:"lines"`
+	// Changes classifies the diff into breaking, dangerous and safe changes
+	Changes []*schemadiff.Change `json:"changes"`
+}
+
+// NewHandler exposes the registry over HTTP:
+//
+//	GET  /subgraphs                          latest version of every subgraph
+//	POST /subgraphs/{name}/schemas           push a schema
+//	GET  /subgraphs/{name}/schemas           version history
+//	GET  /subgraphs/{name}/schemas/{version} a single version with its SDL
+//	GET  /subgraphs/{name}/diff?from=&to=    diff between two versions
+//	GET  /supergraph                         composed supergraph SDL
+func NewHandler(registry *Registry, logger *logrus.Logger) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /subgraphs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, withoutSDL(registry.Subgraphs()))
+	})
+
+	mux.HandleFunc("POST /subgraphs/{name}/schemas", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+
+		var req PushRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			RecordPush(name, "invalid")
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid request body: " + err.Error()})
+			return
+		}
+
+		version, created, err := registry.Push(name, req.URL, req.SDL)
+		if err != nil {
+			var compositionErr *CompositionError
+			if errors.As(err, &compositionErr) {
+				RecordPush(name, "rejected")
+				logger.WithFields(logrus.Fields{
+					"subgraph":  name,
+					"conflicts": len(compositionErr.Conflicts),
+				}).Warn("Schema push rejected")
+				writeJSON(w, http.StatusConflict, compositionResponse(compositionErr))
+				return
+			}
+
+			RecordPush(name, "error")
+			logger.WithError(err).WithField("subgraph", name).Error("Schema push failed")
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		status := http.StatusOK
+		result := "unchanged"
+		if created {
+			status = http.StatusCreated
+			result = "created"
+			SchemaVersions.WithLabelValues(name).Set(float64(version.Version))
+		}
+		RecordPush(name, result)
+
+		logger.WithFields(logrus.Fields{
+			"subgraph": name,
+			"version":  version.Version,
+			"hash":     version.Hash,
+			"created":  created,
+		}).Info("Schema pushed")
+
+		writeJSON(w, status, PushResponse{Version: version, Created: created})
+	})
+
+	mux.HandleFunc("GET /subgraphs/{name}/schemas", func(w http.ResponseWriter, r *http.Request) {
+		history, err := registry.History(r.PathValue("name"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, withoutSDL(history))
+	})
+
+	mux.HandleFunc("GET /subgraphs/{name}/schemas/{version}", func(w http.ResponseWriter, r *http.Request) {
+		number, err := parseVersion(r.PathValue("version"))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		version, err := registry.Version(r.PathValue("name"), number)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, version)
+	})
+
+	mux.HandleFunc("GET /subgraphs/{name}/diff", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+
+		history, err := registry.History(name)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		// Defaults compare the latest version with the one before it
+		to, err := parseVersion(r.URL.Query().Get("to"))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if to == 0 {
+			to = len(history)
+		}
+		from, err := parseVersion(r.URL.Query().Get("from"))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if from == 0 {
+			from = max(to-1, 1)
+		}
+
+		fromVersion, err := registry.Version(name, from)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		toVersion, err := registry.Version(name, to)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		lines := Diff(fromVersion.SDL, toVersion.SDL)
+		if r.URL.Query().Get("format") == "text" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write([]byte(FormatDiff(lines)))
+			return
+		}
+		changes, err := schemadiff.Compare(fromVersion.SDL, toVersion.SDL)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, DiffResponse{Subgraph: name, From: from, To: to, Lines: lines, Changes: changes})
+	})
+
+	mux.HandleFunc("GET /supergraph", func(w http.ResponseWriter, r *http.Request) {
+		supergraph := registry.Supergraph()
+		if supergraph == nil {
+			writeError(w, ErrNotFound)
+			return
+		}
+
+		sdl, err := supergraph.SupergraphSDL()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(sdl))
+	})
+
+	return mux
+}
+
+func compositionResponse(err *CompositionError) ErrorResponse {
+	resp := ErrorResponse{Error: "schema does not compose with the other subgraphs"}
+	for _, conflict := range err.Conflicts {
+		c := &Conflict{Message: conflict.Message}
+		for _, loc := range conflict.Locations {
+			c.Locations = append(c.Locations, loc.String())
+		}
+		resp.Conflicts = append(resp.Conflicts, c)
+	}
+	if len(resp.Conflicts) == 0 {
+		resp.Conflicts = []*Conflict{{Message: err.Err.Error()}}
+	}
+	return resp
+}
+
+// withoutSDL drops the SDL from listings to keep them small
+func withoutSDL(versions []*Version) []*Version {
+	out := make([]*Version, len(versions))
+	for i, version := range versions {
+		copied := *version
+		copied.SDL = ""
+		out[i] = &copied
+	}
+	return out
+}
+
+func parseVersion(raw string) (int, error) {
+	if raw == "" || raw == "latest" {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < 1 {
+		return 0, errors.New("version must be a positive number or latest")
+	}
+	return version, nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	registry, err := NewRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	server := httptest.NewServer(NewHandler(registry, logger))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func push(t *testing.T, server *httptest.Server, subgraph, sdl string) *http.Response {
+	t.Helper()
+
+	body, _ := json.Marshal(PushRequest{URL: "http://" + subgraph + "/query", SDL: sdl})
+	resp, err := http.Post(server.URL+"/subgraphs/"+subgraph+"/schemas", "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestHandlerPushHistoryAndDiff(t *testing.T) {
+	server := newTestServer(t)
+
+	if resp := push(t, server, "users", usersSDL); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("first push status = %d", resp.StatusCode)
+	}
+	if resp := push(t, server, "users", usersSDL); resp.StatusCode != http.StatusOK {
+		t.Errorf("unchanged push status = %d, want 200", resp.StatusCode)
+	}
+	updated := strings.Replace(usersSDL, "name: String!", "name: String!\n  email: String!", 1)
+	if resp := push(t, server, "users", updated); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("second push status = %d", resp.StatusCode)
+	}
+
+	resp, err := http.Get(server.URL + "/subgraphs/users/schemas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var history []Version
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].Version != 2 || history[1].SDL != "" {
+		t.Errorf("unexpected history %+v", history)
+	}
+
+	resp, err = http.Get(server.URL + "/subgraphs/users/diff?from=1&to=2&format=text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	diff, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(diff), "+   email: String!") {
+		t.Errorf("diff missing added field:\n%s", diff)
+	}
+
+	resp, err = http.Get(server.URL + "/subgraphs/users/diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var diffResp DiffResponse
+	if err := json.NewDecoder(resp.Body).Decode(&diffResp); err != nil {
+		t.Fatal(err)
+	}
+	if diffResp.From != 1 || diffResp.To != 2 || len(diffResp.Changes) != 1 || diffResp.Changes[0].Path != "User.email" {
+		t.Errorf("unexpected diff response %+v", diffResp)
+	}
+
+	resp, err = http.Get(server.URL + "/subgraphs/unknown/schemas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown subgraph status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestHandlerRejectsConflicts(t *testing.T) {
+	server := newTestServer(t)
+
+	push(t, server, "users", usersSDL)
+	resp := push(t, server, "products", strings.Replace(productsSDL, "id: ID!\n}", "id: String!\n}", 1))
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("conflicting push status = %d, want 409", resp.StatusCode)
+	}
+
+	var body ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Conflicts) == 0 || len(body.Conflicts[0].Locations) == 0 {
+		t.Errorf("expected conflicts with locations, got %+v", body)
+	}
+}
@@ -0,0 +1,31 @@
+package registry
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// SchemaPushes counts schema pushes by subgraph and result
+	SchemaPushes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "registry_schema_pushes_total",
+			Help: "Total number of schema pushes by subgraph and result",
+		},
+		[]string{"subgraph", "result"},
+	)
+
+	// SchemaVersions tracks the latest version stored for each subgraph
+	SchemaVersions = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "registry_schema_version",
+			Help: "Latest schema version stored for each subgraph",
+		},
+		[]string{"subgraph"},
+	)
+)
+
+// RecordPush - Record a schema push
+func RecordPush(subgraph, result string) {
+	SchemaPushes.WithLabelValues(subgraph, result).Inc()
+}
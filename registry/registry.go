@@ -0,0 +1,263 @@
+// Package registry keeps the versioned history of every subgraph schema and
+// only accepts versions that still compose with the rest of the graph.
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go_fed/federation"
+)
+
+var (
+	// ErrNotFound is returned for unknown subgraphs or versions
+	ErrNotFound = errors.New("schema not found")
+)
+
+// Version is a single schema pushed by a subgraph
+type Version struct {
+	Subgraph  string    `json:"subgraph"`
+	Version   int       `json:"version"`
+	Hash      string    `json:"hash"`
+	URL       string    `json:"url"`
+	SDL       string    `json:"sdl,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CompositionError is returned when a push would break composition
+type CompositionError struct {
+	Subgraph  string
+	Conflicts federation.Conflicts
+	Err       error
+}
+
+func (e *CompositionError) Error() string {
+	if len(e.Conflicts) > 0 {
+		return fmt.Sprintf("schema for %s does not compose:\n%s", e.Subgraph, e.Conflicts)
+	}
+	return fmt.Sprintf("schema for %s does not compose: %v", e.Subgraph, e.Err)
+}
+
+func (e *CompositionError) Unwrap() error {
+	return e.Err
+}
+
+// Registry stores the schema history of every subgraph
+type Registry struct {
+	mu       sync.RWMutex
+	path     string
+	now      func() time.Time
+	history  map[string][]*Version
+	order    []string
+	composed *federation.Supergraph
+}
+
+// NewRegistry creates a registry. When path is not empty the history is
+// loaded from and saved to that JSON file.
+func NewRegistry(path string) (*Registry, error) {
+	r := &Registry{
+		path:    path,
+		now:     time.Now,
+		history: make(map[string][]*Version),
+	}
+
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []*Version
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	for _, version := range versions {
+		if _, ok := r.history[version.Subgraph]; !ok {
+			r.order = append(r.order, version.Subgraph)
+		}
+		r.history[version.Subgraph] = append(r.history[version.Subgraph], version)
+	}
+
+	if len(r.order) > 0 {
+		composed, err := federation.Compose(r.latestSubgraphs("", nil))
+		if err != nil {
+			return nil, fmt.Errorf("stored schemas do not compose: %w", err)
+		}
+		r.composed = composed
+	}
+
+	return r, nil
+}
+
+// Hash returns the hash a schema is stored under
+func Hash(sdl string) string {
+	sum := sha256.Sum256([]byte(sdl))
+	return hex.EncodeToString(sum[:])
+}
+
+// Push stores a new schema version for subgraph. Pushing the schema that
+// is already the latest one returns it with created set to false. Schemas
+// that do not compose with the latest version of the other subgraphs are
+// rejected with a *CompositionError.
+func (r *Registry) Push(subgraph, url, sdl string) (version *Version, created bool, err error) {
+	if subgraph == "" {
+		return nil, false, fmt.Errorf("subgraph name is required")
+	}
+	if sdl == "" {
+		return nil, false, fmt.Errorf("sdl is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hash := Hash(sdl)
+	history := r.history[subgraph]
+	if n := len(history); n > 0 && history[n-1].Hash == hash && history[n-1].URL == url {
+		return history[n-1], false, nil
+	}
+
+	candidate := &federation.Subgraph{Name: subgraph, URL: url, SDL: sdl}
+	composed, err := federation.Compose(r.latestSubgraphs(subgraph, candidate))
+	if err != nil {
+		compositionErr := &CompositionError{Subgraph: subgraph, Err: err}
+		errors.As(err, &compositionErr.Conflicts)
+		return nil, false, compositionErr
+	}
+
+	version = &Version{
+		Subgraph:  subgraph,
+		Version:   len(history) + 1,
+		Hash:      hash,
+		URL:       url,
+		SDL:       sdl,
+		CreatedAt: r.now().UTC(),
+	}
+	if len(history) == 0 {
+		r.order = append(r.order, subgraph)
+	}
+	r.history[subgraph] = append(history, version)
+
+	if err := r.save(); err != nil {
+		r.history[subgraph] = history
+		if len(history) == 0 {
+			r.order = r.order[:len(r.order)-1]
+		}
+		return nil, false, err
+	}
+
+	r.composed = composed
+	return version, true, nil
+}
+
+// latestSubgraphs returns the latest schema of each subgraph, replacing
+// the one named replace with candidate
+func (r *Registry) latestSubgraphs(replace string, candidate *federation.Subgraph) []*federation.Subgraph {
+	var subgraphs []*federation.Subgraph
+	for _, name := range r.order {
+		if name == replace {
+			continue
+		}
+		history := r.history[name]
+		latest := history[len(history)-1]
+		subgraphs = append(subgraphs, &federation.Subgraph{Name: name, URL: latest.URL, SDL: latest.SDL})
+	}
+	if candidate != nil {
+		subgraphs = append(subgraphs, candidate)
+	}
+	return subgraphs
+}
+
+// Subgraphs returns the latest version of every subgraph, sorted by name
+func (r *Registry) Subgraphs() []*Version {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	latest := make([]*Version, 0, len(r.history))
+	for _, history := range r.history {
+		latest = append(latest, history[len(history)-1])
+	}
+	sort.Slice(latest, func(i, j int) bool { return latest[i].Subgraph < latest[j].Subgraph })
+	return latest
+}
+
+// History returns every version pushed by subgraph, oldest first
+func (r *Registry) History(subgraph string) ([]*Version, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history, ok := r.history[subgraph]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]*Version(nil), history...), nil
+}
+
+// Version returns a single version of subgraph. Version 0 is the latest.
+func (r *Registry) Version(subgraph string, version int) (*Version, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history, ok := r.history[subgraph]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if version == 0 {
+		return history[len(history)-1], nil
+	}
+	if version < 0 || version > len(history) {
+		return nil, ErrNotFound
+	}
+	return history[version-1], nil
+}
+
+// Supergraph returns the supergraph composed from the latest versions
+func (r *Registry) Supergraph() *federation.Supergraph {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.composed
+}
+
+// save writes the whole history to the registry file
+func (r *Registry) save() error {
+	if r.path == "" {
+		return nil
+	}
+
+	var versions []*Version
+	for _, name := range r.order {
+		versions = append(versions, r.history[name]...)
+	}
+	data, err := json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".registry-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
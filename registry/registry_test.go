@@ -0,0 +1,142 @@
+package registry
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const usersSDL = `type User @key(fields: "id") {
+  id: ID!
+  name: String!
+}
+
+type Query {
+  users: [User!]!
+}
+`
+
+const productsSDL = `type Product @key(fields: "id") {
+  id: ID!
+  owner: User!
+}
+
+type User @key(fields: "id", resolvable: false) {
+  id: ID!
+}
+
+type Query {
+  products: [Product!]!
+}
+`
+
+func TestPushStoresVersions(t *testing.T) {
+	registry, err := NewRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	first, created, err := registry.Push("users", "http://users/query", usersSDL)
+	if err != nil || !created {
+		t.Fatalf("Push() = %v, %v", created, err)
+	}
+	if first.Version != 1 || first.Hash != Hash(usersSDL) || !first.CreatedAt.Equal(registry.now()) {
+		t.Errorf("unexpected first version %+v", first)
+	}
+
+	same, created, err := registry.Push("users", "http://users/query", usersSDL)
+	if err != nil || created || same.Version != 1 {
+		t.Errorf("pushing the same schema should not create a version, got %+v created=%v err=%v", same, created, err)
+	}
+
+	updated := strings.Replace(usersSDL, "name: String!", "name: String!\n  email: String!", 1)
+	second, created, err := registry.Push("users", "http://users/query", updated)
+	if err != nil || !created || second.Version != 2 {
+		t.Fatalf("Push() = %+v, %v, %v", second, created, err)
+	}
+
+	history, err := registry.History("users")
+	if err != nil || len(history) != 2 {
+		t.Fatalf("History() = %v, %v", history, err)
+	}
+	latest, err := registry.Version("users", 0)
+	if err != nil || latest.Hash != Hash(updated) {
+		t.Errorf("Version(latest) = %+v, %v", latest, err)
+	}
+
+	if _, err := registry.History("unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("History(unknown) error = %v, want ErrNotFound", err)
+	}
+	if _, err := registry.Version("users", 3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Version(users, 3) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPushRejectsBreakingComposition(t *testing.T) {
+	registry, _ := NewRegistry("")
+
+	if _, _, err := registry.Push("users", "http://users/query", usersSDL); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := registry.Push("products", "http://products/query", productsSDL); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changing the key type breaks the User shared with products
+	broken := strings.Replace(usersSDL, "id: ID!", "id: Int!", 1)
+	_, _, err := registry.Push("users", "http://users/query", broken)
+
+	var compositionErr *CompositionError
+	if !errors.As(err, &compositionErr) {
+		t.Fatalf("Push() error = %v, want CompositionError", err)
+	}
+	if len(compositionErr.Conflicts) == 0 || !strings.Contains(compositionErr.Conflicts[0].Message, "User.id") {
+		t.Errorf("unexpected conflicts %v", compositionErr.Conflicts)
+	}
+
+	history, _ := registry.History("users")
+	if len(history) != 1 {
+		t.Errorf("rejected push must not be stored, history has %d versions", len(history))
+	}
+	if registry.Supergraph() == nil || registry.Supergraph().Schema.Types["Product"] == nil {
+		t.Error("supergraph should still be the last composed one")
+	}
+}
+
+func TestRegistryPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+
+	registry, err := NewRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := registry.Push("users", "http://users/query", usersSDL); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := registry.Push("products", "http://products/query", productsSDL); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewRegistry(path)
+	if err != nil {
+		t.Fatalf("NewRegistry() reload error = %v", err)
+	}
+	if subgraphs := reloaded.Subgraphs(); len(subgraphs) != 2 || subgraphs[0].Subgraph != "products" {
+		t.Errorf("unexpected subgraphs after reload %+v", subgraphs)
+	}
+	if reloaded.Supergraph() == nil {
+		t.Error("supergraph should be composed on reload")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	lines := Diff("a\nb\nc\n", "a\nc\nd\n")
+	got := FormatDiff(lines)
+	want := "  a\n- b\n  c\n+ d\n"
+	if got != want {
+		t.Errorf("Diff() =\n%s\nwant:\n%s", got, want)
+	}
+}
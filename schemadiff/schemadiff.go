@@ -0,0 +1,481 @@
+// Package schemadiff compares two versions of a subgraph schema and sorts
+// every change into breaking, dangerous or safe.
+package schemadiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go_fed/federation"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// Criticality tells how a change affects existing clients
+type Criticality string
+
+const (
+	// Breaking changes make valid operations fail or change their meaning
+	Breaking Criticality = "BREAKING"
+	// Dangerous changes keep operations valid but may change behaviour,
+	// e.g. a new enum value a client does not handle
+	Dangerous Criticality = "DANGEROUS"
+	// Safe changes cannot affect existing clients
+	Safe Criticality = "SAFE"
+)
+
+// ChangeType identifies the kind of change
+type ChangeType string
+
+const (
+	TypeRemoved            ChangeType = "TYPE_REMOVED"
+	TypeAdded              ChangeType = "TYPE_ADDED"
+	TypeKindChanged        ChangeType = "TYPE_KIND_CHANGED"
+	FieldRemoved           ChangeType = "FIELD_REMOVED"
+	FieldAdded             ChangeType = "FIELD_ADDED"
+	FieldTypeChanged       ChangeType = "FIELD_TYPE_CHANGED"
+	FieldDeprecated        ChangeType = "FIELD_DEPRECATED"
+	ArgumentRemoved        ChangeType = "ARGUMENT_REMOVED"
+	ArgumentAdded          ChangeType = "ARGUMENT_ADDED"
+	ArgumentTypeChanged    ChangeType = "ARGUMENT_TYPE_CHANGED"
+	ArgumentDefaultChanged ChangeType = "ARGUMENT_DEFAULT_CHANGED"
+	InputFieldRemoved      ChangeType = "INPUT_FIELD_REMOVED"
+	InputFieldAdded        ChangeType = "INPUT_FIELD_ADDED"
+	InputFieldTypeChanged  ChangeType = "INPUT_FIELD_TYPE_CHANGED"
+	EnumValueRemoved       ChangeType = "ENUM_VALUE_REMOVED"
+	EnumValueAdded         ChangeType = "ENUM_VALUE_ADDED"
+	UnionMemberRemoved     ChangeType = "UNION_MEMBER_REMOVED"
+	UnionMemberAdded       ChangeType = "UNION_MEMBER_ADDED"
+	InterfaceRemoved       ChangeType = "INTERFACE_REMOVED"
+	InterfaceAdded         ChangeType = "INTERFACE_ADDED"
+	EntityKeyChanged       ChangeType = "ENTITY_KEY_CHANGED"
+	EntityKeyRemoved       ChangeType = "ENTITY_KEY_REMOVED"
+	EntityKeyAdded         ChangeType = "ENTITY_KEY_ADDED"
+)
+
+// Change is a single difference between two schemas. Path is the schema
+// coordinate the change applies to: "Type", "Type.field",
+// "Type.field.argument" or "Enum.VALUE".
+type Change struct {
+	Type        ChangeType  `json:"type"`
+	Criticality Criticality `json:"criticality"`
+	Path        string      `json:"path"`
+	Message     string      `json:"message"`
+	// Usage is set by Weigh when operation usage is available
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// Compare returns the changes needed to go from the old SDL to the new one,
+// sorted by criticality and path
+func Compare(oldSDL, newSDL string) ([]*Change, error) {
+	oldTypes, err := definitions("old", oldSDL)
+	if err != nil {
+		return nil, err
+	}
+	newTypes, err := definitions("new", newSDL)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &differ{}
+	for _, name := range sortedNames(oldTypes) {
+		oldDef := oldTypes[name]
+		newDef, ok := newTypes[name]
+		if !ok {
+			d.add(TypeRemoved, Breaking, name, "Type %s was removed", name)
+			continue
+		}
+		d.compareTypes(oldDef, newDef)
+	}
+	for _, name := range sortedNames(newTypes) {
+		if _, ok := oldTypes[name]; !ok {
+			d.add(TypeAdded, Safe, name, "Type %s was added", name)
+		}
+	}
+
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		a, b := d.changes[i], d.changes[j]
+		if rank(a.Criticality) != rank(b.Criticality) {
+			return rank(a.Criticality) < rank(b.Criticality)
+		}
+		return a.Path < b.Path
+	})
+	return d.changes, nil
+}
+
+// HasBreaking reports whether any change is breaking
+func HasBreaking(changes []*Change) bool {
+	for _, change := range changes {
+		if change.Criticality == Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+func rank(c Criticality) int {
+	switch c {
+	case Breaking:
+		return 0
+	case Dangerous:
+		return 1
+	}
+	return 2
+}
+
+// definitions parses a subgraph SDL and merges extensions into their type
+func definitions(name, sdl string) (map[string]*ast.Definition, error) {
+	doc, err := parser.ParseSchema(&ast.Source{Name: name, Input: sdl})
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s schema: %w", name, err)
+	}
+
+	types := make(map[string]*ast.Definition)
+	all := append(ast.DefinitionList{}, doc.Definitions...)
+	all = append(all, doc.Extensions...)
+	for _, def := range all {
+		if federation.IsFederationType(def.Name) {
+			continue
+		}
+		existing, ok := types[def.Name]
+		if !ok {
+			copied := *def
+			copied.Fields = append(ast.FieldList(nil), def.Fields...)
+			copied.EnumValues = append(ast.EnumValueList(nil), def.EnumValues...)
+			copied.Directives = append(ast.DirectiveList(nil), def.Directives...)
+			types[def.Name] = &copied
+			continue
+		}
+		existing.Fields = append(existing.Fields, def.Fields...)
+		existing.EnumValues = append(existing.EnumValues, def.EnumValues...)
+		existing.Types = append(existing.Types, def.Types...)
+		existing.Interfaces = append(existing.Interfaces, def.Interfaces...)
+		existing.Directives = append(existing.Directives, def.Directives...)
+	}
+	return types, nil
+}
+
+type differ struct {
+	changes []*Change
+}
+
+func (d *differ) add(changeType ChangeType, criticality Criticality, path, format string, args ...any) {
+	d.changes = append(d.changes, &Change{
+		Type:        changeType,
+		Criticality: criticality,
+		Path:        path,
+		Message:     fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *differ) compareTypes(oldDef, newDef *ast.Definition) {
+	if oldDef.Kind != newDef.Kind {
+		d.add(TypeKindChanged, Breaking, oldDef.Name, "Type %s changed from %s to %s", oldDef.Name, oldDef.Kind, newDef.Kind)
+		return
+	}
+
+	switch oldDef.Kind {
+	case ast.Object, ast.Interface:
+		d.compareFields(oldDef, newDef)
+		d.compareInterfaces(oldDef, newDef)
+		d.compareKeys(oldDef, newDef)
+	case ast.InputObject:
+		d.compareInputFields(oldDef, newDef)
+	case ast.Enum:
+		d.compareEnumValues(oldDef, newDef)
+	case ast.Union:
+		d.compareUnionMembers(oldDef, newDef)
+	}
+}
+
+func (d *differ) compareFields(oldDef, newDef *ast.Definition) {
+	for _, oldField := range oldDef.Fields {
+		if strings.HasPrefix(oldField.Name, "_") {
+			continue
+		}
+		path := oldDef.Name + "." + oldField.Name
+
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			d.add(FieldRemoved, Breaking, path, "Field %s was removed", path)
+			continue
+		}
+
+		if criticality, changed := outputTypeChange(oldField.Type, newField.Type); changed {
+			d.add(FieldTypeChanged, criticality, path, "Field %s changed type from %s to %s", path, oldField.Type, newField.Type)
+		}
+
+		if deprecated(newField.Directives) && !deprecated(oldField.Directives) {
+			d.add(FieldDeprecated, Safe, path, "Field %s was deprecated", path)
+		}
+
+		d.compareArguments(path, oldField.Arguments, newField.Arguments)
+	}
+
+	for _, newField := range newDef.Fields {
+		if strings.HasPrefix(newField.Name, "_") || oldDef.Fields.ForName(newField.Name) != nil {
+			continue
+		}
+		path := newDef.Name + "." + newField.Name
+		d.add(FieldAdded, Safe, path, "Field %s was added", path)
+	}
+}
+
+func (d *differ) compareArguments(fieldPath string, oldArgs, newArgs ast.ArgumentDefinitionList) {
+	for _, oldArg := range oldArgs {
+		path := fieldPath + "." + oldArg.Name
+
+		newArg := newArgs.ForName(oldArg.Name)
+		if newArg == nil {
+			d.add(ArgumentRemoved, Breaking, path, "Argument %s was removed from %s", oldArg.Name, fieldPath)
+			continue
+		}
+
+		if criticality, changed := inputTypeChange(oldArg.Type, newArg.Type); changed {
+			d.add(ArgumentTypeChanged, criticality, path, "Argument %s on %s changed type from %s to %s", oldArg.Name, fieldPath, oldArg.Type, newArg.Type)
+		}
+
+		if valueString(oldArg.DefaultValue) != valueString(newArg.DefaultValue) {
+			d.add(ArgumentDefaultChanged, Dangerous, path, "Default value of argument %s on %s changed from %s to %s",
+				oldArg.Name, fieldPath, valueString(oldArg.DefaultValue), valueString(newArg.DefaultValue))
+		}
+	}
+
+	for _, newArg := range newArgs {
+		if oldArgs.ForName(newArg.Name) != nil {
+			continue
+		}
+		path := fieldPath + "." + newArg.Name
+		if required(newArg.Type, newArg.DefaultValue) {
+			d.add(ArgumentAdded, Breaking, path, "Required argument %s was added to %s", newArg.Name, fieldPath)
+		} else {
+			d.add(ArgumentAdded, Safe, path, "Optional argument %s was added to %s", newArg.Name, fieldPath)
+		}
+	}
+}
+
+func (d *differ) compareInputFields(oldDef, newDef *ast.Definition) {
+	for _, oldField := range oldDef.Fields {
+		path := oldDef.Name + "." + oldField.Name
+
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			d.add(InputFieldRemoved, Breaking, path, "Input field %s was removed", path)
+			continue
+		}
+		if criticality, changed := inputTypeChange(oldField.Type, newField.Type); changed {
+			d.add(InputFieldTypeChanged, criticality, path, "Input field %s changed type from %s to %s", path, oldField.Type, newField.Type)
+		}
+	}
+
+	for _, newField := range newDef.Fields {
+		if oldDef.Fields.ForName(newField.Name) != nil {
+			continue
+		}
+		path := newDef.Name + "." + newField.Name
+		if required(newField.Type, newField.DefaultValue) {
+			d.add(InputFieldAdded, Breaking, path, "Required input field %s was added", path)
+		} else {
+			d.add(InputFieldAdded, Safe, path, "Optional input field %s was added", path)
+		}
+	}
+}
+
+func (d *differ) compareEnumValues(oldDef, newDef *ast.Definition) {
+	for _, value := range oldDef.EnumValues {
+		if newDef.EnumValues.ForName(value.Name) == nil {
+			path := oldDef.Name + "." + value.Name
+			d.add(EnumValueRemoved, Breaking, path, "Enum value %s was removed from %s", value.Name, oldDef.Name)
+		}
+	}
+	for _, value := range newDef.EnumValues {
+		if oldDef.EnumValues.ForName(value.Name) == nil {
+			path := newDef.Name + "." + value.Name
+			d.add(EnumValueAdded, Dangerous, path, "Enum value %s was added to %s, clients may not handle it", value.Name, newDef.Name)
+		}
+	}
+}
+
+func (d *differ) compareUnionMembers(oldDef, newDef *ast.Definition) {
+	for _, member := range oldDef.Types {
+		if !containsString(newDef.Types, member) {
+			d.add(UnionMemberRemoved, Breaking, oldDef.Name, "Member %s was removed from union %s", member, oldDef.Name)
+		}
+	}
+	for _, member := range newDef.Types {
+		if !containsString(oldDef.Types, member) {
+			d.add(UnionMemberAdded, Dangerous, newDef.Name, "Member %s was added to union %s", member, newDef.Name)
+		}
+	}
+}
+
+func (d *differ) compareInterfaces(oldDef, newDef *ast.Definition) {
+	for _, iface := range oldDef.Interfaces {
+		if !containsString(newDef.Interfaces, iface) {
+			d.add(InterfaceRemoved, Breaking, oldDef.Name, "%s no longer implements %s", oldDef.Name, iface)
+		}
+	}
+	for _, iface := range newDef.Interfaces {
+		if !containsString(oldDef.Interfaces, iface) {
+			d.add(InterfaceAdded, Dangerous, newDef.Name, "%s now implements %s", newDef.Name, iface)
+		}
+	}
+}
+
+// compareKeys reports entity key changes: the gateway and other subgraphs
+// send representations built from these fields
+func (d *differ) compareKeys(oldDef, newDef *ast.Definition) {
+	oldKeys := entityKeys(oldDef)
+	newKeys := entityKeys(newDef)
+
+	for key := range oldKeys {
+		if newKeys[key] {
+			continue
+		}
+		if len(newKeys) > 0 {
+			d.add(EntityKeyChanged, Breaking, oldDef.Name, "@key(fields: %q) on %s was replaced by %s", key, oldDef.Name, strings.Join(sortedKeys(newKeys), ", "))
+		} else {
+			d.add(EntityKeyRemoved, Breaking, oldDef.Name, "@key(fields: %q) was removed from %s", key, oldDef.Name)
+		}
+	}
+	for key := range newKeys {
+		if !oldKeys[key] {
+			d.add(EntityKeyAdded, Safe, newDef.Name, "@key(fields: %q) was added to %s", key, newDef.Name)
+		}
+	}
+}
+
+func entityKeys(def *ast.Definition) map[string]bool {
+	keys := make(map[string]bool)
+	for _, dir := range def.Directives.ForNames("key") {
+		fields := dir.Arguments.ForName("fields")
+		if fields == nil || fields.Value == nil {
+			continue
+		}
+		if set, err := federation.ParseFieldSet(fields.Value.Raw); err == nil {
+			keys[normalizeFieldSet(set)] = true
+		}
+	}
+	return keys
+}
+
+func normalizeFieldSet(set ast.SelectionSet) string {
+	var parts []string
+	for _, sel := range set {
+		if field, ok := sel.(*ast.Field); ok {
+			part := field.Name
+			if len(field.SelectionSet) > 0 {
+				part += " { " + normalizeFieldSet(field.SelectionSet) + " }"
+			}
+			parts = append(parts, part)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+// outputTypeChange classifies a field type change. Clients can always
+// handle a value that became non-null, but not a null they never saw.
+func outputTypeChange(oldType, newType *ast.Type) (Criticality, bool) {
+	if oldType.String() == newType.String() {
+		return "", false
+	}
+	if safeOutputChange(oldType, newType) {
+		return Safe, true
+	}
+	return Breaking, true
+}
+
+func safeOutputChange(oldType, newType *ast.Type) bool {
+	if !oldType.NonNull && newType.NonNull {
+		newType = nullable(newType)
+	}
+	if oldType.NonNull != newType.NonNull {
+		return false
+	}
+	if (oldType.Elem == nil) != (newType.Elem == nil) {
+		return false
+	}
+	if oldType.Elem != nil {
+		return safeOutputChange(oldType.Elem, newType.Elem)
+	}
+	return oldType.NamedType == newType.NamedType
+}
+
+// inputTypeChange classifies an argument or input field type change.
+// Relaxing a non-null input is safe, requiring a value is not.
+func inputTypeChange(oldType, newType *ast.Type) (Criticality, bool) {
+	if oldType.String() == newType.String() {
+		return "", false
+	}
+	if safeInputChange(oldType, newType) {
+		return Safe, true
+	}
+	return Breaking, true
+}
+
+func safeInputChange(oldType, newType *ast.Type) bool {
+	if oldType.NonNull && !newType.NonNull {
+		oldType = nullable(oldType)
+	}
+	if oldType.NonNull != newType.NonNull {
+		return false
+	}
+	if (oldType.Elem == nil) != (newType.Elem == nil) {
+		return false
+	}
+	if oldType.Elem != nil {
+		return safeInputChange(oldType.Elem, newType.Elem)
+	}
+	return oldType.NamedType == newType.NamedType
+}
+
+func nullable(t *ast.Type) *ast.Type {
+	copied := *t
+	copied.NonNull = false
+	return &copied
+}
+
+func required(t *ast.Type, defaultValue *ast.Value) bool {
+	return t.NonNull && defaultValue == nil
+}
+
+func deprecated(directives ast.DirectiveList) bool {
+	return directives.ForName("deprecated") != nil
+}
+
+func valueString(value *ast.Value) string {
+	if value == nil {
+		return "none"
+	}
+	return value.String()
+}
+
+func sortedNames(types map[string]*ast.Definition) []string {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, fmt.Sprintf("%q", key))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,273 @@
+package schemadiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+const baseSchema = `
+type Product @key(fields: "id") {
+  id: ID!
+  name: String!
+  description: String
+  price: Float!
+  category: Category!
+  tags: [String!]!
+}
+
+enum Category {
+  ELECTRONICS
+  BOOKS
+}
+
+input ProductFilter {
+  minPrice: Float
+  category: Category
+}
+
+type Query {
+  products(filter: ProductFilter, limit: Int = 10): [Product!]!
+  product(id: ID!): Product
+}
+`
+
+func findChange(changes []*Change, changeType ChangeType, path string) *Change {
+	for _, change := range changes {
+		if change.Type == changeType && change.Path == path {
+			return change
+		}
+	}
+	return nil
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name        string
+		newSchema   string
+		changeType  ChangeType
+		path        string
+		criticality Criticality
+	}{
+		{
+			name:        "field removed",
+			newSchema:   `type Product @key(fields: "id") { id: ID! name: String! description: String category: Category! tags: [String!]! }`,
+			changeType:  FieldRemoved,
+			path:        "Product.price",
+			criticality: Breaking,
+		},
+		{
+			name:        "output field made nullable",
+			newSchema:   `type Product @key(fields: "id") { id: ID! name: String description: String price: Float! category: Category! tags: [String!]! }`,
+			changeType:  FieldTypeChanged,
+			path:        "Product.name",
+			criticality: Breaking,
+		},
+		{
+			name:        "output field made non-null",
+			newSchema:   `type Product @key(fields: "id") { id: ID! name: String! description: String! price: Float! category: Category! tags: [String!]! }`,
+			changeType:  FieldTypeChanged,
+			path:        "Product.description",
+			criticality: Safe,
+		},
+		{
+			name:        "list item made nullable",
+			newSchema:   `type Product @key(fields: "id") { id: ID! name: String! description: String price: Float! category: Category! tags: [String]! }`,
+			changeType:  FieldTypeChanged,
+			path:        "Product.tags",
+			criticality: Breaking,
+		},
+		{
+			name:        "field type changed",
+			newSchema:   `type Product @key(fields: "id") { id: ID! name: String! description: String price: Int! category: Category! tags: [String!]! }`,
+			changeType:  FieldTypeChanged,
+			path:        "Product.price",
+			criticality: Breaking,
+		},
+		{
+			name:        "enum value removed",
+			newSchema:   `enum Category { ELECTRONICS }`,
+			changeType:  EnumValueRemoved,
+			path:        "Category.BOOKS",
+			criticality: Breaking,
+		},
+		{
+			name:        "enum value added",
+			newSchema:   `enum Category { ELECTRONICS BOOKS SPORTS }`,
+			changeType:  EnumValueAdded,
+			path:        "Category.SPORTS",
+			criticality: Dangerous,
+		},
+		{
+			name:        "argument removed",
+			newSchema:   `type Query { products(filter: ProductFilter): [Product!]! product(id: ID!): Product }`,
+			changeType:  ArgumentRemoved,
+			path:        "Query.products.limit",
+			criticality: Breaking,
+		},
+		{
+			name:        "required argument added",
+			newSchema:   `type Query { products(filter: ProductFilter, limit: Int = 10): [Product!]! product(id: ID!, locale: String!): Product }`,
+			changeType:  ArgumentAdded,
+			path:        "Query.product.locale",
+			criticality: Breaking,
+		},
+		{
+			name:        "optional argument added",
+			newSchema:   `type Query { products(filter: ProductFilter, limit: Int = 10): [Product!]! product(id: ID!, locale: String): Product }`,
+			changeType:  ArgumentAdded,
+			path:        "Query.product.locale",
+			criticality: Safe,
+		},
+		{
+			name:        "argument made required",
+			newSchema:   `type Query { products(filter: ProductFilter!, limit: Int = 10): [Product!]! product(id: ID!): Product }`,
+			changeType:  ArgumentTypeChanged,
+			path:        "Query.products.filter",
+			criticality: Breaking,
+		},
+		{
+			name:        "argument made optional",
+			newSchema:   `type Query { products(filter: ProductFilter, limit: Int = 10): [Product!]! product(id: ID): Product }`,
+			changeType:  ArgumentTypeChanged,
+			path:        "Query.product.id",
+			criticality: Safe,
+		},
+		{
+			name:        "argument default changed",
+			newSchema:   `type Query { products(filter: ProductFilter, limit: Int = 50): [Product!]! product(id: ID!): Product }`,
+			changeType:  ArgumentDefaultChanged,
+			path:        "Query.products.limit",
+			criticality: Dangerous,
+		},
+		{
+			name:        "required input field added",
+			newSchema:   `input ProductFilter { minPrice: Float category: Category owner: ID! }`,
+			changeType:  InputFieldAdded,
+			path:        "ProductFilter.owner",
+			criticality: Breaking,
+		},
+		{
+			name:        "entity key changed",
+			newSchema:   `type Product @key(fields: "sku") { id: ID! sku: String! name: String! description: String price: Float! category: Category! tags: [String!]! }`,
+			changeType:  EntityKeyChanged,
+			path:        "Product",
+			criticality: Breaking,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Compare(baseSchema, replaceTypes(baseSchema, tt.newSchema))
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+
+			change := findChange(changes, tt.changeType, tt.path)
+			if change == nil {
+				t.Fatalf("no %s change on %s in %v", tt.changeType, tt.path, changes)
+			}
+			if change.Criticality != tt.criticality {
+				t.Errorf("criticality = %s, want %s (%s)", change.Criticality, tt.criticality, change.Message)
+			}
+		})
+	}
+}
+
+func TestCompareSameSchema(t *testing.T) {
+	changes, err := Compare(baseSchema, baseSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
+
+func TestCompareTypeRemoved(t *testing.T) {
+	changes, err := Compare(baseSchema, `type Query { product(id: ID!): String }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change := findChange(changes, TypeRemoved, "Product"); change == nil || change.Criticality != Breaking {
+		t.Errorf("expected breaking TYPE_REMOVED on Product, got %v", changes)
+	}
+	if !HasBreaking(changes) {
+		t.Error("HasBreaking() = false")
+	}
+	if changes[0].Criticality != Breaking {
+		t.Error("breaking changes must be sorted first")
+	}
+}
+
+func TestWeigh(t *testing.T) {
+	newSchema := replaceTypes(baseSchema, `
+type Product @key(fields: "id") { id: ID! name: String! description: String category: Category! tags: [String!]! }
+enum Category { ELECTRONICS }`)
+
+	changes, err := Compare(baseSchema, newSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invalid, err := Weigh(baseSchema, changes, []Operation{
+		{Name: "ProductNames", Query: `{ products { id name } }`, Count: 100},
+		{Name: "Books", Query: `{ products(filter: { category: BOOKS }) { name } }`, Count: 7},
+		{Name: "Broken", Query: `{ nope }`, Count: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invalid) != 1 {
+		t.Errorf("expected the invalid operation to be reported, got %v", invalid)
+	}
+
+	price := findChange(changes, FieldRemoved, "Product.price")
+	if price == nil || price.Usage == nil || len(price.Usage.Operations) != 0 {
+		t.Fatalf("Product.price removal should be unused, got %+v", price)
+	}
+
+	books := findChange(changes, EnumValueRemoved, "Category.BOOKS")
+	if books == nil || books.Usage == nil || books.Usage.Count != 7 || books.Usage.Operations[0] != "Books" {
+		t.Fatalf("Category.BOOKS removal should be used by Books, got %+v", books.Usage)
+	}
+
+	if !HasUsedBreaking(changes) {
+		t.Error("HasUsedBreaking() = false, the BOOKS removal is used")
+	}
+}
+
+func TestHasUsedBreakingIgnoresUnused(t *testing.T) {
+	changes := []*Change{{Type: FieldRemoved, Criticality: Breaking, Path: "Product.price", Usage: &Usage{}}}
+	if HasUsedBreaking(changes) {
+		t.Error("unused breaking change should not fail")
+	}
+}
+
+// replaceTypes returns schema with the types declared in replacements
+// swapped in, keeping every other type unchanged
+func replaceTypes(schema, replacements string) string {
+	base, err := parser.ParseSchema(&ast.Source{Input: schema})
+	if err != nil {
+		panic(err)
+	}
+	updates, err := parser.ParseSchema(&ast.Source{Input: replacements})
+	if err != nil {
+		panic(err)
+	}
+
+	doc := &ast.SchemaDocument{}
+	for _, def := range base.Definitions {
+		if updated := updates.Definitions.ForName(def.Name); updated != nil {
+			def = updated
+		}
+		doc.Definitions = append(doc.Definitions, def)
+	}
+
+	var sb strings.Builder
+	formatter.NewFormatter(&sb).FormatSchemaDocument(doc)
+	return sb.String()
+}
@@ -0,0 +1,199 @@
+package schemadiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"go_fed/federation"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Operation is a client operation recorded with the number of times it ran
+type Operation struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+	Count int64  `json:"count"`
+}
+
+// Usage tells which recorded operations touch a changed coordinate
+type Usage struct {
+	Operations []string `json:"operations"`
+	Count      int64    `json:"count"`
+}
+
+// LoadOperations reads recorded operations from a JSON file holding an
+// array of {"name", "query", "count"} objects
+func LoadOperations(path string) ([]Operation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []Operation
+	if err := json.Unmarshal(data, &operations); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return operations, nil
+}
+
+// Weigh sets the usage of every change from the operations recorded
+// against the old schema. Operations that are not valid against it are
+// returned as errors and ignored.
+func Weigh(oldSDL string, changes []*Change, operations []Operation) ([]error, error) {
+	schema, err := federation.SubgraphSchema(&federation.Subgraph{Name: "old", SDL: oldSDL})
+	if err != nil {
+		return nil, err
+	}
+
+	type hits struct {
+		operations map[string]bool
+		count      int64
+	}
+	byCoordinate := make(map[string]*hits)
+
+	var invalid []error
+	for i, op := range operations {
+		name := op.Name
+		if name == "" {
+			name = fmt.Sprintf("operation #%d", i+1)
+		}
+
+		doc, errs := gqlparser.LoadQuery(schema, op.Query)
+		if len(errs) > 0 {
+			invalid = append(invalid, fmt.Errorf("%s: %w", name, errs))
+			continue
+		}
+
+		for coordinate := range Coordinates(schema, doc) {
+			h := byCoordinate[coordinate]
+			if h == nil {
+				h = &hits{operations: make(map[string]bool)}
+				byCoordinate[coordinate] = h
+			}
+			if !h.operations[name] {
+				h.operations[name] = true
+				h.count += op.Count
+			}
+		}
+	}
+
+	for _, change := range changes {
+		usage := &Usage{Operations: []string{}}
+		if h := byCoordinate[change.Path]; h != nil {
+			for name := range h.operations {
+				usage.Operations = append(usage.Operations, name)
+			}
+			sort.Strings(usage.Operations)
+			usage.Count = h.count
+		}
+		change.Usage = usage
+	}
+
+	return invalid, nil
+}
+
+// HasUsedBreaking reports whether a breaking change affects a recorded
+// operation. Changes that were not weighed count as used.
+func HasUsedBreaking(changes []*Change) bool {
+	for _, change := range changes {
+		if change.Criticality != Breaking {
+			continue
+		}
+		if change.Usage == nil || len(change.Usage.Operations) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Coordinates returns the schema coordinates an operation depends on:
+// types, fields, arguments, input fields and enum values
+func Coordinates(schema *ast.Schema, doc *ast.QueryDocument) map[string]bool {
+	c := &coordinates{schema: schema, doc: doc, seen: make(map[string]bool)}
+	for _, op := range doc.Operations {
+		for _, variable := range op.VariableDefinitions {
+			c.addType(variable.Type)
+			c.addValue(variable.DefaultValue)
+		}
+		c.selections(op.SelectionSet)
+	}
+	return c.seen
+}
+
+type coordinates struct {
+	schema    *ast.Schema
+	doc       *ast.QueryDocument
+	seen      map[string]bool
+	fragments map[string]bool
+}
+
+func (c *coordinates) selections(set ast.SelectionSet) {
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if sel.Definition == nil || sel.ObjectDefinition == nil {
+				continue
+			}
+			parent := sel.ObjectDefinition.Name
+			c.seen[parent] = true
+			c.seen[parent+"."+sel.Name] = true
+			c.addType(sel.Definition.Type)
+			for _, arg := range sel.Arguments {
+				c.seen[parent+"."+sel.Name+"."+arg.Name] = true
+				c.addValue(arg.Value)
+			}
+			c.selections(sel.SelectionSet)
+		case *ast.InlineFragment:
+			if sel.TypeCondition != "" {
+				c.seen[sel.TypeCondition] = true
+			}
+			c.selections(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if c.fragments == nil {
+				c.fragments = make(map[string]bool)
+			}
+			if c.fragments[sel.Name] {
+				continue
+			}
+			c.fragments[sel.Name] = true
+			if fragment := c.doc.Fragments.ForName(sel.Name); fragment != nil {
+				c.seen[fragment.TypeCondition] = true
+				c.selections(fragment.SelectionSet)
+			}
+		}
+	}
+}
+
+func (c *coordinates) addType(t *ast.Type) {
+	if t != nil {
+		c.seen[t.Name()] = true
+	}
+}
+
+// addValue records enum values and input fields used in literals
+func (c *coordinates) addValue(value *ast.Value) {
+	if value == nil {
+		return
+	}
+
+	switch value.Kind {
+	case ast.EnumValue:
+		if value.Definition != nil {
+			c.seen[value.Definition.Name+"."+value.Raw] = true
+		}
+	case ast.ObjectValue:
+		if value.Definition != nil {
+			c.seen[value.Definition.Name] = true
+			for _, child := range value.Children {
+				c.seen[value.Definition.Name+"."+child.Name] = true
+			}
+		}
+	}
+	for _, child := range value.Children {
+		c.addValue(child.Value)
+	}
+}
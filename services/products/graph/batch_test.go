@@ -0,0 +1,175 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"products/graph/model"
+	"products/repository"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/client"
+)
+
+// slowRepository never answers for the IDs in stuck, so their fetches run
+// into the timeout
+type slowRepository struct {
+	repository.ProductRepository
+	stuck map[string]bool
+}
+
+func (r *slowRepository) Get(ctx context.Context, id string) (*model.Product, error) {
+	if r.stuck[id] {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return r.ProductRepository.Get(ctx, id)
+}
+
+type batchResponse struct {
+	ProductsByIds []*struct{ ID string }
+}
+
+type batchError struct {
+	Path       []interface{}
+	Extensions map[string]interface{}
+}
+
+// withTimeout limits the request, and with it the batch, to d
+func withTimeout(t *testing.T, d time.Duration) client.Option {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	t.Cleanup(cancel)
+	return func(bd *client.Request) { bd.HTTP = bd.HTTP.WithContext(ctx) }
+}
+
+func TestProductsByIdsKeepsInputOrder(t *testing.T) {
+	ids := []string{"5", "999", "1", "3"}
+	products, err := NewResolver().Query().ProductsByIds(context.Background(), ids, nil)
+	if err != nil {
+		t.Fatalf("ProductsByIds() error = %v", err)
+	}
+	if len(products) != len(ids) {
+		t.Fatalf("got %d products, want %d", len(products), len(ids))
+	}
+	for i, id := range ids {
+		switch {
+		case id == "999" && products[i] != nil:
+			t.Errorf("products[%d] = %+v, want nil", i, products[i])
+		case id != "999" && (products[i] == nil || products[i].ID != id):
+			t.Errorf("products[%d] = %+v, want product %s", i, products[i], id)
+		}
+	}
+}
+
+func TestProductsByIdsReportsMissingIDs(t *testing.T) {
+	c := newStreamingServer(NewResolver())
+
+	var resp batchResponse
+	err := c.Post(`{ productsByIds(ids: ["1", "999", "2"]) { id } }`, &resp)
+	errs := decodeErrors(t, err)
+
+	if len(resp.ProductsByIds) != 3 || resp.ProductsByIds[1] != nil || resp.ProductsByIds[0].ID != "1" || resp.ProductsByIds[2].ID != "2" {
+		t.Errorf("productsByIds = %+v, want [1 null 2]", resp.ProductsByIds)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1", len(errs))
+	}
+	assertBatchError(t, errs[0], 1, "999", errNotFoundCode)
+}
+
+func TestProductsByIdsTimeout(t *testing.T) {
+	resolver := NewResolver(WithRepository(&slowRepository{
+		ProductRepository: repository.NewMemoryRepository(repository.SeedProducts()),
+		stuck:             map[string]bool{"2": true, "4": true},
+	}))
+	c := newStreamingServer(resolver)
+	query := `query ($partial: Boolean) { productsByIds(ids: ["1", "2", "3", "4"], partial: $partial) { id } }`
+
+	// Sem partial, um timeout derruba o campo inteiro mas lista todos os IDs
+	var strict batchResponse
+	err := c.Post(query, &strict, withTimeout(t, 300*time.Millisecond))
+	errs := decodeErrors(t, err)
+	if strict.ProductsByIds != nil {
+		t.Errorf("productsByIds = %+v, want null", strict.ProductsByIds)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2", len(errs))
+	}
+	assertBatchError(t, errs[0], 1, "2", errTimeoutCode)
+	assertBatchError(t, errs[1], 3, "4", errTimeoutCode)
+
+	// Com partial, os produtos buscados a tempo voltam junto com os erros
+	var partial batchResponse
+	err = c.Post(query, &partial, client.Var("partial", true), withTimeout(t, 300*time.Millisecond))
+	errs = decodeErrors(t, err)
+	if len(partial.ProductsByIds) != 4 || partial.ProductsByIds[0].ID != "1" || partial.ProductsByIds[1] != nil ||
+		partial.ProductsByIds[2].ID != "3" || partial.ProductsByIds[3] != nil {
+		t.Errorf("productsByIds = %+v, want [1 null 3 null]", partial.ProductsByIds)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2", len(errs))
+	}
+	assertBatchError(t, errs[0], 1, "2", errTimeoutCode)
+	assertBatchError(t, errs[1], 3, "4", errTimeoutCode)
+}
+
+func decodeErrors(t *testing.T, err error) []batchError {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	raw, ok := err.(client.RawJsonError)
+	if !ok {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var errs []batchError
+	if jsonErr := json.Unmarshal(raw.RawMessage, &errs); jsonErr != nil {
+		t.Fatalf("can not decode errors %s: %v", raw.RawMessage, jsonErr)
+	}
+	return errs
+}
+
+func assertBatchError(t *testing.T, err batchError, index int, id, code string) {
+	t.Helper()
+
+	if len(err.Path) != 2 || err.Path[0] != "productsByIds" || err.Path[1] != float64(index) {
+		t.Errorf("path = %v, want [productsByIds %d]", err.Path, index)
+	}
+	if err.Extensions["id"] != id || err.Extensions["code"] != code {
+		t.Errorf("extensions = %v, want id %s and code %s", err.Extensions, id, code)
+	}
+}
+
+func TestFetchBatchBoundsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	fetch := func(ctx context.Context, id string) (*model.Product, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return &model.Product{ID: id}, nil
+	}
+
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+	}
+	batch := fetchBatch(context.Background(), "bounded", ids, fetch)
+
+	if peak.Load() > batchConcurrency {
+		t.Errorf("peak concurrent fetches = %d, want at most %d", peak.Load(), batchConcurrency)
+	}
+	for i, item := range batch.items {
+		if item == nil || item.ID != ids[i] {
+			t.Fatalf("items[%d] = %v, want %s", i, item, ids[i])
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"products/graph/model"
+	"products/repository"
+	"shared/cache"
+	"time"
+)
+
+// Limits of the products cache
+const (
+	productCacheSize   = 1000
+	productCacheTTL    = time.Minute
+	productLoadTimeout = 5 * time.Second
+)
+
+// productLoads puts load behind the products cache, so concurrent misses
+// for one product share a single load. Unknown products come back as
+// repository.ErrNotFound, which keeps them out of the cache.
+func (r *Resolver) productLoads(load func(ctx context.Context, id string) (*model.Product, error)) *cache.Loading[string, *model.Product] {
+	return cache.NewLoading(r.cache, "products", func(ctx context.Context, id string) (*model.Product, error) {
+		product, err := load(ctx, id)
+		if err == nil && product == nil {
+			return nil, repository.ErrNotFound
+		}
+		return product, err
+	}, productLoadTimeout)
+}
+
+// cachedProduct returns the product with id from the cache, loading it
+// through loads on a miss. Unknown products return a nil product and no
+// error.
+func cachedProduct(ctx context.Context, loads *cache.Loading[string, *model.Product], id string) (*model.Product, error) {
+	product, err := loads.GetOrLoad(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return product, err
+}
+
+// cacheProduct writes product to the cache after a mutation. Both loaders
+// forget their load of it in flight first, so a load that read the old
+// product does not write it back.
+func (r *Resolver) cacheProduct(product *model.Product) {
+	r.productLoader.Forget(product.ID)
+	r.batchLoader.Forget(product.ID)
+	r.cache.Set(product.ID, product)
+}
+
+// uncacheProduct drops a deleted product from the cache, forgetting its
+// loads in flight like cacheProduct
+func (r *Resolver) uncacheProduct(id string) {
+	r.productLoader.Forget(id)
+	r.batchLoader.Forget(id)
+	r.cache.Delete(id)
+}
@@ -0,0 +1,172 @@
+package graph
+
+import (
+	"context"
+	"products/graph/model"
+	"products/repository"
+	"shared/cache"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newCachedResolver() (*Resolver, *countingRepository) {
+	repo := &countingRepository{ProductRepository: repository.NewMemoryRepository(repository.SeedProducts())}
+	return NewResolver(WithRepository(repo), WithOwners(&fakeOwners{})), repo
+}
+
+func TestProductIsServedFromCache(t *testing.T) {
+	resolver, repo := newCachedResolver()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		product, err := resolver.Query().Product(ctx, "1")
+		if err != nil || product == nil || product.ID != "1" {
+			t.Fatalf("Product() = %v, %v", product, err)
+		}
+	}
+	if repo.gets != 1 {
+		t.Errorf("repository gets = %d, want 1", repo.gets)
+	}
+
+	// Produtos inexistentes não ficam no cache
+	for i := 0; i < 2; i++ {
+		if product, _ := resolver.Query().Product(ctx, "999"); product != nil {
+			t.Fatalf("Product(999) = %v, want nil", product)
+		}
+	}
+	if repo.gets != 3 {
+		t.Errorf("repository gets = %d, want unknown IDs looked up every time", repo.gets)
+	}
+}
+
+// gatedRepository reads every Get right away but holds its result until
+// release is closed, as a slow repository would
+type gatedRepository struct {
+	*countingRepository
+	release chan struct{}
+	waiting atomic.Int32
+}
+
+func (r *gatedRepository) Get(ctx context.Context, id string) (*model.Product, error) {
+	product, err := r.countingRepository.Get(ctx, id)
+	r.waiting.Add(1)
+	<-r.release
+	return product, err
+}
+
+func TestProductMergesConcurrentMisses(t *testing.T) {
+	counting := &countingRepository{ProductRepository: repository.NewMemoryRepository(repository.SeedProducts())}
+	repo := &gatedRepository{countingRepository: counting, release: make(chan struct{})}
+	resolver := NewResolver(WithRepository(repo), WithOwners(&fakeOwners{}))
+	merged := testutil.ToFloat64(cache.LoadsMerged.WithLabelValues("products", "products"))
+
+	const callers = 8
+	products := make([]*model.Product, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			products[i], _ = resolver.Query().Product(context.Background(), "1")
+		}(i)
+	}
+
+	// Libera o repositório só depois que todas as faltas se juntaram
+	deadline := time.Now().Add(time.Second)
+	for testutil.ToFloat64(cache.LoadsMerged.WithLabelValues("products", "products"))-merged < callers-1 {
+		if time.Now().After(deadline) {
+			t.Fatal("concurrent misses did not merge")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(repo.release)
+	wg.Wait()
+
+	for i, product := range products {
+		if product == nil || product.ID != "1" {
+			t.Errorf("products[%d] = %v, want product 1", i, product)
+		}
+	}
+	if counting.gets != 1 {
+		t.Errorf("repository gets = %d, want 1", counting.gets)
+	}
+}
+
+func TestDeleteProductDuringLoad(t *testing.T) {
+	counting := &countingRepository{ProductRepository: repository.NewMemoryRepository(repository.SeedProducts())}
+	repo := &gatedRepository{countingRepository: counting, release: make(chan struct{})}
+	resolver := NewResolver(WithRepository(repo), WithOwners(&fakeOwners{}))
+
+	loaded := make(chan *model.Product, 1)
+	go func() {
+		product, _ := resolver.Query().Product(context.Background(), "1")
+		loaded <- product
+	}()
+	// A carga já leu o produto quando a remoção acontece
+	deadline := time.Now().Add(time.Second)
+	for repo.waiting.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the load did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := resolver.Mutation().DeleteProduct(context.Background(), "1"); err != nil {
+		t.Fatalf("DeleteProduct() error = %v", err)
+	}
+	close(repo.release)
+	<-loaded
+
+	if product, ok := resolver.Cache().Get("1"); ok {
+		t.Errorf("deleted product %v was cached by the load in flight", product)
+	}
+}
+
+func TestProductsByIdsUsesCache(t *testing.T) {
+	resolver, repo := newCachedResolver()
+	ctx := context.Background()
+
+	if _, err := resolver.Query().Product(ctx, "1"); err != nil {
+		t.Fatalf("Product() error = %v", err)
+	}
+	products, err := resolver.Query().ProductsByIds(ctx, []string{"1", "2"}, nil)
+	if err != nil || len(products) != 2 || products[0].ID != "1" || products[1].ID != "2" {
+		t.Fatalf("ProductsByIds() = %v, %v", products, err)
+	}
+	if _, err := resolver.Query().ProductsByIds(ctx, []string{"2", "1"}, nil); err != nil {
+		t.Fatalf("ProductsByIds() error = %v", err)
+	}
+	// Só o ID 2 da primeira chamada chega ao repositório
+	if repo.gets != 2 {
+		t.Errorf("repository gets = %d, want 2", repo.gets)
+	}
+}
+
+func TestMutationsKeepCacheConsistent(t *testing.T) {
+	resolver, _ := newCachedResolver()
+	ctx := context.Background()
+
+	if _, err := resolver.Query().Product(ctx, "1"); err != nil {
+		t.Fatalf("Product() error = %v", err)
+	}
+	name := "Renamed"
+	if _, err := resolver.Mutation().UpdateProduct(ctx, "1", model.UpdateProductInput{Name: &name}); err != nil {
+		t.Fatalf("UpdateProduct() error = %v", err)
+	}
+	if product, _ := resolver.Query().Product(ctx, "1"); product == nil || product.Name != "Renamed" {
+		t.Errorf("Product() = %v after update, want the new name", product)
+	}
+
+	if _, err := resolver.Mutation().DeleteProduct(ctx, "1"); err != nil {
+		t.Fatalf("DeleteProduct() error = %v", err)
+	}
+	if product, _ := resolver.Query().Product(ctx, "1"); product != nil {
+		t.Errorf("Product() = %v after delete, want nil", product)
+	}
+	if size := resolver.Cache().Len(); size != 0 {
+		t.Errorf("cache size = %d after delete, want 0", size)
+	}
+}
@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"products/graph/model"
+	"products/repository"
+	"shared/pagination"
+	"strconv"
+)
+
+const (
+	errInvalidCursorCode   = "INVALID_CURSOR"
+	errInvalidPageSizeCode = "INVALID_PAGE_SIZE"
+)
+
+// productConnection pages products, which must be sorted by sort as
+// repository.SortBy orders them
+func productConnection(ctx context.Context, products []*model.Product, sort *model.ProductSort, args pagination.Args) (*model.ProductConnection, error) {
+	page, err := pagination.PaginateBy(products, func(product *model.Product) string { return product.ID }, productOrder(sort), args)
+	if err != nil {
+		return nil, paginationError(ctx, err)
+	}
+
+	edges := make([]*model.ProductEdge, len(page.Items))
+	for i, product := range page.Items {
+		edges[i] = &model.ProductEdge{Cursor: page.Cursors[i], Node: product}
+	}
+	return &model.ProductConnection{
+		Edges:      edges,
+		PageInfo:   pageInfo(page),
+		TotalCount: page.TotalCount,
+	}, nil
+}
+
+// productOrder is the pagination order of sort, nil for the ID order
+func productOrder(sort *model.ProductSort) *pagination.Order[*model.Product] {
+	if sort == nil {
+		return nil
+	}
+	return &pagination.Order[*model.Product]{
+		Name: fmt.Sprintf("%s %s", sort.Field, sort.Direction),
+		Value: func(product *model.Product) string {
+			switch sort.Field {
+			case model.ProductSortFieldPrice:
+				return strconv.FormatFloat(product.Price, 'g', -1, 64)
+			case model.ProductSortFieldName:
+				return product.Name
+			default:
+				return product.Category
+			}
+		},
+		At: func(value, id string) (*model.Product, error) {
+			at := &model.Product{ID: id}
+			switch sort.Field {
+			case model.ProductSortFieldPrice:
+				price, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, err
+				}
+				at.Price = price
+			case model.ProductSortFieldName:
+				at.Name = value
+			default:
+				at.Category = value
+			}
+			return at, nil
+		},
+		Compare: func(a, b *model.Product) int { return repository.Compare(a, b, sort) },
+	}
+}
+
+func pageInfo[T any](page *pagination.Page[T]) *model.PageInfo {
+	return &model.PageInfo{
+		HasNextPage:     page.HasNextPage,
+		HasPreviousPage: page.HasPreviousPage,
+		StartCursor:     page.StartCursor(),
+		EndCursor:       page.EndCursor(),
+	}
+}
+
+// paginationError gives invalid connection arguments a code in extensions
+func paginationError(ctx context.Context, err error) error {
+	code := errInvalidPageSizeCode
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		code = errInvalidCursorCode
+	}
+	return codedError(ctx, err, err.Error(), map[string]interface{}{"code": code})
+}
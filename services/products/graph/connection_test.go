@@ -0,0 +1,170 @@
+package graph
+
+import (
+	"context"
+	"products/graph/model"
+	"testing"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestProductsConnectionPages(t *testing.T) {
+	resolver := NewResolver(WithOwners(&fakeOwners{known: map[string]bool{"1": true}}))
+	ctx := context.Background()
+
+	first, err := resolver.Query().ProductsConnection(ctx, nil, nil, intPtr(4), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("ProductsConnection() error = %v", err)
+	}
+	if len(first.Edges) != 4 || !first.PageInfo.HasNextPage || first.TotalCount != 8 {
+		t.Fatalf("first page = %+v, info %+v, total %d", first.Edges, first.PageInfo, first.TotalCount)
+	}
+
+	// Um produto removido entre as páginas não desloca o cursor
+	if _, err := resolver.Mutation().DeleteProduct(ctx, "2"); err != nil {
+		t.Fatalf("DeleteProduct() error = %v", err)
+	}
+
+	second, err := resolver.Query().ProductsConnection(ctx, nil, nil, intPtr(4), first.PageInfo.EndCursor, nil, nil)
+	if err != nil {
+		t.Fatalf("ProductsConnection() error = %v", err)
+	}
+	if len(second.Edges) != 4 || second.Edges[0].Node.ID != "5" || second.PageInfo.HasNextPage {
+		t.Errorf("second page = %+v, info %+v", second.Edges, second.PageInfo)
+	}
+
+	back, err := resolver.Query().ProductsConnection(ctx, nil, nil, nil, nil, intPtr(2), second.PageInfo.StartCursor)
+	if err != nil {
+		t.Fatalf("ProductsConnection() error = %v", err)
+	}
+	if len(back.Edges) != 2 || back.Edges[0].Node.ID != "3" || back.Edges[1].Node.ID != "4" {
+		t.Errorf("backward page = %+v", back.Edges)
+	}
+}
+
+func TestProductsByCategoryConnection(t *testing.T) {
+	resolver := NewResolver()
+
+	page, err := resolver.Query().ProductsByCategoryConnection(context.Background(), "Electronics", nil, intPtr(2), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("ProductsByCategoryConnection() error = %v", err)
+	}
+	if page.TotalCount != 5 || len(page.Edges) != 2 || page.Edges[1].Node.ID != "2" || !page.PageInfo.HasNextPage {
+		t.Errorf("page = %+v, info %+v, total %d", page.Edges, page.PageInfo, page.TotalCount)
+	}
+}
+
+func TestProductsConnectionErrors(t *testing.T) {
+	resolver := NewResolver()
+	ctx := context.Background()
+	bad := "not-a-cursor"
+
+	_, err := resolver.Query().ProductsConnection(ctx, nil, nil, nil, &bad, nil, nil)
+	if code := errorCode(t, err); code != errInvalidCursorCode {
+		t.Errorf("code = %q, want %q", code, errInvalidCursorCode)
+	}
+
+	_, err = resolver.Query().ProductsConnection(ctx, nil, nil, intPtr(-1), nil, nil, nil)
+	if code := errorCode(t, err); code != errInvalidPageSizeCode {
+		t.Errorf("code = %q, want %q", code, errInvalidPageSizeCode)
+	}
+}
+
+func TestProductsConnectionFilter(t *testing.T) {
+	resolver := NewResolver()
+	search := "apple"
+
+	page, err := resolver.Query().ProductsConnection(context.Background(), &model.ProductFilter{Text: &search}, nil, intPtr(1), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("ProductsConnection() error = %v", err)
+	}
+	if page.TotalCount != 2 || len(page.Edges) != 1 || page.Edges[0].Node.ID != "1" || !page.PageInfo.HasNextPage {
+		t.Errorf("page = %+v, info %+v, total %d", page.Edges, page.PageInfo, page.TotalCount)
+	}
+}
+
+func TestProductsConnectionSorted(t *testing.T) {
+	resolver := NewResolver(WithOwners(&fakeOwners{known: map[string]bool{"1": true}}))
+	ctx := context.Background()
+	byPrice := &model.ProductSort{Field: model.ProductSortFieldPrice, Direction: model.SortDirectionDesc}
+
+	want, err := resolver.Query().Products(ctx, nil, byPrice)
+	if err != nil {
+		t.Fatalf("Products() error = %v", err)
+	}
+
+	first, err := resolver.Query().ProductsConnection(ctx, nil, byPrice, intPtr(3), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("ProductsConnection() error = %v", err)
+	}
+
+	// O produto do cursor sai da lista, mas o cursor guarda o preço e o ID
+	cursor := first.Edges[2].Node
+	if _, err := resolver.Mutation().DeleteProduct(ctx, cursor.ID); err != nil {
+		t.Fatalf("DeleteProduct() error = %v", err)
+	}
+
+	var got []string
+	for _, edge := range first.Edges {
+		got = append(got, edge.Node.ID)
+	}
+	for after := first.PageInfo.EndCursor; ; {
+		page, err := resolver.Query().ProductsConnection(ctx, nil, byPrice, intPtr(3), after, nil, nil)
+		if err != nil {
+			t.Fatalf("ProductsConnection() error = %v", err)
+		}
+		for _, edge := range page.Edges {
+			got = append(got, edge.Node.ID)
+		}
+		if !page.PageInfo.HasNextPage {
+			break
+		}
+		after = page.PageInfo.EndCursor
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("paged %v, want %d products", got, len(want))
+	}
+	for i, product := range want {
+		if got[i] != product.ID {
+			t.Fatalf("paged %v, want the order of products(sort)", got)
+		}
+	}
+}
+
+func TestProductsByCategoryConnectionSorted(t *testing.T) {
+	resolver := NewResolver()
+	byName := &model.ProductSort{Field: model.ProductSortFieldName, Direction: model.SortDirectionAsc}
+
+	page, err := resolver.Query().ProductsByCategoryConnection(context.Background(), "Electronics", byName, intPtr(2), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("ProductsByCategoryConnection() error = %v", err)
+	}
+	if len(page.Edges) != 2 || page.Edges[0].Node.Name > page.Edges[1].Node.Name {
+		t.Errorf("page = %+v, want names in ascending order", page.Edges)
+	}
+}
+
+func TestProductsConnectionRejectsCursorsOfAnotherSort(t *testing.T) {
+	resolver := NewResolver()
+	ctx := context.Background()
+	byName := &model.ProductSort{Field: model.ProductSortFieldName, Direction: model.SortDirectionAsc}
+
+	unsorted, err := resolver.Query().ProductsConnection(ctx, nil, nil, intPtr(2), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("ProductsConnection() error = %v", err)
+	}
+	_, err = resolver.Query().ProductsConnection(ctx, nil, byName, intPtr(2), unsorted.PageInfo.EndCursor, nil, nil)
+	if code := errorCode(t, err); code != errInvalidCursorCode {
+		t.Errorf("code = %q, want %q", code, errInvalidCursorCode)
+	}
+
+	sorted, err := resolver.Query().ProductsConnection(ctx, nil, byName, intPtr(2), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("ProductsConnection() error = %v", err)
+	}
+	_, err = resolver.Query().ProductsConnection(ctx, nil, nil, intPtr(2), sorted.PageInfo.EndCursor, nil, nil)
+	if code := errorCode(t, err); code != errInvalidCursorCode {
+		t.Errorf("code = %q, want %q", code, errInvalidCursorCode)
+	}
+}
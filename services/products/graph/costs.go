@@ -0,0 +1,23 @@
+package graph
+
+import (
+	"shared/extensions"
+	"shared/pagination"
+)
+
+// FieldCosts prices the products fields that do more work than a
+// plain field read. Batch lookups scale with the number of IDs requested,
+// and productsWithSemaphore and its stream also hold a semaphore slot per
+// ID. Connections and searches scale with the number of results asked for,
+// and connections without first or last with the default page size.
+var FieldCosts = map[string]extensions.FieldCost{
+	"Query.products":                           {Cost: 10},
+	"Query.productsByCategory":                 {Cost: 10},
+	"Query.productsByIds":                      {Cost: 1, ListArgument: "ids"},
+	"Query.productsWithSemaphore":              {Cost: 2, ListArgument: "ids"},
+	"Query.productsConnection":                 {Cost: 1, ListArgument: "first|last", DefaultSize: pagination.DefaultPageSize},
+	"Query.productsByCategoryConnection":       {Cost: 1, ListArgument: "first|last", DefaultSize: pagination.DefaultPageSize},
+	"Query.searchProducts":                     {Cost: 1, ListArgument: "limit"},
+	"Subscription.productsWithSemaphoreStream": {Cost: 2, ListArgument: "ids"},
+	"Query._entities":                          {Cost: 1, ListArgument: "representations"},
+}
@@ -0,0 +1,101 @@
+package graph
+
+import (
+	"shared/extensions"
+	"strconv"
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+const queryTooComplexCode = "QUERY_TOO_COMPLEX"
+
+// newLimitedClient serves the schema behind the operation policies priced
+// with FieldCosts
+func newLimitedClient(t *testing.T, cfg extensions.Config) *client.Client {
+	t.Helper()
+
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: NewResolver()}))
+	srv.AddTransport(transport.POST{})
+	if err := extensions.Install(srv, "products", FieldCosts, cfg); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	return client.New(srv)
+}
+
+// rejectedCost returns the cost reported when the operation was rejected
+// as too complex, or 0 when it was accepted
+func rejectedCost(t *testing.T, err error) float64 {
+	t.Helper()
+
+	errs := decodeErrors(t, err)
+	if len(errs) == 0 {
+		return 0
+	}
+	if code := errs[0].Extensions["code"]; code != queryTooComplexCode {
+		t.Fatalf("error code = %v, want %s", code, queryTooComplexCode)
+	}
+	cost, _ := errs[0].Extensions["cost"].(float64)
+	return cost
+}
+
+func TestFieldCostsScaleProductsWithSemaphore(t *testing.T) {
+	c := newLimitedClient(t, extensions.Config{PersistedQueryCacheSize: 10, MaxQueryCost: 100})
+	query := `query ($ids: [ID!]!) { productsWithSemaphore(ids: $ids) { id name } }`
+
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i%8 + 1)
+	}
+
+	// (2 + 2 seleções) * 10 IDs
+	var resp map[string]interface{}
+	if cost := rejectedCost(t, c.Post(query, &resp, client.Var("ids", ids[:10]))); cost != 0 {
+		t.Fatalf("10 ids should be accepted, rejected with cost %v", cost)
+	}
+	if cost := rejectedCost(t, c.Post(query, &resp, client.Var("ids", ids))); cost != 4000 {
+		t.Errorf("reported cost = %v, want 4000", cost)
+	}
+}
+
+func TestFieldCostsScaleProductsByIds(t *testing.T) {
+	c := newLimitedClient(t, extensions.Config{PersistedQueryCacheSize: 10, MaxQueryCost: 10})
+
+	var resp map[string]interface{}
+	if cost := rejectedCost(t, c.Post(`{ productsByIds(ids: ["1", "2", "3", "4", "5", "6"]) { id } }`, &resp)); cost != 12 {
+		t.Errorf("reported cost = %v, want 12", cost)
+	}
+}
+
+func TestFieldCostsUseSearchLimitDefault(t *testing.T) {
+	c := newLimitedClient(t, extensions.Config{PersistedQueryCacheSize: 10, MaxQueryCost: 30})
+
+	// (1 + score) * limit, que por padrão é 20
+	var resp map[string]interface{}
+	if cost := rejectedCost(t, c.Post(`{ searchProducts(query: "tenis") { score } }`, &resp)); cost != 40 {
+		t.Errorf("reported cost = %v, want 40", cost)
+	}
+	if cost := rejectedCost(t, c.Post(`{ searchProducts(query: "tenis", limit: 5) { score } }`, &resp)); cost != 0 {
+		t.Errorf("limit 5 should be accepted, rejected with cost %v", cost)
+	}
+}
+
+func TestFieldCostsUseDefaultPageSize(t *testing.T) {
+	c := newLimitedClient(t, extensions.Config{PersistedQueryCacheSize: 10, MaxQueryCost: 60})
+
+	// Sem first nem last a conexão devolve pagination.DefaultPageSize produtos:
+	// (1 + edges 1 + node 1 + id 1) * 20
+	var resp map[string]interface{}
+	if cost := rejectedCost(t, c.Post(`{ productsConnection { edges { node { id } } } }`, &resp)); cost != 80 {
+		t.Errorf("productsConnection cost = %v, want 80", cost)
+	}
+	err := c.Post(`{ productsByCategoryConnection(category: "Electronics") { edges { node { id name price } } } }`, &resp)
+	if cost := rejectedCost(t, err); cost != 120 {
+		t.Errorf("productsByCategoryConnection cost = %v, want 120", cost)
+	}
+	if cost := rejectedCost(t, c.Post(`{ productsConnection(first: 5) { edges { node { id } } } }`, &resp)); cost != 0 {
+		t.Errorf("first 5 should be accepted, rejected with cost %v", cost)
+	}
+}
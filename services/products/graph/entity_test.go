@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"context"
+	"products/graph/model"
+	"products/repository"
+	"testing"
+)
+
+func TestFindManyProductByIDs(t *testing.T) {
+	resolver := NewResolver()
+
+	reps := []*model.ProductByIDsInput{
+		{ID: "4"},
+		{ID: "999"},
+		{ID: "1"},
+	}
+
+	result, err := resolver.Entity().FindManyProductByIDs(context.Background(), reps)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Results must be aligned with the representations
+	if len(result) != len(reps) {
+		t.Fatalf("Expected %d entities, got %d", len(reps), len(result))
+	}
+	if result[0] == nil || result[0].Name != "Coffee Maker" {
+		t.Errorf("Expected Coffee Maker at index 0, got %v", result[0])
+	}
+	if result[1] != nil {
+		t.Errorf("Expected nil for unknown ID, got %v", result[1])
+	}
+	if result[2] == nil || result[2].Name != "iPhone 15 Pro" {
+		t.Errorf("Expected iPhone 15 Pro at index 2, got %v", result[2])
+	}
+}
+
+func TestProductOwnerIsReference(t *testing.T) {
+	for _, product := range repository.SeedProducts() {
+		if product.Owner == nil || product.Owner.ID == "" {
+			t.Errorf("Product %s has no owner reference", product.ID)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"products/owners"
+	"products/repository"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	errNotFoundCode         = "NOT_FOUND"
+	errOwnerNotFoundCode    = "OWNER_NOT_FOUND"
+	errUsersUnavailableCode = "USERS_SERVICE_UNAVAILABLE"
+	errInvalidLimitCode     = "INVALID_LIMIT"
+)
+
+// codedError builds a GraphQL error with a code in extensions
+func codedError(ctx context.Context, err error, message string, extensions map[string]interface{}) error {
+	return &gqlerror.Error{
+		Err:        err,
+		Message:    message,
+		Path:       graphql.GetPath(ctx),
+		Extensions: extensions,
+	}
+}
+
+// repositoryError turns repository errors into GraphQL errors with a code
+// in extensions. Validation errors also carry the offending input field.
+func repositoryError(ctx context.Context, id string, err error) error {
+	var validation *repository.ValidationError
+	switch {
+	case errors.As(err, &validation):
+		return codedError(ctx, err, validation.Message, map[string]interface{}{
+			"code":  validation.Code,
+			"field": validation.Field,
+		})
+	case errors.Is(err, repository.ErrNotFound):
+		return codedError(ctx, err, fmt.Sprintf("product %s not found", id), map[string]interface{}{
+			"code": errNotFoundCode,
+			"id":   id,
+		})
+	}
+	return err
+}
+
+// checkOwner fails unless the users service confirms ownerID exists
+func (r *Resolver) checkOwner(ctx context.Context, ownerID string) error {
+	exists, err := r.owners.Exists(ctx, ownerID)
+	if errors.Is(err, owners.ErrUnavailable) {
+		return codedError(ctx, err, fmt.Sprintf("can not check owner %s: %v", ownerID, err), map[string]interface{}{
+			"code": errUsersUnavailableCode,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return codedError(ctx, nil, fmt.Sprintf("owner %s does not exist in the users service", ownerID), map[string]interface{}{
+			"code":  errOwnerNotFoundCode,
+			"field": "ownerId",
+			"id":    ownerID,
+		})
+	}
+	return nil
+}
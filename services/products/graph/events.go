@@ -0,0 +1,26 @@
+package graph
+
+import "products/graph/model"
+
+// productChangesTopic is the only topic of the productChanged broker
+const productChangesTopic = "products"
+
+// publishChange tells productChanged subscribers about a write. product is
+// nil for deletions.
+func (r *Resolver) publishChange(change model.ProductChangeType, id string, product *model.Product) {
+	r.changes.Publish(productChangesTopic, &model.ProductChangeEvent{Type: change, ID: id, Product: product})
+}
+
+// publishPriceChange tells productPriceChanged subscribers about an update
+// that changed the price
+func (r *Resolver) publishPriceChange(before, after *model.Product) {
+	if before.Price == after.Price {
+		return
+	}
+	r.prices.Publish(after.ID, &model.ProductPriceChange{
+		ID:       after.ID,
+		OldPrice: before.Price,
+		NewPrice: after.Price,
+		Product:  after,
+	})
+}
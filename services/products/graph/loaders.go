@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"products/graph/model"
+	"products/repository"
+	"shared/dataloader"
+)
+
+// Loaders are the DataLoaders of one request
+type Loaders struct {
+	Products *dataloader.Loader[string, *model.Product]
+}
+
+type loadersKey struct{}
+
+// AttachLoaders returns ctx with fresh loaders reading from the resolver
+// repository. dataloader.Middleware calls it for every request.
+func (r *Resolver) AttachLoaders(ctx context.Context) context.Context {
+	loaders := &Loaders{
+		Products: dataloader.New(ctx, "products", "products", r.loaderConfig, r.repo.GetMany),
+	}
+	return context.WithValue(ctx, loadersKey{}, loaders)
+}
+
+// loadersFrom returns the loaders of the request, nil when the request did
+// not go through dataloader.Middleware
+func loadersFrom(ctx context.Context) *Loaders {
+	loaders, _ := ctx.Value(loadersKey{}).(*Loaders)
+	return loaders
+}
+
+// loadProduct returns the product with id, nil when there is none
+func (r *Resolver) loadProduct(ctx context.Context, id string) (*model.Product, error) {
+	if loaders := loadersFrom(ctx); loaders != nil {
+		return loaders.Products.Load(ctx, id)
+	}
+	product, err := r.repo.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return product, err
+}
+
+// loadProducts returns the products aligned with ids, nil for unknown IDs
+func (r *Resolver) loadProducts(ctx context.Context, ids []string) ([]*model.Product, error) {
+	if loaders := loadersFrom(ctx); loaders != nil {
+		return loaders.Products.LoadMany(ctx, ids)
+	}
+	return r.repo.GetMany(ctx, ids)
+}
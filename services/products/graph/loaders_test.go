@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"products/graph/model"
+	"products/repository"
+	"shared/dataloader"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+// countingRepository counts the lookups that reach the repository
+type countingRepository struct {
+	repository.ProductRepository
+
+	mu    sync.Mutex
+	gets  int
+	batch [][]string
+}
+
+func (r *countingRepository) Get(ctx context.Context, id string) (*model.Product, error) {
+	r.mu.Lock()
+	r.gets++
+	r.mu.Unlock()
+	return r.ProductRepository.Get(ctx, id)
+}
+
+func (r *countingRepository) GetMany(ctx context.Context, ids []string) ([]*model.Product, error) {
+	r.mu.Lock()
+	r.batch = append(r.batch, ids)
+	r.mu.Unlock()
+	return r.ProductRepository.GetMany(ctx, ids)
+}
+
+func TestProductLookupsShareOneBatch(t *testing.T) {
+	repo := &countingRepository{ProductRepository: repository.NewMemoryRepository(repository.SeedProducts())}
+	resolver := NewResolver(WithRepository(repo), WithOwners(&fakeOwners{}))
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: resolver}))
+	srv.AddTransport(transport.POST{})
+	server := httptest.NewServer(dataloader.Middleware(resolver.AttachLoaders)(srv))
+	defer server.Close()
+
+	query := `{"query": "{ a: product(id: \"1\") { name } b: product(id: \"2\") { name } _entities(representations: [{__typename: \"Product\", id: \"1\"}, {__typename: \"Product\", id: \"3\"}]) { ... on Product { name } } }"}`
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(query))
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	resp.Body.Close()
+
+	// Os campos raiz e as entidades viram uma busca só, sem repetir o ID 1
+	if repo.gets != 0 || len(repo.batch) != 1 || len(repo.batch[0]) != 3 {
+		t.Errorf("gets = %d, batches = %v, want one batch of 3 IDs", repo.gets, repo.batch)
+	}
+}
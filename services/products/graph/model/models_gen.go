@@ -2,6 +2,31 @@
 
 package model
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type CreateProductInput struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Category    string  `json:"category"`
+	OwnerID     string  `json:"ownerId"`
+}
+
+type Mutation struct {
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor,omitempty"`
+	EndCursor       *string `json:"endCursor,omitempty"`
+}
+
 type Product struct {
 	ID          string  `json:"id"`
 	Name        string  `json:"name"`
@@ -11,9 +36,70 @@ type Product struct {
 	Owner       *User   `json:"owner"`
 }
 
+func (Product) IsEntity() {}
+
+type ProductByIDsInput struct {
+	ID string `json:"ID"`
+}
+
+type ProductChangeEvent struct {
+	Type    ProductChangeType `json:"type"`
+	ID      string            `json:"id"`
+	Product *Product          `json:"product,omitempty"`
+}
+
+type ProductConnection struct {
+	Edges      []*ProductEdge `json:"edges"`
+	PageInfo   *PageInfo      `json:"pageInfo"`
+	TotalCount int            `json:"totalCount"`
+}
+
+type ProductEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Product `json:"node"`
+}
+
+type ProductFilter struct {
+	MinPrice   *float64 `json:"minPrice,omitempty"`
+	MaxPrice   *float64 `json:"maxPrice,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	OwnerIds   []string `json:"ownerIds,omitempty"`
+	Text       *string  `json:"text,omitempty"`
+}
+
+type ProductPriceChange struct {
+	ID       string   `json:"id"`
+	OldPrice float64  `json:"oldPrice"`
+	NewPrice float64  `json:"newPrice"`
+	Product  *Product `json:"product"`
+}
+
+type ProductSearchResult struct {
+	Product    *Product           `json:"product"`
+	Score      float64            `json:"score"`
+	Highlights []*SearchHighlight `json:"highlights"`
+}
+
+type ProductSort struct {
+	Field     ProductSortField `json:"field"`
+	Direction SortDirection    `json:"direction"`
+}
+
+type ProductStreamItem struct {
+	Index   int      `json:"index"`
+	ID      string   `json:"id"`
+	Product *Product `json:"product,omitempty"`
+	Error   *string  `json:"error,omitempty"`
+}
+
 type Query struct {
 }
 
+type SearchHighlight struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+}
+
 type SemaphoreStats struct {
 	Max       int `json:"max"`
 	Current   int `json:"current"`
@@ -21,8 +107,188 @@ type SemaphoreStats struct {
 	Usage     int `json:"usage"`
 }
 
+type Subscription struct {
+}
+
+type UpdateProductInput struct {
+	Name        *string  `json:"name,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Price       *float64 `json:"price,omitempty"`
+	Category    *string  `json:"category,omitempty"`
+	OwnerID     *string  `json:"ownerId,omitempty"`
+}
+
 type User struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID string `json:"id"`
+}
+
+func (User) IsEntity() {}
+
+type ProductChangeType string
+
+const (
+	ProductChangeTypeCreated ProductChangeType = "CREATED"
+	ProductChangeTypeUpdated ProductChangeType = "UPDATED"
+	ProductChangeTypeDeleted ProductChangeType = "DELETED"
+)
+
+var AllProductChangeType = []ProductChangeType{
+	ProductChangeTypeCreated,
+	ProductChangeTypeUpdated,
+	ProductChangeTypeDeleted,
+}
+
+func (e ProductChangeType) IsValid() bool {
+	switch e {
+	case ProductChangeTypeCreated, ProductChangeTypeUpdated, ProductChangeTypeDeleted:
+		return true
+	}
+	return false
+}
+
+func (e ProductChangeType) String() string {
+	return string(e)
+}
+
+func (e *ProductChangeType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ProductChangeType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ProductChangeType", str)
+	}
+	return nil
+}
+
+func (e ProductChangeType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ProductChangeType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ProductChangeType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ProductSortField string
+
+const (
+	ProductSortFieldPrice    ProductSortField = "PRICE"
+	ProductSortFieldName     ProductSortField = "NAME"
+	ProductSortFieldCategory ProductSortField = "CATEGORY"
+)
+
+var AllProductSortField = []ProductSortField{
+	ProductSortFieldPrice,
+	ProductSortFieldName,
+	ProductSortFieldCategory,
+}
+
+func (e ProductSortField) IsValid() bool {
+	switch e {
+	case ProductSortFieldPrice, ProductSortFieldName, ProductSortFieldCategory:
+		return true
+	}
+	return false
+}
+
+func (e ProductSortField) String() string {
+	return string(e)
+}
+
+func (e *ProductSortField) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ProductSortField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ProductSortField", str)
+	}
+	return nil
+}
+
+func (e ProductSortField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ProductSortField) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ProductSortField) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type SortDirection string
+
+const (
+	SortDirectionAsc  SortDirection = "ASC"
+	SortDirectionDesc SortDirection = "DESC"
+)
+
+var AllSortDirection = []SortDirection{
+	SortDirectionAsc,
+	SortDirectionDesc,
+}
+
+func (e SortDirection) IsValid() bool {
+	switch e {
+	case SortDirectionAsc, SortDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (e SortDirection) String() string {
+	return string(e)
+}
+
+func (e *SortDirection) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SortDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SortDirection", str)
+	}
+	return nil
+}
+
+func (e SortDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *SortDirection) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e SortDirection) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,199 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"products/graph/model"
+	"products/owners"
+	"products/repository"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// fakeOwners answers owner checks without a users service
+type fakeOwners struct {
+	known map[string]bool
+	err   error
+	calls int
+}
+
+func (f *fakeOwners) Exists(ctx context.Context, id string) (bool, error) {
+	f.calls++
+	return f.known[id], f.err
+}
+
+func errorCode(t *testing.T, err error) string {
+	t.Helper()
+
+	var gqlErr *gqlerror.Error
+	if !errors.As(err, &gqlErr) {
+		t.Fatalf("expected a GraphQL error, got %T: %v", err, err)
+	}
+	code, _ := gqlErr.Extensions["code"].(string)
+	return code
+}
+
+func newMutationResolver(checker owners.Checker) MutationResolver {
+	return NewResolver(WithOwners(checker)).Mutation()
+}
+
+func validProduct(ownerID string) model.CreateProductInput {
+	return model.CreateProductInput{
+		Name:        "Kindle",
+		Description: "Leitor de livros digitais",
+		Price:       499.9,
+		Category:    "Electronics",
+		OwnerID:     ownerID,
+	}
+}
+
+func TestCreateProduct(t *testing.T) {
+	resolver := NewResolver(WithOwners(&fakeOwners{known: map[string]bool{"2": true}}))
+	ctx := context.Background()
+
+	product, err := resolver.Mutation().CreateProduct(ctx, validProduct("2"))
+	if err != nil {
+		t.Fatalf("CreateProduct() error = %v", err)
+	}
+	if product.ID != "9" || product.Owner.ID != "2" {
+		t.Errorf("CreateProduct() = %+v, want ID 9 owned by 2", product)
+	}
+
+	found, _ := resolver.Query().Product(ctx, product.ID)
+	if found == nil || found.Name != "Kindle" {
+		t.Errorf("created product is not readable: %v", found)
+	}
+}
+
+func TestCreateProductValidation(t *testing.T) {
+	checker := &fakeOwners{known: map[string]bool{"1": true}}
+	resolver := newMutationResolver(checker)
+
+	negative := validProduct("1")
+	negative.Price = -1
+	blank := validProduct("1")
+	blank.Name = ""
+
+	tests := []struct {
+		name     string
+		input    model.CreateProductInput
+		wantCode string
+	}{
+		{"negative price", negative, repository.CodeInvalidPrice},
+		{"blank name", blank, repository.CodeRequired},
+		{"unknown owner", validProduct("999"), errOwnerNotFoundCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolver.CreateProduct(context.Background(), tt.input)
+			if code := errorCode(t, err); code != tt.wantCode {
+				t.Errorf("error code = %s, want %s", code, tt.wantCode)
+			}
+		})
+	}
+
+	// Invalid input is rejected before asking the users service
+	if checker.calls != 1 {
+		t.Errorf("users service was called %d times, want 1", checker.calls)
+	}
+}
+
+func TestMutationsFailWhenUsersServiceIsDown(t *testing.T) {
+	down := &fakeOwners{err: fmt.Errorf("%w: connection refused", owners.ErrUnavailable)}
+	resolver := NewResolver(WithOwners(down))
+	ctx := context.Background()
+
+	_, err := resolver.Mutation().CreateProduct(ctx, validProduct("1"))
+	if code := errorCode(t, err); code != errUsersUnavailableCode {
+		t.Errorf("createProduct error code = %s, want %s", code, errUsersUnavailableCode)
+	}
+
+	_, err = resolver.Mutation().TransferOwnership(ctx, "1", "2")
+	if code := errorCode(t, err); code != errUsersUnavailableCode {
+		t.Errorf("transferOwnership error code = %s, want %s", code, errUsersUnavailableCode)
+	}
+
+	// Changes that do not touch the owner do not need the users service
+	name := "iPhone 15"
+	if _, err := resolver.Mutation().UpdateProduct(ctx, "1", model.UpdateProductInput{Name: &name}); err != nil {
+		t.Errorf("updateProduct without an owner change error = %v", err)
+	}
+	if _, err := resolver.Mutation().DeleteProduct(ctx, "2"); err != nil {
+		t.Errorf("deleteProduct error = %v", err)
+	}
+
+	product, _ := resolver.Query().Product(ctx, "1")
+	if product.Owner.ID != "1" {
+		t.Errorf("failed transfer changed the owner to %s", product.Owner.ID)
+	}
+}
+
+func TestUpdateProduct(t *testing.T) {
+	resolver := NewResolver(WithOwners(&fakeOwners{known: map[string]bool{"3": true}}))
+	ctx := context.Background()
+
+	price := 899.99
+	owner := "3"
+	product, err := resolver.Mutation().UpdateProduct(ctx, "1", model.UpdateProductInput{Price: &price, OwnerID: &owner})
+	if err != nil {
+		t.Fatalf("UpdateProduct() error = %v", err)
+	}
+	if product.Price != 899.99 || product.Owner.ID != "3" || product.Name != "iPhone 15 Pro" {
+		t.Errorf("UpdateProduct() = %+v", product)
+	}
+
+	unknown := "999"
+	_, err = resolver.Mutation().UpdateProduct(ctx, "1", model.UpdateProductInput{OwnerID: &unknown})
+	if code := errorCode(t, err); code != errOwnerNotFoundCode {
+		t.Errorf("error code = %s, want %s", code, errOwnerNotFoundCode)
+	}
+
+	_, err = resolver.Mutation().UpdateProduct(ctx, "999", model.UpdateProductInput{Price: &price})
+	if code := errorCode(t, err); code != errNotFoundCode {
+		t.Errorf("error code = %s, want %s", code, errNotFoundCode)
+	}
+}
+
+func TestTransferOwnership(t *testing.T) {
+	resolver := NewResolver(WithOwners(&fakeOwners{known: map[string]bool{"5": true}}))
+	ctx := context.Background()
+
+	product, err := resolver.Mutation().TransferOwnership(ctx, "4", "5")
+	if err != nil {
+		t.Fatalf("TransferOwnership() error = %v", err)
+	}
+	if product.Owner.ID != "5" {
+		t.Errorf("owner = %s, want 5", product.Owner.ID)
+	}
+
+	_, err = resolver.Mutation().TransferOwnership(ctx, "4", "6")
+	if code := errorCode(t, err); code != errOwnerNotFoundCode {
+		t.Errorf("error code = %s, want %s", code, errOwnerNotFoundCode)
+	}
+
+	_, err = resolver.Mutation().TransferOwnership(ctx, "999", "5")
+	if code := errorCode(t, err); code != errNotFoundCode {
+		t.Errorf("error code = %s, want %s", code, errNotFoundCode)
+	}
+}
+
+func TestDeleteProduct(t *testing.T) {
+	resolver := NewResolver(WithOwners(&fakeOwners{}))
+	ctx := context.Background()
+
+	id, err := resolver.Mutation().DeleteProduct(ctx, "8")
+	if err != nil || id != "8" {
+		t.Fatalf("DeleteProduct() = %q, %v", id, err)
+	}
+	if product, _ := resolver.Query().Product(ctx, "8"); product != nil {
+		t.Errorf("deleted product is still readable: %v", product)
+	}
+
+	_, err = resolver.Mutation().DeleteProduct(ctx, "8")
+	if code := errorCode(t, err); code != errNotFoundCode {
+		t.Errorf("error code = %s, want %s", code, errNotFoundCode)
+	}
+}
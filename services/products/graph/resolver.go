@@ -1,119 +1,129 @@
 package graph
 
-import "products/graph/model"
+import (
+	"products/graph/model"
+	"products/owners"
+	"products/repository"
+	"products/search"
+	"shared/cache"
+	"shared/dataloader"
+	"shared/eviction"
+	"shared/pubsub"
+)
+
+// defaultUsersURL is where owners are checked when no Checker is given
+const defaultUsersURL = "http://localhost:8081/query"
 
 type Resolver struct {
 	semaphore *Semaphore
+	repo      repository.ProductRepository
+	index     *search.IndexedRepository
+	owners    owners.Checker
+	buffer    int
+	changes   *pubsub.Broker[*model.ProductChangeEvent]
+	prices    *pubsub.Broker[*model.ProductPriceChange]
+	cache     cache.Store[string, *model.Product]
+	// Cargas de product e productsByIds atrás do cache
+	productLoader *cache.Loading[string, *model.Product]
+	batchLoader   *cache.Loading[string, *model.Product]
+
+	loaderConfig dataloader.Config
+	cachePolicy  eviction.Kind
+	cacheShards  int
+}
+
+// Option configures a Resolver
+type Option func(*Resolver)
+
+// WithRepository sets the repository the resolvers read products from
+func WithRepository(repo repository.ProductRepository) Option {
+	return func(r *Resolver) {
+		r.repo = repo
+	}
+}
+
+// WithOwners sets how mutations check that an owner exists
+func WithOwners(checker owners.Checker) Option {
+	return func(r *Resolver) {
+		r.owners = checker
+	}
+}
+
+// WithSubscriptionBuffer sets how many events a subscriber may fall behind
+// by before events are dropped for it
+func WithSubscriptionBuffer(size int) Option {
+	return func(r *Resolver) {
+		r.buffer = size
+	}
+}
+
+// WithLoaderConfig sets how the request DataLoaders batch their keys
+func WithLoaderConfig(config dataloader.Config) Option {
+	return func(r *Resolver) {
+		r.loaderConfig = config
+	}
 }
 
-// NewResolver creates a new resolver with the semaphore configured
-func NewResolver() *Resolver {
-	return &Resolver{
-		semaphore: NewSemaphore(3), // Maximum 3 concurrent resolutions
+// WithCachePolicy sets the eviction policy of the products cache
+func WithCachePolicy(kind eviction.Kind) Option {
+	return func(r *Resolver) {
+		r.cachePolicy = kind
 	}
 }
 
+// WithCacheShards spreads the products cache over shards locks
+func WithCacheShards(shards int) Option {
+	return func(r *Resolver) {
+		r.cacheShards = shards
+	}
+}
+
+// NewResolver creates a new resolver with the semaphore configured. Products
+// are kept in memory from SeedProducts unless WithRepository is given.
+func NewResolver(opts ...Option) *Resolver {
+	r := &Resolver{
+		semaphore:    NewSemaphore(3), // Maximum 3 concurrent resolutions
+		loaderConfig: dataloader.Config{Wait: dataloader.DefaultWait, MaxBatch: dataloader.DefaultMaxBatch},
+		cachePolicy:  eviction.LRU,
+		cacheShards:  1,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.repo == nil {
+		r.repo = repository.NewMemoryRepository(repository.SeedProducts())
+	}
+	// Todas as escritas passam pelo índice de busca
+	r.index = search.NewIndexedRepository(r.repo)
+	r.repo = r.index
+	// Cache na frente de product e productsByIds, mantido pelas mutations
+	r.cache = cache.NewStore[string, *model.Product]("products", "products", r.cacheShards,
+		cache.WithMaxSize(productCacheSize),
+		cache.WithTTL(productCacheTTL),
+		cache.WithPolicy(r.cachePolicy),
+	)
+	r.productLoader = r.productLoads(r.loadProduct)
+	r.batchLoader = r.productLoads(r.fetchProduct)
+	// Eventos publicados pelas mutations para as subscriptions
+	r.changes = pubsub.NewBroker[*model.ProductChangeEvent]("products", "productChanged", r.buffer)
+	r.prices = pubsub.NewBroker[*model.ProductPriceChange]("products", "productPriceChanged", r.buffer)
+	if r.owners == nil {
+		r.owners = owners.NewHTTPChecker(defaultUsersURL, nil)
+	}
+	return r
+}
+
 // Semaphore returns the semaphore of the resolver
 func (r *Resolver) Semaphore() *Semaphore {
 	return r.semaphore
 }
 
-// Mock data for products
-var products = []*model.Product{
-	{
-		ID:          "1",
-		Name:        "iPhone 15 Pro",
-		Description: "Smartphone Apple com chip A17 Pro",
-		Price:       999.99,
-		Category:    "Electronics",
-		Owner: &model.User{
-			ID:    "1",
-			Name:  "Alice",
-			Email: "alice@example.com",
-		},
-	},
-	{
-		ID:          "2",
-		Name:        "MacBook Air M2",
-		Description: "Notebook Apple com chip M2",
-		Price:       1199.99,
-		Category:    "Electronics",
-		Owner: &model.User{
-			ID:    "2",
-			Name:  "Bob",
-			Email: "bob@example.com",
-		},
-	},
-	{
-		ID:          "3",
-		Name:        "Nike Air Max",
-		Description: "Tênis esportivo Nike",
-		Price:       129.99,
-		Category:    "Sports",
-		Owner: &model.User{
-			ID:    "1",
-			Name:  "Alice",
-			Email: "alice@example.com",
-		},
-	},
-	{
-		ID:          "4",
-		Name:        "Coffee Maker",
-		Description: "Máquina de café automática",
-		Price:       89.99,
-		Category:    "Home",
-		Owner: &model.User{
-			ID:    "3",
-			Name:  "Charlie",
-			Email: "charlie@example.com",
-		},
-	},
-	{
-		ID:          "5",
-		Name:        "Gaming Mouse",
-		Description: "Mouse gamer com RGB",
-		Price:       79.99,
-		Category:    "Electronics",
-		Owner: &model.User{
-			ID:    "4",
-			Name:  "Diana",
-			Email: "diana@example.com",
-		},
-	},
-	{
-		ID:          "6",
-		Name:        "Yoga Mat",
-		Description: "Tapete de yoga premium",
-		Price:       45.99,
-		Category:    "Sports",
-		Owner: &model.User{
-			ID:    "5",
-			Name:  "Eve",
-			Email: "eve@example.com",
-		},
-	},
-	{
-		ID:          "7",
-		Name:        "Bluetooth Speaker",
-		Description: "Alto-falante Bluetooth portátil",
-		Price:       129.99,
-		Category:    "Electronics",
-		Owner: &model.User{
-			ID:    "6",
-			Name:  "Frank",
-			Email: "frank@example.com",
-		},
-	},
-	{
-		ID:          "8",
-		Name:        "Smart Watch",
-		Description: "Relógio inteligente com monitor cardíaco",
-		Price:       299.99,
-		Category:    "Electronics",
-		Owner: &model.User{
-			ID:    "7",
-			Name:  "Grace",
-			Email: "grace@example.com",
-		},
-	},
+// Cache returns the products cache of the resolver
+func (r *Resolver) Cache() cache.Store[string, *model.Product] {
+	return r.cache
+}
+
+// Repository returns the product repository of the resolver
+func (r *Resolver) Repository() repository.ProductRepository {
+	return r.repo
 }
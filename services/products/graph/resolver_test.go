@@ -2,12 +2,14 @@ package graph
 
 import (
 	"context"
+	"products/graph/model"
+	"products/repository"
 	"testing"
 	"time"
 )
 
 func TestSemaphoreStats(t *testing.T) {
-	resolver := NewResolver()
+	resolver := NewResolver().Query()
 
 	// Test initial statistics
 	stats, err := resolver.SemaphoreStats(context.Background())
@@ -32,7 +34,7 @@ func TestSemaphoreStats(t *testing.T) {
 }
 
 func TestProductsWithSemaphore(t *testing.T) {
-	resolver := NewResolver()
+	resolver := NewResolver().Query()
 
 	// Test concurrent resolution with semaphore
 	ids := []string{"1", "2", "3", "4", "5"}
@@ -59,7 +61,7 @@ func TestProductsWithSemaphore(t *testing.T) {
 }
 
 func TestProductsByCategory(t *testing.T) {
-	resolver := NewResolver()
+	resolver := NewResolver().Query()
 
 	products, err := resolver.ProductsByCategory(context.Background(), "Electronics")
 	if err != nil {
@@ -79,13 +81,45 @@ func TestProductsByCategory(t *testing.T) {
 	t.Logf("ProductsByCategory test passed - Found %d electronics products", len(products))
 }
 
+func TestProductsFilterAndSort(t *testing.T) {
+	resolver := NewResolver().Query()
+	minPrice := 100.0
+
+	products, err := resolver.Products(context.Background(),
+		&model.ProductFilter{MinPrice: &minPrice, Categories: []string{"electronics"}},
+		&model.ProductSort{Field: model.ProductSortFieldPrice, Direction: model.SortDirectionAsc})
+	if err != nil {
+		t.Fatalf("Products() error = %v", err)
+	}
+
+	want := []string{"7", "8", "1", "2"}
+	if len(products) != len(want) {
+		t.Fatalf("Products() returned %d products, want %d", len(products), len(want))
+	}
+	for i, product := range products {
+		if product.ID != want[i] {
+			t.Errorf("products[%d] = %s, want %s", i, product.ID, want[i])
+		}
+	}
+}
+
+func TestProductsInvalidFilter(t *testing.T) {
+	resolver := NewResolver().Query()
+	minPrice, maxPrice := 50.0, 10.0
+
+	_, err := resolver.Products(context.Background(), &model.ProductFilter{MinPrice: &minPrice, MaxPrice: &maxPrice}, nil)
+	if code := errorCode(t, err); code != repository.CodeInvalidRange {
+		t.Errorf("code = %q, want %s", code, repository.CodeInvalidRange)
+	}
+}
+
 func TestProductsByIds(t *testing.T) {
-	resolver := NewResolver()
+	resolver := NewResolver().Query()
 
 	ids := []string{"1", "2", "3"}
 
 	start := time.Now()
-	products, err := resolver.ProductsByIds(context.Background(), ids)
+	products, err := resolver.ProductsByIds(context.Background(), ids, nil)
 	duration := time.Since(start)
 
 	if err != nil {
@@ -104,3 +138,34 @@ func TestProductsByIds(t *testing.T) {
 
 	t.Logf("ProductsByIds test passed - Duration: %v", duration)
 }
+
+func TestSearchProducts(t *testing.T) {
+	resolver := NewResolver(WithOwners(&fakeOwners{known: map[string]bool{"1": true}}))
+	ctx := context.Background()
+
+	results, err := resolver.Query().SearchProducts(ctx, "tênis", nil)
+	if err != nil {
+		t.Fatalf("SearchProducts() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Product.ID != "3" || len(results[0].Highlights) == 0 {
+		t.Fatalf("SearchProducts(tênis) = %+v, want the Nike product highlighted", results)
+	}
+
+	// O índice acompanha as mutations
+	created, err := resolver.Mutation().CreateProduct(ctx, model.CreateProductInput{
+		Name: "Tênis de Corrida", Description: "Tênis leve", Price: 300, Category: "Sports", OwnerID: "1",
+	})
+	if err != nil {
+		t.Fatalf("CreateProduct() error = %v", err)
+	}
+	results, _ = resolver.Query().SearchProducts(ctx, "tenis", nil)
+	if len(results) != 2 || results[0].Product.ID != created.ID {
+		t.Errorf("SearchProducts(tenis) after create = %+v, want the new product first", results)
+	}
+
+	limit := 0
+	_, err = resolver.Query().SearchProducts(ctx, "tenis", &limit)
+	if code := errorCode(t, err); code != errInvalidLimitCode {
+		t.Errorf("code = %q, want %s", code, errInvalidLimitCode)
+	}
+}
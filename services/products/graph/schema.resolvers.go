@@ -1,250 +1,222 @@
-// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
-
 package graph
 
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.76
+
 import (
 	"context"
+	"errors"
+	"fmt"
 	"products/graph/model"
-	"sync"
+	"products/repository"
+	"products/search"
+	"shared/fanout"
+	"shared/pagination"
 	"time"
 )
 
-// __resolveReference is the resolver for the __resolveReference field.
-func (r *Resolver) __resolveReference(ctx context.Context, obj interface{}) (interface{}, error) {
-	// Extrair o ID da referência
-	var id string
-	switch v := obj.(type) {
-	case map[string]interface{}:
-		if idVal, ok := v["id"]; ok {
-			if idStr, ok := idVal.(string); ok {
-				id = idStr
-			}
-		}
-	case *model.Product:
-		id = v.ID
+// CreateProduct is the resolver for the createProduct field.
+func (r *mutationResolver) CreateProduct(ctx context.Context, input model.CreateProductInput) (*model.Product, error) {
+	candidate := &model.Product{
+		Name:        input.Name,
+		Description: input.Description,
+		Price:       input.Price,
+		Category:    input.Category,
+		Owner:       &model.User{ID: input.OwnerID},
 	}
-
-	if id == "" {
-		return nil, nil
+	if err := repository.Validate(candidate); err != nil {
+		return nil, repositoryError(ctx, "", err)
 	}
 
-	// Buscar o produto pelo ID
-	for _, product := range products {
-		if product.ID == id {
-			return product, nil
-		}
+	// O dono precisa existir no subgraph de usuários
+	if err := r.checkOwner(ctx, input.OwnerID); err != nil {
+		return nil, err
 	}
 
-	return nil, nil
-}
-
-// Products is the resolver for the products field.
-func (r *Resolver) Products(ctx context.Context) ([]*model.Product, error) {
-	return products, nil
-}
-
-// Product is the resolver for the product field.
-func (r *Resolver) Product(ctx context.Context, id string) (*model.Product, error) {
-	for _, product := range products {
-		if product.ID == id {
-			return product, nil
-		}
+	product, err := r.repo.Create(ctx, input)
+	if err != nil {
+		return nil, repositoryError(ctx, "", err)
 	}
-	return nil, nil
+	r.cacheProduct(product)
+	r.publishChange(model.ProductChangeTypeCreated, product.ID, product)
+	return product, nil
 }
 
-// ProductsByIds is the resolver for the productsByIds field.
-func (r *Resolver) ProductsByIds(ctx context.Context, ids []string) ([]*model.Product, error) {
-	// Configurar contexto com timeout
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	// Criar canal para resultados e erros
-	resultChan := make(chan *model.Product, len(ids))
-	errorChan := make(chan error, len(ids))
-
-	// WaitGroup para aguardar todas as goroutines
-	var wg sync.WaitGroup
-
-	// Função para buscar um produto individual
-	fetchProduct := func(productID string) {
-		defer wg.Done()
-
-		// Simular latência de rede/database
-		select {
-		case <-time.After(100 * time.Millisecond):
-			// Simular latência variável
-		case <-ctx.Done():
-			errorChan <- ctx.Err()
-			return
-		}
+// UpdateProduct is the resolver for the updateProduct field.
+func (r *mutationResolver) UpdateProduct(ctx context.Context, id string, input model.UpdateProductInput) (*model.Product, error) {
+	before, err := r.repo.Get(ctx, id)
+	if err != nil {
+		return nil, repositoryError(ctx, id, err)
+	}
 
-		// Verificar se o contexto foi cancelado
-		select {
-		case <-ctx.Done():
-			errorChan <- ctx.Err()
-			return
-		default:
+	// Só consulta o subgraph de usuários quando o dono muda
+	if input.OwnerID != nil {
+		if err := r.checkOwner(ctx, *input.OwnerID); err != nil {
+			return nil, err
 		}
+	}
 
-		// Buscar produto
-		for _, product := range products {
-			if product.ID == productID {
-				resultChan <- product
-				return
-			}
-		}
+	product, err := r.repo.Update(ctx, id, input)
+	if err != nil {
+		return nil, repositoryError(ctx, id, err)
+	}
+	r.cacheProduct(product)
+	r.publishChange(model.ProductChangeTypeUpdated, id, product)
+	r.publishPriceChange(before, product)
+	return product, nil
+}
 
-		// Produto não encontrado
-		errorChan <- nil
+// DeleteProduct is the resolver for the deleteProduct field.
+func (r *mutationResolver) DeleteProduct(ctx context.Context, id string) (string, error) {
+	if err := r.repo.Delete(ctx, id); err != nil {
+		return "", repositoryError(ctx, id, err)
 	}
+	r.uncacheProduct(id)
+	r.publishChange(model.ProductChangeTypeDeleted, id, nil)
+	r.prices.CloseTopic(id)
+	return id, nil
+}
 
-	// Iniciar goroutines para cada ID
-	for _, id := range ids {
-		wg.Add(1)
-		go fetchProduct(id)
+// TransferOwnership is the resolver for the transferOwnership field.
+func (r *mutationResolver) TransferOwnership(ctx context.Context, id string, newOwnerID string) (*model.Product, error) {
+	if _, err := r.repo.Get(ctx, id); err != nil {
+		return nil, repositoryError(ctx, id, err)
 	}
 
-	// Goroutine para aguardar conclusão e fechar canais
-	go func() {
-		wg.Wait()
-		close(resultChan)
-		close(errorChan)
-	}()
-
-	// Coletar resultados
-	var results []*model.Product
-	var errors []error
-
-	// Coletar produtos encontrados
-	for product := range resultChan {
-		if product != nil {
-			results = append(results, product)
-		}
+	if err := r.checkOwner(ctx, newOwnerID); err != nil {
+		return nil, err
 	}
 
-	// Verificar erros
-	for err := range errorChan {
-		if err != nil {
-			errors = append(errors, err)
-		}
+	product, err := r.repo.Update(ctx, id, model.UpdateProductInput{OwnerID: &newOwnerID})
+	if err != nil {
+		return nil, repositoryError(ctx, id, err)
 	}
+	r.cacheProduct(product)
+	r.publishChange(model.ProductChangeTypeUpdated, id, product)
+	return product, nil
+}
 
-	// Se houve erros de contexto, retornar o primeiro
-	if len(errors) > 0 {
-		return results, errors[0]
+// Products is the resolver for the products field.
+func (r *queryResolver) Products(ctx context.Context, filter *model.ProductFilter, sort *model.ProductSort) ([]*model.Product, error) {
+	products, err := r.repo.Find(ctx, filter, sort)
+	if err != nil {
+		return nil, repositoryError(ctx, "", err)
 	}
+	return products, nil
+}
 
-	return results, nil
+// Product is the resolver for the product field.
+func (r *queryResolver) Product(ctx context.Context, id string) (*model.Product, error) {
+	// Campos product irmãos da mesma requisição viram uma única busca
+	return cachedProduct(ctx, r.productLoader, id)
+}
+
+// ProductsByIds is the resolver for the productsByIds field.
+func (r *queryResolver) ProductsByIds(ctx context.Context, ids []string, partial *bool) ([]*model.Product, error) {
+	// Configurar contexto com timeout
+	ctx, cancel := context.WithTimeout(ctx, batchTimeout)
+	defer cancel()
+
+	// Os resultados seguem a ordem dos IDs, com nil para os não encontrados;
+	// faltas simultâneas do mesmo ID no cache viram uma única busca
+	return fetchBatch(ctx, "productsByIds", ids, r.batchLoader.GetOrLoad).resolve(ctx, partial)
 }
 
 // ProductsByCategory is the resolver for the productsByCategory field.
-func (r *Resolver) ProductsByCategory(ctx context.Context, category string) ([]*model.Product, error) {
-	var results []*model.Product
+func (r *queryResolver) ProductsByCategory(ctx context.Context, category string) ([]*model.Product, error) {
+	return r.repo.ListByCategory(ctx, category)
+}
 
-	for _, product := range products {
-		if product.Category == category {
-			results = append(results, product)
-		}
+// ProductsConnection is the resolver for the productsConnection field.
+func (r *queryResolver) ProductsConnection(ctx context.Context, filter *model.ProductFilter, sort *model.ProductSort, first *int, after *string, last *int, before *string) (*model.ProductConnection, error) {
+	// Os cursores guardam o valor de ordenação, então a ordem vem antes da página
+	products, err := r.repo.Find(ctx, filter, sort)
+	if err != nil {
+		return nil, repositoryError(ctx, "", err)
 	}
+	return productConnection(ctx, products, sort, pagination.Args{First: first, After: after, Last: last, Before: before})
+}
 
-	return results, nil
+// ProductsByCategoryConnection is the resolver for the productsByCategoryConnection field.
+func (r *queryResolver) ProductsByCategoryConnection(ctx context.Context, category string, sort *model.ProductSort, first *int, after *string, last *int, before *string) (*model.ProductConnection, error) {
+	products, err := r.repo.ListByCategory(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+	repository.SortBy(products, sort)
+	return productConnection(ctx, products, sort, pagination.Args{First: first, After: after, Last: last, Before: before})
 }
 
 // ProductsWithSemaphore is the resolver for the productsWithSemaphore field.
-func (r *Resolver) ProductsWithSemaphore(ctx context.Context, ids []string) ([]*model.Product, error) {
+func (r *queryResolver) ProductsWithSemaphore(ctx context.Context, ids []string) ([]*model.Product, error) {
+	// Com @stream os produtos seguem em payloads próprios conforme chegam
+	stream, err := streamDirective(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if stream != nil {
+		return r.streamProductsWithSemaphore(ctx, ids, stream)
+	}
+
 	// Configurar contexto com timeout
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	// Criar canal para resultados e erros
-	resultChan := make(chan *model.Product, len(ids))
-	errorChan := make(chan error, len(ids))
-
-	// WaitGroup para aguardar todas as goroutines
-	var wg sync.WaitGroup
-
-	// Função para buscar um produto individual com semáforo
-	fetchProductWithSemaphore := func(productID string) {
-		defer wg.Done()
-
-		// Adquirir permissão do semáforo
-		if err := r.semaphore.Acquire(ctx); err != nil {
-			errorChan <- err
-			return
-		}
-		defer r.semaphore.Release()
-
-		// Simular latência de rede/database (mais longa para demonstrar backpressure)
-		select {
-		case <-time.After(200 * time.Millisecond):
-			// Simular latência variável
-		case <-ctx.Done():
-			errorChan <- ctx.Err()
-			return
-		}
-
-		// Verificar se o contexto foi cancelado
-		select {
-		case <-ctx.Done():
-			errorChan <- ctx.Err()
-			return
-		default:
-		}
+	// O semáforo do resolver limita as buscas; a primeira falha cancela as
+	// buscas restantes
+	results, err := fanout.Run(ctx, fanout.Options{
+		Service: "products",
+		Name:    "productsWithSemaphore",
+		Limiter: r.semaphore,
+		Mode:    fanout.FailFast,
+	}, ids, r.fetchSlowProduct)
+	if err != nil {
+		return nil, err
+	}
 
-		// Buscar produto
-		for _, product := range products {
-			if product.ID == productID {
-				resultChan <- product
-				return
-			}
+	// Produtos não encontrados ficam de fora, os demais seguem a ordem dos IDs
+	products := make([]*model.Product, 0, len(results))
+	for _, result := range results {
+		if result.Value != nil {
+			products = append(products, result.Value)
 		}
-
-		// Produto não encontrado
-		errorChan <- nil
 	}
+	return products, nil
+}
 
-	// Iniciar goroutines para cada ID
-	for _, id := range ids {
-		wg.Add(1)
-		go fetchProductWithSemaphore(id)
+// SearchProducts is the resolver for the searchProducts field.
+func (r *queryResolver) SearchProducts(ctx context.Context, query string, limit *int) ([]*model.ProductSearchResult, error) {
+	size := search.DefaultLimit
+	if limit != nil {
+		size = *limit
 	}
 
-	// Goroutine para aguardar conclusão e fechar canais
-	go func() {
-		wg.Wait()
-		close(resultChan)
-		close(errorChan)
-	}()
-
-	// Coletar resultados
-	var results []*model.Product
-	var errors []error
-
-	// Coletar produtos encontrados
-	for product := range resultChan {
-		if product != nil {
-			results = append(results, product)
-		}
+	results, err := r.index.Search(ctx, query, size)
+	if errors.Is(err, search.ErrInvalidLimit) {
+		return nil, codedError(ctx, err, fmt.Sprintf("limit must be between 1 and %d, got %d", search.MaxLimit, size), map[string]interface{}{
+			"code":     errInvalidLimitCode,
+			"maxLimit": search.MaxLimit,
+		})
 	}
-
-	// Verificar erros
-	for err := range errorChan {
-		if err != nil {
-			errors = append(errors, err)
-		}
+	if err != nil {
+		return nil, err
 	}
 
-	// Se houve erros de contexto, retornar o primeiro
-	if len(errors) > 0 {
-		return results, errors[0]
+	// Converter os resultados do índice para o modelo GraphQL
+	out := make([]*model.ProductSearchResult, len(results))
+	for i, result := range results {
+		highlights := make([]*model.SearchHighlight, len(result.Highlights))
+		for j, h := range result.Highlights {
+			highlights[j] = &model.SearchHighlight{Field: h.Field, Snippet: h.Snippet}
+		}
+		out[i] = &model.ProductSearchResult{Product: result.Product, Score: result.Score, Highlights: highlights}
 	}
-
-	return results, nil
+	return out, nil
 }
 
 // SemaphoreStats is the resolver for the semaphoreStats field.
-func (r *Resolver) SemaphoreStats(ctx context.Context) (*model.SemaphoreStats, error) {
+func (r *queryResolver) SemaphoreStats(ctx context.Context) (*model.SemaphoreStats, error) {
 	stats := r.semaphore.Stats()
 
 	return &model.SemaphoreStats{
@@ -255,7 +227,39 @@ func (r *Resolver) SemaphoreStats(ctx context.Context) (*model.SemaphoreStats, e
 	}, nil
 }
 
+// ProductChanged is the resolver for the productChanged field.
+func (r *subscriptionResolver) ProductChanged(ctx context.Context) (<-chan *model.ProductChangeEvent, error) {
+	return r.changes.Subscribe(ctx, productChangesTopic), nil
+}
+
+// ProductPriceChanged is the resolver for the productPriceChanged field.
+func (r *subscriptionResolver) ProductPriceChanged(ctx context.Context, id string) (<-chan *model.ProductPriceChange, error) {
+	// Assinar antes de conferir o produto, para não perder uma remoção
+	// feita entre as duas etapas
+	changes := r.prices.Subscribe(ctx, id)
+	if _, err := r.repo.Get(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			r.prices.CloseTopic(id)
+		}
+		return nil, repositoryError(ctx, id, err)
+	}
+	return changes, nil
+}
+
+// ProductsWithSemaphoreStream is the resolver for the productsWithSemaphoreStream field.
+func (r *subscriptionResolver) ProductsWithSemaphoreStream(ctx context.Context, ids []string) (<-chan *model.ProductStreamItem, error) {
+	return r.streamWithSemaphore(ctx, ids), nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
@@ -33,8 +33,10 @@ func (s *Semaphore) Acquire(ctx context.Context) error {
 	case s.permits <- struct{}{}:
 		s.mu.Lock()
 		s.current++
+		// As métricas recebem os valores lidos sob o lock
+		current, max := s.current, s.max
 		s.mu.Unlock()
-		metrics.UpdateSemaphoreMetrics("products", s.current, s.max)
+		metrics.UpdateSemaphoreMetrics("products", current, max)
 		return nil
 	case <-ctx.Done():
 		return ctx.Err()
@@ -111,3 +111,38 @@ func TestSemaphoreTimeout(t *testing.T) {
 
 	t.Log("Semaphore timeout test passed")
 }
+
+func TestProductsWithSemaphoreKeepsInputOrder(t *testing.T) {
+	resolver := NewResolver().Query()
+	ids := []string{"4", "999", "2", "1"}
+
+	products, err := resolver.ProductsWithSemaphore(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("ProductsWithSemaphore() error = %v", err)
+	}
+	// O ID inexistente fica de fora e os demais seguem a ordem pedida
+	want := []string{"4", "2", "1"}
+	if len(products) != len(want) {
+		t.Fatalf("got %d products, want %d", len(products), len(want))
+	}
+	for i, product := range products {
+		if product.ID != want[i] {
+			t.Errorf("products[%d] = %s, want %s", i, product.ID, want[i])
+		}
+	}
+}
+
+func TestProductsWithSemaphoreFailsFast(t *testing.T) {
+	resolver := NewResolver().Query()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := resolver.ProductsWithSemaphore(ctx, []string{"1", "2", "3", "4", "5", "6"})
+	if err == nil {
+		t.Fatal("expected a timeout error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("ProductsWithSemaphore() took %v, want the waiting fetches canceled", elapsed)
+	}
+}
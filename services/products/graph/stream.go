@@ -0,0 +1,238 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"products/graph/model"
+	"products/incremental"
+	"products/repository"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// streamTimeout bounds a productsWithSemaphoreStream subscription and a
+// streamed productsWithSemaphore, like the timeout of productsWithSemaphore
+const streamTimeout = 10 * time.Second
+
+// fetchWithSemaphore loads one product while holding a semaphore permit.
+// Unknown IDs return a nil product and no error.
+func (r *Resolver) fetchWithSemaphore(ctx context.Context, id string) (*model.Product, error) {
+	// Adquirir permissão do semáforo
+	if err := r.semaphore.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer r.semaphore.Release()
+	return r.fetchSlowProduct(ctx, id)
+}
+
+// fetchSlowProduct is the fetch behind the semaphore, the caller holds the
+// permit. Unknown IDs return a nil product and no error.
+func (r *Resolver) fetchSlowProduct(ctx context.Context, id string) (*model.Product, error) {
+	// Simular latência de rede/database (mais longa para demonstrar backpressure)
+	select {
+	case <-time.After(200 * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	product, err := r.repo.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return product, err
+}
+
+// fetchEach fetches ids through the semaphore with one worker per permit
+// and calls done with each product as soon as its fetch finishes. It
+// returns after the last fetch; once ctx ends the remaining fetches fail
+// right away.
+func (r *Resolver) fetchEach(ctx context.Context, ids []string, done func(index int, product *model.Product, err error)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(min(len(ids), r.semaphore.MaxCount()), 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				product, err := r.fetchWithSemaphore(ctx, ids[index])
+				done(index, product, err)
+			}
+		}()
+	}
+	for index := range ids {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// streamWithSemaphore fetches ids through the semaphore and sends each
+// product as soon as its fetch finishes, in completion order. The channel
+// is closed once every ID was sent or ctx is done.
+func (r *Resolver) streamWithSemaphore(ctx context.Context, ids []string) <-chan *model.ProductStreamItem {
+	// O buffer comporta todos os itens, então nenhuma busca fica presa
+	// esperando o cliente ler
+	out := make(chan *model.ProductStreamItem, len(ids))
+
+	go func() {
+		defer close(out)
+		ctx, cancel := context.WithTimeout(ctx, streamTimeout)
+		defer cancel()
+
+		r.fetchEach(ctx, ids, func(index int, product *model.Product, err error) {
+			item := &model.ProductStreamItem{Index: index, ID: ids[index], Product: product}
+			if err != nil {
+				message := err.Error()
+				item.Error = &message
+			}
+			out <- item
+		})
+	}()
+	return out
+}
+
+// fetched is the outcome of one fetch of fetchInOrder
+type fetched struct {
+	product *model.Product
+	err     error
+}
+
+// fetchInOrder fetches ids like fetchEach but yields the results in the
+// order of ids. The channel holds every result, so it can be abandoned.
+func (r *Resolver) fetchInOrder(ctx context.Context, ids []string) <-chan fetched {
+	slots := make([]chan fetched, len(ids))
+	for i := range slots {
+		slots[i] = make(chan fetched, 1)
+	}
+	go r.fetchEach(ctx, ids, func(index int, product *model.Product, err error) {
+		slots[index] <- fetched{product, err}
+	})
+
+	out := make(chan fetched, len(ids))
+	go func() {
+		defer close(out)
+		for _, slot := range slots {
+			out <- <-slot
+		}
+	}()
+	return out
+}
+
+// streamArgs are the @stream arguments of a field
+type streamArgs struct {
+	sink         *incremental.Sink
+	label        string
+	initialCount int
+}
+
+// streamDirective returns the @stream arguments of the field resolving in
+// ctx, nil when the field is not streamed or the transport cannot deliver
+// incrementally, in which case the whole list is returned
+func streamDirective(ctx context.Context) (*streamArgs, error) {
+	sink := incremental.SinkFrom(ctx)
+	if sink == nil {
+		return nil, nil
+	}
+	directive := graphql.GetFieldContext(ctx).Field.Directives.ForName("stream")
+	if directive == nil {
+		return nil, nil
+	}
+
+	values := directive.ArgumentMap(graphql.GetOperationContext(ctx).Variables)
+	if enabled, ok := values["if"].(bool); ok && !enabled {
+		return nil, nil
+	}
+	args := &streamArgs{sink: sink}
+	if label, ok := values["label"].(string); ok {
+		args.label = label
+	}
+	if raw, ok := values["initialCount"]; ok && raw != nil {
+		count, err := graphql.UnmarshalInt(raw)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("@stream initialCount must be a non-negative integer, got %v", raw)
+		}
+		args.initialCount = count
+	}
+	return args, nil
+}
+
+// streamProductsWithSemaphore resolves productsWithSemaphore under
+// @stream. The first initialCount products are returned for the initial
+// payload and each later one is sent once it and every product before it
+// were fetched, so the list keeps the order of ids. Unknown IDs are left
+// out and the first failed fetch ends the list with its error.
+func (r *Resolver) streamProductsWithSemaphore(ctx context.Context, ids []string, args *streamArgs) ([]*model.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, streamTimeout)
+	results := r.fetchInOrder(ctx, ids)
+
+	products := make([]*model.Product, 0, min(args.initialCount, len(ids)))
+	consumed := 0
+	for len(products) < args.initialCount && consumed < len(ids) {
+		result := <-results
+		consumed++
+		if result.err != nil {
+			cancel()
+			return nil, result.err
+		}
+		if result.product != nil {
+			products = append(products, result.product)
+		}
+	}
+	// Sem nada a enviar depois, a lista vai inteira no payload inicial
+	if consumed == len(ids) {
+		cancel()
+		return products, nil
+	}
+
+	path := graphql.GetPath(ctx)
+	stream := args.sink.Stream(path, args.label)
+	marshal := r.streamedProductMarshaler(ctx)
+	go func() {
+		defer stream.Close()
+		defer cancel()
+
+		index := len(products)
+		for result := range results {
+			if result.err != nil {
+				stream.Fail(index, gqlerror.List{gqlerror.WrapPath(append(append(ast.Path{}, path...), ast.PathIndex(index)), result.err)})
+				return
+			}
+			if result.product == nil {
+				continue
+			}
+			item, errs := marshal(index, result.product)
+			if !stream.Send(index, item, errs) {
+				return
+			}
+			index++
+		}
+	}()
+	return products, nil
+}
+
+// streamedProductMarshaler returns a function marshaling a product of the
+// list resolving in ctx with the list's selection set, as the executor does
+// for the items of the initial payload
+func (r *Resolver) streamedProductMarshaler(ctx context.Context) func(index int, product *model.Product) (json.RawMessage, gqlerror.List) {
+	ec := &executionContext{
+		OperationContext: graphql.GetOperationContext(ctx),
+		executableSchema: &executableSchema{resolvers: r},
+	}
+	selections := graphql.GetFieldContext(ctx).Field.Selections
+
+	return func(index int, product *model.Product) (json.RawMessage, gqlerror.List) {
+		itemCtx := graphql.WithFieldContext(ctx, &graphql.FieldContext{Index: &index, Result: product})
+		itemCtx = graphql.WithFreshResponseContext(itemCtx)
+
+		var buf bytes.Buffer
+		ec._Product(itemCtx, selections, product).MarshalGQL(&buf)
+		return buf.Bytes(), graphql.GetErrors(itemCtx)
+	}
+}
@@ -0,0 +1,242 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"products/incremental"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+func newStreamingHandler(resolver *Resolver) *handler.Server {
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: resolver}))
+	srv.AddTransport(transport.SSE{})
+	srv.AddTransport(incremental.Transport{})
+	srv.AddTransport(transport.POST{})
+	return srv
+}
+
+func newStreamingServer(resolver *Resolver) *client.Client {
+	return client.New(newStreamingHandler(resolver))
+}
+
+// postIncremental sends query accepting multipart/mixed and returns each
+// part with the time it arrived, or the single JSON body when the response
+// is not multipart
+func postIncremental(t *testing.T, url, query string) ([]map[string]interface{}, []time.Duration) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "multipart/mixed")
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	mediaType, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "multipart/mixed" {
+		var part map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&part); err != nil {
+			t.Fatalf("decoding %s response: %v", mediaType, err)
+		}
+		return []map[string]interface{}{part}, []time.Duration{time.Since(start)}
+	}
+
+	var parts []map[string]interface{}
+	var times []time.Duration
+	reader := multipart.NewReader(resp.Body, params["boundary"])
+	for {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			return parts, times
+		}
+		if err != nil {
+			t.Fatalf("reading part %d: %v", len(parts), err)
+		}
+		var part map[string]interface{}
+		if err := json.NewDecoder(p).Decode(&part); err != nil {
+			t.Fatalf("decoding part %d: %v", len(parts), err)
+		}
+		parts = append(parts, part)
+		times = append(times, time.Since(start))
+	}
+}
+
+func TestStreamSendsEachProductWhenReady(t *testing.T) {
+	resolver := NewResolver()
+	ids := []string{"1", "2", "3", "4", "5", "6", "999"}
+
+	start := time.Now()
+	stream := resolver.streamWithSemaphore(context.Background(), ids)
+
+	first := <-stream
+	firstAt := time.Since(start)
+	seen := map[int]bool{first.Index: true}
+	found := 0
+	if first.Product != nil {
+		found++
+	}
+	for item := range stream {
+		if seen[item.Index] || ids[item.Index] != item.ID {
+			t.Errorf("unexpected item %+v", item)
+		}
+		seen[item.Index] = true
+		if item.Product != nil {
+			found++
+		} else if item.ID != "999" || item.Error != nil {
+			t.Errorf("item %+v, want only 999 to be missing", item)
+		}
+	}
+	total := time.Since(start)
+
+	if len(seen) != len(ids) || found != 6 {
+		t.Fatalf("received %d items with %d products, want %d items and 6 products", len(seen), found, len(ids))
+	}
+	// Com 3 permissões, os 7 IDs levam 3 rodadas de 200ms; o primeiro item
+	// chega ao fim da primeira rodada
+	if firstAt > 350*time.Millisecond || total < 550*time.Millisecond {
+		t.Errorf("first item after %v and last after %v, want the first well before the last", firstAt, total)
+	}
+}
+
+func TestStreamReportsFetchErrors(t *testing.T) {
+	resolver := NewResolver()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	for item := range resolver.streamWithSemaphore(ctx, []string{"1", "2"}) {
+		if item.Product != nil || item.Error == nil {
+			t.Errorf("item %+v, want a timeout error", item)
+		}
+	}
+}
+
+func TestProductsWithSemaphoreStreamOverSSE(t *testing.T) {
+	c := newStreamingServer(NewResolver())
+
+	sub := c.SSE(context.Background(), `subscription { productsWithSemaphoreStream(ids: ["1", "4"]) { index id product { name } } }`)
+	defer sub.Close()
+
+	names := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		var resp map[string]interface{}
+		if err := sub.Next(&resp); err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		item := resp["data"].(map[string]interface{})["productsWithSemaphoreStream"].(map[string]interface{})
+		names[item["product"].(map[string]interface{})["name"].(string)] = true
+	}
+	if !names["iPhone 15 Pro"] || !names["Coffee Maker"] {
+		t.Errorf("received %v, want both products", names)
+	}
+}
+
+func TestDeferFragment(t *testing.T) {
+	c := newStreamingServer(NewResolver())
+
+	inc := c.IncrementalHTTP(context.Background(), `query {
+		product(id: "1") { id ... @defer(label: "details") { description owner { id } } }
+	}`)
+	defer inc.Close()
+
+	var initial map[string]interface{}
+	if err := inc.Next(&initial); err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	// Os campos de Product não têm resolver próprio, então o gqlgen resolve o
+	// fragmento adiado junto com a resposta inicial
+	product := initial["data"].(map[string]interface{})["product"].(map[string]interface{})
+	if product["description"] != "Smartphone Apple com chip A17 Pro" {
+		t.Errorf("description = %v", product["description"])
+	}
+	if owner := product["owner"].(map[string]interface{}); owner["id"] != "1" {
+		t.Errorf("owner = %v, want 1", owner)
+	}
+}
+
+func TestStreamDirective(t *testing.T) {
+	srv := httptest.NewServer(newStreamingHandler(NewResolver()))
+	defer srv.Close()
+
+	parts, times := postIncremental(t, srv.URL, `query {
+		productsWithSemaphore(ids: ["1", "2", "999", "3", "4"]) @stream(initialCount: 1, label: "rest") { id name }
+	}`)
+
+	// Payload inicial com um produto, um payload por produto restante (o 999
+	// não existe) e o payload final sem dados
+	if len(parts) != 5 {
+		t.Fatalf("received %d parts, want 5: %v", len(parts), parts)
+	}
+	initial := parts[0]["data"].(map[string]interface{})["productsWithSemaphore"].([]interface{})
+	if len(initial) != 1 || initial[0].(map[string]interface{})["id"] != "1" || parts[0]["hasNext"] != true {
+		t.Errorf("initial payload = %v, want product 1 and hasNext", parts[0])
+	}
+	for i, want := range []string{"2", "3", "4"} {
+		payload := parts[i+1]["incremental"].([]interface{})[0].(map[string]interface{})
+		item := payload["items"].([]interface{})[0].(map[string]interface{})
+		path := payload["path"].([]interface{})
+		if item["id"] != want || path[0] != "productsWithSemaphore" || path[1] != float64(i+1) || payload["label"] != "rest" {
+			t.Errorf("part %d = %v, want product %s at index %d", i+1, payload, want, i+1)
+		}
+	}
+	if last := parts[4]; last["hasNext"] != false || last["incremental"] != nil {
+		t.Errorf("last part = %v, want only hasNext false", last)
+	}
+	// Com 3 permissões, o primeiro produto chega depois da primeira rodada de
+	// 200ms e o quarto só depois da segunda
+	if times[0] > 350*time.Millisecond || times[3] < 350*time.Millisecond {
+		t.Errorf("parts arrived at %v, want the initial payload before the last products", times)
+	}
+}
+
+func TestStreamDirectiveWithoutIncrementalTransport(t *testing.T) {
+	c := newStreamingServer(NewResolver())
+
+	var resp struct {
+		ProductsWithSemaphore []struct{ ID string }
+	}
+	c.MustPost(`query { productsWithSemaphore(ids: ["1", "2", "3"]) @stream { id } }`, &resp)
+
+	if len(resp.ProductsWithSemaphore) != 3 {
+		t.Errorf("received %v, want the whole list", resp.ProductsWithSemaphore)
+	}
+}
+
+func TestStreamDirectiveRejectsNegativeInitialCount(t *testing.T) {
+	srv := httptest.NewServer(newStreamingHandler(NewResolver()))
+	defer srv.Close()
+
+	parts, _ := postIncremental(t, srv.URL, `query { productsWithSemaphore(ids: ["1"]) @stream(initialCount: -1) { id } }`)
+
+	if len(parts) != 1 || parts[0]["errors"] == nil {
+		t.Errorf("received %v, want a single payload with an error", parts)
+	}
+}
+
+func TestStreamDirectiveDisabled(t *testing.T) {
+	srv := httptest.NewServer(newStreamingHandler(NewResolver()))
+	defer srv.Close()
+
+	parts, _ := postIncremental(t, srv.URL, `query { productsWithSemaphore(ids: ["1", "2"]) @stream(if: false) { id } }`)
+
+	if len(parts) != 1 || parts[0]["hasNext"] != false {
+		t.Fatalf("received %v, want a single payload", parts)
+	}
+	if products := parts[0]["data"].(map[string]interface{})["productsWithSemaphore"].([]interface{}); len(products) != 2 {
+		t.Errorf("products = %v, want both", products)
+	}
+}
@@ -0,0 +1,121 @@
+package graph
+
+import (
+	"context"
+	"products/graph/model"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+func TestProductSubscriptions(t *testing.T) {
+	resolver := NewResolver(WithOwners(&fakeOwners{known: map[string]bool{"1": true}}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, err := resolver.Subscription().ProductChanged(ctx)
+	if err != nil {
+		t.Fatalf("ProductChanged() error = %v", err)
+	}
+	prices, err := resolver.Subscription().ProductPriceChanged(ctx, "1")
+	if err != nil {
+		t.Fatalf("ProductPriceChanged() error = %v", err)
+	}
+
+	next := func() *model.ProductChangeEvent {
+		t.Helper()
+		select {
+		case event := <-changes:
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("no productChanged event")
+		}
+		return nil
+	}
+
+	created, _ := resolver.Mutation().CreateProduct(context.Background(), validProduct("1"))
+	if event := next(); event.Type != model.ProductChangeTypeCreated || event.ID != created.ID {
+		t.Errorf("event = %+v, want CREATED %s", event, created.ID)
+	}
+
+	// Mudar só o nome não gera evento de preço
+	name := "iPhone 15"
+	resolver.Mutation().UpdateProduct(context.Background(), "1", model.UpdateProductInput{Name: &name})
+	next()
+	price := 899.99
+	resolver.Mutation().UpdateProduct(context.Background(), "1", model.UpdateProductInput{Price: &price})
+	if event := next(); event.Type != model.ProductChangeTypeUpdated || event.Product.Price != price {
+		t.Errorf("event = %+v, want UPDATED with the new price", event)
+	}
+
+	select {
+	case change := <-prices:
+		if change.OldPrice != 999.99 || change.NewPrice != price || change.Product.Name != name {
+			t.Errorf("price change = %+v", change)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no productPriceChanged event")
+	}
+	select {
+	case change := <-prices:
+		t.Errorf("unexpected price change %+v", change)
+	default:
+	}
+
+	resolver.Mutation().DeleteProduct(context.Background(), "1")
+	if event := next(); event.Type != model.ProductChangeTypeDeleted || event.Product != nil {
+		t.Errorf("event = %+v, want DELETED without a product", event)
+	}
+	if _, ok := <-prices; ok {
+		t.Error("price subscription still open after delete")
+	}
+}
+
+func TestProductPriceChangedUnknownProduct(t *testing.T) {
+	resolver := NewResolver()
+
+	_, err := resolver.Subscription().ProductPriceChanged(context.Background(), "999")
+	if code := errorCode(t, err); code != errNotFoundCode {
+		t.Errorf("code = %q, want %s", code, errNotFoundCode)
+	}
+}
+
+func TestProductChangedOverWebsocket(t *testing.T) {
+	resolver := NewResolver(WithOwners(&fakeOwners{known: map[string]bool{"1": true}}))
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: resolver}))
+	srv.AddTransport(transport.Websocket{})
+	srv.AddTransport(transport.POST{})
+	c := client.New(srv)
+
+	sub := c.Websocket(`subscription { productChanged { type id product { name } } }`)
+	defer sub.Close()
+
+	// Esperar a assinatura chegar ao broker antes de publicar
+	deadline := time.Now().Add(time.Second)
+	for resolver.changes.Subscribers(productChangesTopic) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription never reached the broker")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	var created struct{ CreateProduct struct{ ID string } }
+	c.MustPost(`mutation { createProduct(input: {name: "Kindle", description: "Leitor", price: 499.9, category: "Electronics", ownerId: "1"}) { id } }`, &created)
+
+	var resp struct {
+		ProductChanged struct {
+			Type    string
+			ID      string
+			Product struct{ Name string }
+		}
+	}
+	if err := sub.Next(&resp); err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if resp.ProductChanged.Type != "CREATED" || resp.ProductChanged.ID != created.CreateProduct.ID || resp.ProductChanged.Product.Name != "Kindle" {
+		t.Errorf("received %+v", resp.ProductChanged)
+	}
+}
@@ -0,0 +1,117 @@
+package incremental
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Payload is a subsequent payload of an incremental response: the data of
+// a deferred fragment, or the next item of a streamed list
+type Payload struct {
+	Data   json.RawMessage   `json:"data,omitempty"`
+	Items  []json.RawMessage `json:"items,omitempty"`
+	Path   ast.Path          `json:"path"`
+	Label  string            `json:"label,omitempty"`
+	Errors gqlerror.List     `json:"errors,omitempty"`
+}
+
+// Sink collects the streams of one operation. Streams are registered while
+// the initial payload resolves and the transport keeps the response open
+// until every stream is closed.
+type Sink struct {
+	wg       sync.WaitGroup
+	open     atomic.Int32
+	payloads chan Payload
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSink creates an empty sink
+func NewSink() *Sink {
+	return &Sink{
+		payloads: make(chan Payload),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Stream registers the stream of the list at path. It must be closed once
+// its last item was sent.
+func (s *Sink) Stream(path ast.Path, label string) *Stream {
+	s.wg.Add(1)
+	s.open.Add(1)
+	return &Stream{sink: s, path: path, label: label}
+}
+
+// Pending reports whether a stream is still open
+func (s *Sink) Pending() bool {
+	return s.open.Load() > 0
+}
+
+// payloadsUntilDone returns the payloads of every stream, closed once all
+// of them are closed
+func (s *Sink) payloadsUntilDone() <-chan Payload {
+	go func() {
+		s.wg.Wait()
+		close(s.payloads)
+	}()
+	return s.payloads
+}
+
+// Stop tells the streams nobody reads their payloads anymore
+func (s *Sink) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Stream sends the items of one streamed list
+type Stream struct {
+	sink  *Sink
+	path  ast.Path
+	label string
+}
+
+// Send delivers the item at index, with the errors raised resolving it. It
+// reports false once the client is gone and the stream should stop.
+func (st *Stream) Send(index int, item json.RawMessage, errs gqlerror.List) bool {
+	return st.send(Payload{Items: []json.RawMessage{item}, Errors: errs}, index)
+}
+
+// Fail ends the list at index with errs, the stream must not send again
+func (st *Stream) Fail(index int, errs gqlerror.List) {
+	st.send(Payload{Errors: errs}, index)
+}
+
+func (st *Stream) send(payload Payload, index int) bool {
+	payload.Path = append(append(ast.Path{}, st.path...), ast.PathIndex(index))
+	payload.Label = st.label
+	select {
+	case st.sink.payloads <- payload:
+		return true
+	case <-st.sink.stop:
+		return false
+	}
+}
+
+// Close marks the stream as done
+func (st *Stream) Close() {
+	st.sink.open.Add(-1)
+	st.sink.wg.Done()
+}
+
+type sinkKey struct{}
+
+// WithSink makes sink available to the resolvers of the operation
+func WithSink(ctx context.Context, sink *Sink) context.Context {
+	return context.WithValue(ctx, sinkKey{}, sink)
+}
+
+// SinkFrom returns the sink of the operation, nil when the transport does
+// not deliver incrementally
+func SinkFrom(ctx context.Context) *Sink {
+	sink, _ := ctx.Value(sinkKey{}).(*Sink)
+	return sink
+}
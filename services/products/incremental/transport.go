@@ -0,0 +1,185 @@
+package incremental
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const defaultBoundary = "-"
+
+// Transport serves multipart/mixed incremental delivery. Like gqlgen's
+// MultipartMixed it sends @defer fragments as they resolve, and it adds
+// @stream: resolvers that register a Stream with the Sink in their context
+// send the rest of their list as later payloads, in the 2022-08-24 format
+// ({"incremental": [{"items": [...], "path": [..., index]}], "hasNext"}).
+type Transport struct {
+	Boundary string
+}
+
+var _ graphql.Transport = Transport{}
+
+// Supports accepts JSON POST requests that accept multipart/mixed
+func (t Transport) Supports(r *http.Request) bool {
+	if !strings.Contains(r.Header.Get("Accept"), "multipart/mixed") {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return r.Method == http.MethodPost && mediaType == "application/json"
+}
+
+// Do runs the operation and writes the initial payload, then every
+// deferred fragment and streamed item until none is left
+func (t Transport) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	ctx := r.Context()
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		transport.SendErrorf(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	start := graphql.Now()
+	params := &graphql.RawParams{}
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(params); err != nil {
+		writeError(w, http.StatusBadRequest, exec.DispatchError(ctx, gqlerror.List{
+			gqlerror.Errorf("json request body could not be decoded: %+v", err),
+		}))
+		return
+	}
+	params.Headers = r.Header
+	params.ReadTime = graphql.TraceTiming{Start: start, End: graphql.Now()}
+
+	opCtx, errs := exec.CreateOperationContext(ctx, params)
+	ctx = graphql.WithOperationContext(ctx, opCtx)
+	if errs != nil {
+		status := http.StatusOK
+		if errcode.GetErrorKind(errs) == errcode.KindProtocol {
+			status = http.StatusUnprocessableEntity
+		}
+		writeError(w, status, exec.DispatchError(ctx, errs))
+		return
+	}
+	if opCtx.Operation.Operation == ast.Subscription {
+		writeError(w, http.StatusBadRequest, exec.DispatchError(ctx, gqlerror.List{
+			gqlerror.Errorf("subscriptions are served over websocket or SSE"),
+		}))
+		return
+	}
+
+	sink := NewSink()
+	// Resolvers still streaming stop once the client is gone
+	defer sink.Stop()
+	ctx = WithSink(ctx, sink)
+
+	boundary := t.Boundary
+	if boundary == "" {
+		boundary = defaultBoundary
+	}
+	w.Header().Set("Content-Type", fmt.Sprintf(`multipart/mixed;boundary="%s";deferSpec=20220824`, boundary))
+	w.Header().Set("Cache-Control", "no-cache")
+	mw := &multipartWriter{w: w, flusher: flusher, boundary: boundary}
+
+	responses, ctx := exec.DispatchOperation(ctx, opCtx)
+	initial := responses(ctx)
+
+	// Os fragmentos adiados continuam vindo do executor, os itens do sink
+	var deferred chan *graphql.Response
+	if initial.HasNext != nil && *initial.HasNext {
+		deferred = make(chan *graphql.Response)
+		go func() {
+			defer close(deferred)
+			for resp := responses(ctx); resp != nil; resp = responses(ctx) {
+				select {
+				case deferred <- resp:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	streams := sink.payloadsUntilDone()
+
+	hasNext := deferred != nil || sink.Pending()
+	initial.HasNext = &hasNext
+	mw.part(initial, !hasNext)
+	if !hasNext {
+		return
+	}
+
+	for deferred != nil || streams != nil {
+		var payload Payload
+		select {
+		case resp, ok := <-deferred:
+			if !ok {
+				deferred = nil
+				continue
+			}
+			payload = Payload{Data: resp.Data, Path: resp.Path, Label: resp.Label, Errors: resp.Errors}
+		case item, ok := <-streams:
+			if !ok {
+				streams = nil
+				continue
+			}
+			payload = item
+		case <-ctx.Done():
+			return
+		}
+		mw.part(incrementalPart{Incremental: []Payload{payload}, HasNext: true}, false)
+	}
+	// Só se sabe que o último payload foi o último depois que todos fecharam
+	mw.part(incrementalPart{HasNext: false}, true)
+}
+
+// incrementalPart is every part after the initial payload
+type incrementalPart struct {
+	Incremental []Payload `json:"incremental,omitempty"`
+	HasNext     bool      `json:"hasNext"`
+}
+
+// multipartWriter writes each part followed by its closing delimiter, so
+// clients can use a part as soon as it is flushed
+type multipartWriter struct {
+	w        io.Writer
+	flusher  http.Flusher
+	boundary string
+	started  bool
+}
+
+func (m *multipartWriter) part(body any, last bool) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		data, _ = json.Marshal(&graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("marshaling payload: %v", err)}})
+	}
+
+	if !m.started {
+		fmt.Fprintf(m.w, "--%s\r\n", m.boundary)
+		m.started = true
+	}
+	fmt.Fprintf(m.w, "Content-Type: application/json\r\n\r\n%s\r\n", data)
+	if last {
+		fmt.Fprintf(m.w, "--%s--\r\n", m.boundary)
+	} else {
+		fmt.Fprintf(m.w, "--%s\r\n", m.boundary)
+	}
+	m.flusher.Flush()
+}
+
+func writeError(w http.ResponseWriter, status int, resp *graphql.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
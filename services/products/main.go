@@ -1,22 +1,42 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"products/graph"
 	"products/handlers"
+	"products/incremental"
 	"products/logger"
 	"products/middleware"
+	"products/owners"
+	"products/repository"
+	"shared/cache"
+	"shared/dataloader"
+	"shared/eviction"
+	"shared/extensions"
+	"shared/pubsub"
+	"shared/registry"
+	"time"
 
 	"products/metrics"
 
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 const defaultPort = "8082"
 
+// cacheJanitorInterval is how often expired cache entries are dropped
+const cacheJanitorInterval = time.Minute
+
 func main() {
 	port := os.Getenv("PRODUCTS_SERVICE_PORT")
 	if port == "" {
@@ -26,16 +46,89 @@ func main() {
 	// Setup logger
 	logger := logger.SetupLogger()
 
+	// Product owners are checked against the users service
+	usersURL := os.Getenv("USERS_SERVICE_URL")
+	if usersURL == "" {
+		usersURL = "http://localhost:8081/query"
+	}
+
+	// Products are kept in SQLite when PRODUCTS_DB_PATH is set
+	repo, err := repository.FromEnv(context.Background())
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to open product repository")
+	}
+	if closer, ok := repo.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	// Subscription events are buffered per subscriber
+	bufferSize, err := pubsub.BufferSizeFromEnv()
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid subscription configuration")
+	}
+
+	// Request DataLoaders batch the product lookups of one request
+	loaderConfig, err := dataloader.ConfigFromEnv()
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid DataLoader configuration")
+	}
+
+	// The products cache evicts with the configured policy once it is full
+	cachePolicy, err := eviction.KindFromEnv("PRODUCTS_CACHE_POLICY")
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid cache configuration")
+	}
+	// Sharding spreads the cache over several locks to reduce contention
+	cacheShards, err := cache.ShardsFromEnv("PRODUCTS_CACHE_SHARDS")
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid cache configuration")
+	}
+
 	// Create resolver with semaphore
-	resolver := graph.NewResolver()
+	resolver := graph.NewResolver(
+		graph.WithRepository(repo),
+		graph.WithOwners(owners.NewHTTPChecker(usersURL, nil)),
+		graph.WithSubscriptionBuffer(bufferSize),
+		graph.WithLoaderConfig(loaderConfig),
+		graph.WithCachePolicy(cachePolicy),
+		graph.WithCacheShards(cacheShards),
+	)
+
+	// Drop expired cache entries in the background until the service stops
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	defer stopJanitor()
+	resolver.Cache().StartJanitor(janitorCtx, cacheJanitorInterval)
 
 	// Configure GraphQL
-	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	schema := graph.NewExecutableSchema(graph.Config{Resolvers: resolver})
+	srv := handler.New(schema)
+	srv.AddTransport(transport.Websocket{KeepAlivePingInterval: 10 * time.Second})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	// SSE and multipart/mixed must come before POST, which would also accept
+	// their requests: SSE streams subscriptions over plain HTTP and
+	// multipart/mixed delivers @defer fragments and @stream items as they
+	// resolve
+	srv.AddTransport(transport.SSE{})
+	srv.AddTransport(incremental.Transport{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.Use(extension.Introspection{})
+
+	// Persisted queries and safelist
+	extensionsConfig, err := extensions.ConfigFromEnv()
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid operation policy configuration")
+	}
+	if err := extensions.Install(srv, "products", graph.FieldCosts, extensionsConfig); err != nil {
+		logger.WithError(err).Fatal("Failed to configure operation policies")
+	}
 
 	// Configure mux
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/query", srv.ServeHTTP)
+	mux.Handle("/query", dataloader.Middleware(resolver.AttachLoaders)(srv))
 	mux.HandleFunc("/healthz", handlers.HealthHandler(logger))
 	mux.Handle("/metrics", promhttp.Handler())
 
@@ -58,10 +151,29 @@ func main() {
 			"http://localhost:" + port + "/healthz (Health Check)",
 			"http://localhost:" + port + "/metrics (Prometheus Metrics)",
 		},
-		"semaphore_max": resolver.Semaphore().MaxCount(),
-		"features":      []string{"semaphore", "metrics", "tracing"},
+		"semaphore_max":        resolver.Semaphore().MaxCount(),
+		"users_service":        usersURL,
+		"repository":           fmt.Sprintf("%T", repo),
+		"cache_policy":         cachePolicy,
+		"cache_shards":         cacheShards,
+		"features":             []string{"semaphore", "cache", "metrics", "tracing", "apq", "subscriptions", "defer", "dataloader"},
+		"subscription_buffer":  bufferSize,
+		"dataloader_wait":      loaderConfig.Wait.String(),
+		"dataloader_max_batch": loaderConfig.MaxBatch,
+		"safelist":             extensionsConfig.Strict,
+		"max_query_depth":      extensionsConfig.MaxQueryDepth,
+		"max_query_cost":       extensionsConfig.MaxQueryCost,
 	}).Info("Products service starting with semaphore, metrics and tracing")
 
+	// Register the schema with the registry when one is configured
+	if registryURL := os.Getenv("SCHEMA_REGISTRY_URL"); registryURL != "" {
+		routingURL := os.Getenv("PRODUCTS_SERVICE_URL")
+		if routingURL == "" {
+			routingURL = "http://localhost:" + port + "/query"
+		}
+		go registry.PushWithRetry(context.Background(), logger, registryURL, "products", routingURL, registry.SDL(schema.Schema()))
+	}
+
 	if err := http.ListenAndServe(":"+port, handlerWithMiddleware); err != nil {
 		logger.WithError(err).Fatal("Failed to start server")
 	}
@@ -0,0 +1,94 @@
+package owners
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrUnavailable is returned when the users service can not answer, so
+// the owner can not be checked either way
+var ErrUnavailable = errors.New("users service unavailable")
+
+// Checker tells whether a user exists in the users subgraph
+type Checker interface {
+	Exists(ctx context.Context, id string) (bool, error)
+}
+
+// userQuery is registered in services/users/persisted-queries.json, so it
+// still runs when the users service only accepts its safelist
+const userQuery = `query ProductOwner($id: ID!) { user(id: $id) { id } }`
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphQLResponse struct {
+	Data struct {
+		User *struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// HTTPChecker asks the users service for the user over GraphQL
+type HTTPChecker struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPChecker creates a checker for the users service at url. A nil
+// client uses one with a 3 second timeout.
+func NewHTTPChecker(url string, client *http.Client) *HTTPChecker {
+	if client == nil {
+		client = &http.Client{Timeout: 3 * time.Second}
+	}
+	return &HTTPChecker{url: url, client: client}
+}
+
+// URL returns the users service endpoint
+func (c *HTTPChecker) URL() string {
+	return c.url
+}
+
+// Exists reports whether the users service knows id. Transport failures,
+// non-200 answers and GraphQL errors are wrapped in ErrUnavailable.
+func (c *HTTPChecker) Exists(ctx context.Context, id string) (bool, error) {
+	body, err := json.Marshal(graphQLRequest{Query: userQuery, Variables: map[string]interface{}{"id": id}})
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+
+	var out graphQLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, fmt.Errorf("%w: decoding response: %v", ErrUnavailable, err)
+	}
+	if len(out.Errors) > 0 {
+		return false, fmt.Errorf("%w: %s", ErrUnavailable, out.Errors[0].Message)
+	}
+	return out.Data.User != nil, nil
+}
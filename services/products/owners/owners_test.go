@@ -0,0 +1,99 @@
+package owners
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func usersService(t *testing.T, known map[string]bool) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		id, _ := req.Variables["id"].(string)
+		if known[id] {
+			w.Write([]byte(`{"data":{"user":{"id":"` + id + `"}}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"user":null}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPCheckerExists(t *testing.T) {
+	server := usersService(t, map[string]bool{"1": true})
+	checker := NewHTTPChecker(server.URL, nil)
+
+	exists, err := checker.Exists(context.Background(), "1")
+	if err != nil || !exists {
+		t.Errorf("Exists(1) = %v, %v, want true", exists, err)
+	}
+
+	exists, err = checker.Exists(context.Background(), "999")
+	if err != nil || exists {
+		t.Errorf("Exists(999) = %v, %v, want false", exists, err)
+	}
+}
+
+func TestHTTPCheckerUnavailable(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	t.Cleanup(failing.Close)
+
+	erroring := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":[{"message":"internal system error"}],"data":null}`))
+	}))
+	t.Cleanup(erroring.Close)
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	for name, url := range map[string]string{
+		"bad status":     failing.URL,
+		"graphql errors": erroring.URL,
+		"unreachable":    closed.URL,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewHTTPChecker(url, nil).Exists(context.Background(), "1")
+			if !errors.Is(err, ErrUnavailable) {
+				t.Errorf("Exists() error = %v, want ErrUnavailable", err)
+			}
+		})
+	}
+}
+
+func TestUserQueryIsInTheUsersManifest(t *testing.T) {
+	data, err := os.ReadFile("../../users/persisted-queries.json")
+	if err != nil {
+		t.Fatalf("reading the users manifest: %v", err)
+	}
+	var manifest struct {
+		Operations []struct{ ID, Body string }
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("decoding the users manifest: %v", err)
+	}
+
+	// Com SAFELIST_MODE=strict no serviço de usuários, só queries do
+	// manifesto passam
+	sum := sha256.Sum256([]byte(userQuery))
+	for _, op := range manifest.Operations {
+		if op.Body == userQuery && op.ID == hex.EncodeToString(sum[:]) {
+			return
+		}
+	}
+	t.Errorf("the users manifest does not register %q", userQuery)
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"products/graph/model"
+)
+
+// CodeInvalidRange is returned for a filter whose minimum is above its maximum
+const CodeInvalidRange = "INVALID_RANGE"
+
+// ValidateFilter checks a filter before it reaches a backend
+func ValidateFilter(filter *model.ProductFilter) error {
+	if filter == nil || filter.MinPrice == nil || filter.MaxPrice == nil {
+		return nil
+	}
+	if *filter.MinPrice > *filter.MaxPrice {
+		return &ValidationError{
+			Field:   "maxPrice",
+			Code:    CodeInvalidRange,
+			Message: fmt.Sprintf("maxPrice %v is below minPrice %v", *filter.MaxPrice, *filter.MinPrice),
+		}
+	}
+	return nil
+}
+
+// fold normalizes text for the case-insensitive matches and sorts. Both
+// backends use it, SQLite through the fold SQL function.
+func fold(s string) string {
+	return strings.ToLower(s)
+}
+
+// filterText returns the folded search text, empty when the filter has none
+func filterText(filter *model.ProductFilter) string {
+	if filter == nil || filter.Text == nil {
+		return ""
+	}
+	return fold(strings.TrimSpace(*filter.Text))
+}
+
+// Matches reports whether product passes every condition set in filter
+func Matches(product *model.Product, filter *model.ProductFilter) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.MinPrice != nil && product.Price < *filter.MinPrice {
+		return false
+	}
+	if filter.MaxPrice != nil && product.Price > *filter.MaxPrice {
+		return false
+	}
+	if len(filter.Categories) > 0 && !containsFold(filter.Categories, product.Category) {
+		return false
+	}
+	if len(filter.OwnerIds) > 0 && !contains(filter.OwnerIds, product.Owner.ID) {
+		return false
+	}
+	if text := filterText(filter); text != "" {
+		if !strings.Contains(fold(product.Name), text) && !strings.Contains(fold(product.Description), text) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare orders two products the way Find sorts them: by the sort field
+// in its direction, then by ID in both directions. A nil sort orders by ID.
+func Compare(a, b *model.Product, order *model.ProductSort) int {
+	if order != nil {
+		c := 0
+		switch order.Field {
+		case model.ProductSortFieldPrice:
+			c = cmp.Compare(a.Price, b.Price)
+		case model.ProductSortFieldName:
+			c = strings.Compare(fold(a.Name), fold(b.Name))
+		case model.ProductSortFieldCategory:
+			c = strings.Compare(fold(a.Category), fold(b.Category))
+		}
+		if order.Direction == model.SortDirectionDesc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareIDs(a.ID, b.ID)
+}
+
+// compareIDs orders numeric IDs by value and anything else as strings
+func compareIDs(a, b string) int {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return cmp.Compare(x, y)
+	}
+	return strings.Compare(a, b)
+}
+
+// SortBy orders products by sort, or by ID when sort is nil
+func SortBy(products []*model.Product, order *model.ProductSort) {
+	sort.SliceStable(products, func(i, j int) bool { return Compare(products[i], products[j], order) < 0 })
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	value = fold(value)
+	for _, v := range values {
+		if fold(v) == value {
+			return true
+		}
+	}
+	return false
+}
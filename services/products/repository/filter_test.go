@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"products/graph/model"
+)
+
+func float(v float64) *float64 { return &v }
+func text(s string) *string    { return &s }
+
+func TestRepositoryFind(t *testing.T) {
+	for name, repo := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			testFind(t, repo)
+		})
+	}
+}
+
+func testFind(t *testing.T, repo ProductRepository) {
+	tests := []struct {
+		name   string
+		filter *model.ProductFilter
+		sort   *model.ProductSort
+		want   []string
+	}{
+		{"everything", nil, nil, []string{"1", "2", "3", "4", "5", "6", "7", "8"}},
+		{"price range", &model.ProductFilter{MinPrice: float(80), MaxPrice: float(300)}, nil, []string{"3", "4", "7", "8"}},
+		{"categories ignore case", &model.ProductFilter{Categories: []string{"sports", "HOME"}}, nil, []string{"3", "4", "6"}},
+		{"empty categories", &model.ProductFilter{Categories: []string{}}, nil, []string{"1", "2", "3", "4", "5", "6", "7", "8"}},
+		{"owners", &model.ProductFilter{OwnerIds: []string{"1", "99"}}, nil, []string{"1", "3"}},
+		{"text in description", &model.ProductFilter{Text: text("APPLE")}, nil, []string{"1", "2"}},
+		{"text with accents", &model.ProductFilter{Text: text(" TÊNIS ")}, nil, []string{"3"}},
+		{"blank text", &model.ProductFilter{Text: text("  ")}, nil, []string{"1", "2", "3", "4", "5", "6", "7", "8"}},
+		{"combined", &model.ProductFilter{Categories: []string{"Electronics"}, MaxPrice: float(300), Text: text("mouse")}, nil, []string{"5"}},
+		{"no match", &model.ProductFilter{MinPrice: float(5000)}, nil, []string{}},
+		{"price asc", nil, &model.ProductSort{Field: model.ProductSortFieldPrice, Direction: model.SortDirectionAsc}, []string{"6", "5", "4", "3", "7", "8", "1", "2"}},
+		{"price desc keeps ties by ID", nil, &model.ProductSort{Field: model.ProductSortFieldPrice, Direction: model.SortDirectionDesc}, []string{"2", "1", "8", "3", "7", "4", "5", "6"}},
+		{"name ignores case", nil, &model.ProductSort{Field: model.ProductSortFieldName, Direction: model.SortDirectionAsc}, []string{"7", "4", "5", "1", "2", "3", "8", "6"}},
+		{"filtered category desc", &model.ProductFilter{MaxPrice: float(130)}, &model.ProductSort{Field: model.ProductSortFieldCategory, Direction: model.SortDirectionDesc}, []string{"3", "6", "4", "5", "7"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, err := repo.Find(context.Background(), tt.filter, tt.sort)
+			if err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			got := make([]string, len(products))
+			for i, product := range products {
+				got[i] = product.ID
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	_, err := repo.Find(context.Background(), &model.ProductFilter{MinPrice: float(10), MaxPrice: float(5)}, nil)
+	var validation *ValidationError
+	if !errors.As(err, &validation) || validation.Code != CodeInvalidRange || validation.Field != "maxPrice" {
+		t.Errorf("Find() with an inverted range error = %v, want %s", err, CodeInvalidRange)
+	}
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+
+	"products/graph/model"
+)
+
+// MemoryRepository keeps products in a map guarded by a RWMutex
+type MemoryRepository struct {
+	mu       sync.RWMutex
+	products map[string]*model.Product
+	lastID   int
+}
+
+// NewMemoryRepository creates a repository holding a copy of products
+func NewMemoryRepository(products []*model.Product) *MemoryRepository {
+	repo := &MemoryRepository{products: make(map[string]*model.Product, len(products))}
+	for _, product := range products {
+		repo.put(clone(product))
+	}
+	return repo
+}
+
+// List returns every product ordered by ID
+func (r *MemoryRepository) List(ctx context.Context) ([]*model.Product, error) {
+	return r.filter(ctx, func(*model.Product) bool { return true })
+}
+
+// ListByCategory returns the products of a category ordered by ID
+func (r *MemoryRepository) ListByCategory(ctx context.Context, category string) ([]*model.Product, error) {
+	return r.filter(ctx, func(product *model.Product) bool { return product.Category == category })
+}
+
+// Find returns the products matching filter ordered by sort, or by ID
+func (r *MemoryRepository) Find(ctx context.Context, filter *model.ProductFilter, sort *model.ProductSort) ([]*model.Product, error) {
+	if err := ValidateFilter(filter); err != nil {
+		return nil, err
+	}
+
+	products, err := r.filter(ctx, func(product *model.Product) bool { return Matches(product, filter) })
+	if err != nil {
+		return nil, err
+	}
+	SortBy(products, sort)
+	return products, nil
+}
+
+// Get returns the product with id or ErrNotFound
+func (r *MemoryRepository) Get(ctx context.Context, id string) (*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	product, ok := r.products[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(product), nil
+}
+
+// GetMany returns the products aligned with ids, nil for unknown IDs
+func (r *MemoryRepository) GetMany(ctx context.Context, ids []string) ([]*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := make([]*model.Product, len(ids))
+	for i, id := range ids {
+		products[i] = clone(r.products[id])
+	}
+	return products, nil
+}
+
+// Create stores a new product with the next free ID
+func (r *MemoryRepository) Create(ctx context.Context, input model.CreateProductInput) (*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	product := &model.Product{
+		Name:        input.Name,
+		Description: input.Description,
+		Price:       input.Price,
+		Category:    input.Category,
+		Owner:       &model.User{ID: input.OwnerID},
+	}
+	if err := Validate(product); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product.ID = strconv.Itoa(r.lastID + 1)
+	r.put(product)
+	return clone(product), nil
+}
+
+// Update changes the fields set in input and returns the updated product
+func (r *MemoryRepository) Update(ctx context.Context, id string, input model.UpdateProductInput) (*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.products[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	updated := clone(current)
+	apply(updated, input)
+	if err := Validate(updated); err != nil {
+		return nil, err
+	}
+
+	r.put(updated)
+	return clone(updated), nil
+}
+
+// Delete removes the product with id or returns ErrNotFound
+func (r *MemoryRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.products[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.products, id)
+	return nil
+}
+
+// filter returns the products matching keep ordered by ID
+func (r *MemoryRepository) filter(ctx context.Context, keep func(*model.Product) bool) ([]*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := make([]*model.Product, 0, len(r.products))
+	for _, product := range r.products {
+		if keep(product) {
+			products = append(products, clone(product))
+		}
+	}
+	sortProducts(products)
+	return products, nil
+}
+
+// put stores a product, the caller holds the write lock
+func (r *MemoryRepository) put(product *model.Product) {
+	r.products[product.ID] = product
+	if n, err := strconv.Atoi(product.ID); err == nil && n > r.lastID {
+		r.lastID = n
+	}
+}
+
+// sortProducts orders products by numeric ID, falling back to string order
+func sortProducts(products []*model.Product) {
+	sort.Slice(products, func(i, j int) bool { return compareIDs(products[i].ID, products[j].ID) < 0 })
+}
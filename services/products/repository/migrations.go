@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration is a versioned schema change. Applied versions are recorded in
+// schema_migrations, so each one runs exactly once per database.
+type migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// migrations must only ever be appended to, never edited once released
+var migrations = []migration{
+	{
+		Version: 1,
+		Name:    "create products",
+		SQL: `CREATE TABLE products (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			name        TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			price       REAL NOT NULL CHECK (price >= 0),
+			category    TEXT NOT NULL,
+			owner_id    TEXT NOT NULL
+		)`,
+	},
+	{
+		Version: 2,
+		Name:    "index products by category and owner",
+		SQL: `CREATE INDEX idx_products_category ON products (category);
+			CREATE INDEX idx_products_owner ON products (owner_id)`,
+	},
+}
+
+// Migrate applies the pending migrations in order and returns the schema
+// version the database ends at
+func Migrate(ctx context.Context, db *sql.DB) (int, error) {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return 0, fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, db, m); err != nil {
+			return current, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		current = m.Version
+	}
+	return current, nil
+}
+
+// applyMigration runs a migration and records it in the same transaction
+func applyMigration(ctx context.Context, db *sql.DB, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"products/graph/model"
+)
+
+// ErrNotFound is returned when no product has the requested ID
+var ErrNotFound = errors.New("product not found")
+
+// ProductRepository stores the products served by the products subgraph
+type ProductRepository interface {
+	// List returns every product ordered by ID
+	List(ctx context.Context) ([]*model.Product, error)
+	// ListByCategory returns the products of a category ordered by ID
+	ListByCategory(ctx context.Context, category string) ([]*model.Product, error)
+	// Find returns the products matching filter ordered by sort, or by ID
+	// when sort is nil. A nil filter matches every product.
+	Find(ctx context.Context, filter *model.ProductFilter, sort *model.ProductSort) ([]*model.Product, error)
+	// Get returns the product with id or ErrNotFound
+	Get(ctx context.Context, id string) (*model.Product, error)
+	// GetMany returns the products aligned with ids, nil for unknown IDs
+	GetMany(ctx context.Context, ids []string) ([]*model.Product, error)
+	// Create stores a new product with the next free ID
+	Create(ctx context.Context, input model.CreateProductInput) (*model.Product, error)
+	// Update changes the fields set in input and returns the updated product
+	Update(ctx context.Context, id string, input model.UpdateProductInput) (*model.Product, error)
+	// Delete removes the product with id or returns ErrNotFound
+	Delete(ctx context.Context, id string) error
+}
+
+// FromEnv opens the SQLite database at PRODUCTS_DB_PATH, or keeps the
+// products in memory when it is not set
+func FromEnv(ctx context.Context) (ProductRepository, error) {
+	path := os.Getenv("PRODUCTS_DB_PATH")
+	if path == "" {
+		return NewMemoryRepository(SeedProducts()), nil
+	}
+	return OpenSQLite(ctx, path, SeedProducts())
+}
+
+// Validation error codes
+const (
+	CodeRequired     = "REQUIRED"
+	CodeInvalidPrice = "INVALID_PRICE"
+)
+
+// ValidationError reports an input field the repository refused to store
+type ValidationError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Validate checks the fields every stored product must have
+func Validate(product *model.Product) error {
+	required := []struct{ field, value string }{
+		{"name", product.Name},
+		{"category", product.Category},
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			return &ValidationError{Field: r.field, Code: CodeRequired, Message: r.field + " is required"}
+		}
+	}
+	if product.Owner == nil || product.Owner.ID == "" {
+		return &ValidationError{Field: "ownerId", Code: CodeRequired, Message: "ownerId is required"}
+	}
+	if product.Price < 0 {
+		return &ValidationError{Field: "price", Code: CodeInvalidPrice, Message: fmt.Sprintf("price %v can not be negative", product.Price)}
+	}
+	return nil
+}
+
+// apply copies the fields set in input over product
+func apply(product *model.Product, input model.UpdateProductInput) {
+	if input.Name != nil {
+		product.Name = *input.Name
+	}
+	if input.Description != nil {
+		product.Description = *input.Description
+	}
+	if input.Price != nil {
+		product.Price = *input.Price
+	}
+	if input.Category != nil {
+		product.Category = *input.Category
+	}
+	if input.OwnerID != nil {
+		product.Owner = &model.User{ID: *input.OwnerID}
+	}
+}
+
+// SeedProducts returns the products the service starts with. Owners are
+// User references resolved by the users subgraph.
+func SeedProducts() []*model.Product {
+	return []*model.Product{
+
+		{
+			ID:          "1",
+			Name:        "iPhone 15 Pro",
+			Description: "Smartphone Apple com chip A17 Pro",
+			Price:       999.99,
+			Category:    "Electronics",
+			Owner:       &model.User{ID: "1"},
+		},
+		{
+			ID:          "2",
+			Name:        "MacBook Air M2",
+			Description: "Notebook Apple com chip M2",
+			Price:       1199.99,
+			Category:    "Electronics",
+			Owner:       &model.User{ID: "2"},
+		},
+		{
+			ID:          "3",
+			Name:        "Nike Air Max",
+			Description: "Tênis esportivo Nike",
+			Price:       129.99,
+			Category:    "Sports",
+			Owner:       &model.User{ID: "1"},
+		},
+		{
+			ID:          "4",
+			Name:        "Coffee Maker",
+			Description: "Máquina de café automática",
+			Price:       89.99,
+			Category:    "Home",
+			Owner:       &model.User{ID: "3"},
+		},
+		{
+			ID:          "5",
+			Name:        "Gaming Mouse",
+			Description: "Mouse gamer com RGB",
+			Price:       79.99,
+			Category:    "Electronics",
+			Owner:       &model.User{ID: "4"},
+		},
+		{
+			ID:          "6",
+			Name:        "Yoga Mat",
+			Description: "Tapete de yoga premium",
+			Price:       45.99,
+			Category:    "Sports",
+			Owner:       &model.User{ID: "5"},
+		},
+		{
+			ID:          "7",
+			Name:        "Bluetooth Speaker",
+			Description: "Alto-falante Bluetooth portátil",
+			Price:       129.99,
+			Category:    "Electronics",
+			Owner:       &model.User{ID: "6"},
+		},
+		{
+			ID:          "8",
+			Name:        "Smart Watch",
+			Description: "Relógio inteligente com monitor cardíaco",
+			Price:       299.99,
+			Category:    "Electronics",
+			Owner:       &model.User{ID: "7"},
+		},
+	}
+}
+
+// clone copies a product so callers can not change the stored value
+func clone(product *model.Product) *model.Product {
+	if product == nil {
+		return nil
+	}
+	copied := *product
+	if product.Owner != nil {
+		owner := *product.Owner
+		copied.Owner = &owner
+	}
+	return &copied
+}
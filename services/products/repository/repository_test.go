@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"products/graph/model"
+)
+
+// backends returns a seeded repository of each implementation
+func backends(t *testing.T) map[string]ProductRepository {
+	t.Helper()
+
+	sqlite, err := OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "products.db"), SeedProducts())
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+
+	return map[string]ProductRepository{
+		"memory": NewMemoryRepository(SeedProducts()),
+		"sqlite": sqlite,
+	}
+}
+
+func TestRepositoryReads(t *testing.T) {
+	for name, repo := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			testReads(t, repo)
+		})
+	}
+}
+
+func testReads(t *testing.T, repo ProductRepository) {
+	ctx := context.Background()
+
+	products, err := repo.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 8 || products[0].ID != "1" || products[7].ID != "8" {
+		t.Fatalf("List() returned %d products, want the 8 seed products in ID order", len(products))
+	}
+
+	electronics, _ := repo.ListByCategory(ctx, "Electronics")
+	for _, product := range electronics {
+		if product.Category != "Electronics" {
+			t.Errorf("ListByCategory() returned a %s product", product.Category)
+		}
+	}
+	if len(electronics) != 5 {
+		t.Errorf("ListByCategory(Electronics) returned %d products, want 5", len(electronics))
+	}
+
+	if _, err := repo.Get(ctx, "999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(999) error = %v, want ErrNotFound", err)
+	}
+
+	many, _ := repo.GetMany(ctx, []string{"4", "999", "1"})
+	if len(many) != 3 || many[0].Name != "Coffee Maker" || many[1] != nil || many[2].Name != "iPhone 15 Pro" {
+		t.Errorf("GetMany() = %v, want results aligned with the IDs", many)
+	}
+}
+
+func TestRepositoryReturnsCopies(t *testing.T) {
+	for name, repo := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			testReturnsCopies(t, repo)
+		})
+	}
+}
+
+func testReturnsCopies(t *testing.T, repo ProductRepository) {
+	ctx := context.Background()
+
+	product, _ := repo.Get(ctx, "1")
+	product.Name = "Changed"
+	product.Owner.ID = "999"
+
+	again, _ := repo.Get(ctx, "1")
+	if again.Name != "iPhone 15 Pro" || again.Owner.ID != "1" {
+		t.Errorf("stored product changed through a returned value: %+v", again)
+	}
+}
+
+func TestRepositoryWrites(t *testing.T) {
+	for name, repo := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			testWrites(t, repo)
+		})
+	}
+}
+
+func testWrites(t *testing.T, repo ProductRepository) {
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, model.CreateProductInput{Name: "Kindle", Price: 10, Category: "Books", OwnerID: "1"})
+	if err != nil || created.ID != "9" {
+		t.Fatalf("Create() = %v, %v, want ID 9", created, err)
+	}
+
+	var validation *ValidationError
+	_, err = repo.Create(ctx, model.CreateProductInput{Name: "Kindle", Price: 10, OwnerID: "1"})
+	if !errors.As(err, &validation) || validation.Field != "category" {
+		t.Errorf("Create() without category error = %v", err)
+	}
+
+	price := -5.0
+	_, err = repo.Update(ctx, "9", model.UpdateProductInput{Price: &price})
+	if !errors.As(err, &validation) || validation.Code != CodeInvalidPrice {
+		t.Errorf("Update() with a negative price error = %v", err)
+	}
+	if product, _ := repo.Get(ctx, "9"); product.Price != 10 {
+		t.Errorf("rejected update changed the price to %v", product.Price)
+	}
+
+	if err := repo.Delete(ctx, "9"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, "9"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete() error = %v, want ErrNotFound", err)
+	}
+
+	// Deleted IDs are never handed out again
+	next, err := repo.Create(ctx, model.CreateProductInput{Name: "Kobo", Price: 10, Category: "Books", OwnerID: "1"})
+	if err != nil || next.ID != "10" {
+		t.Errorf("Create() after a delete = %v, %v, want ID 10", next, err)
+	}
+
+	if _, err := repo.Update(ctx, "999", model.UpdateProductInput{Price: &price}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update(999) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"products/graph/model"
+
+	"modernc.org/sqlite"
+)
+
+const productColumns = `id, name, description, price, category, owner_id`
+
+func init() {
+	// fold lets queries match and sort text exactly like the memory backend
+	sqlite.MustRegisterDeterministicScalarFunction("fold", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		switch v := args[0].(type) {
+		case string:
+			return fold(v), nil
+		case []byte:
+			return fold(string(v)), nil
+		default:
+			return v, nil
+		}
+	})
+}
+
+// SQLiteRepository stores products in a SQLite database through the pure
+// Go modernc.org/sqlite driver, so the service still builds without cgo
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the database at path, applies the pending migrations
+// and seeds it with seed when it has no products yet
+func OpenSQLite(ctx context.Context, path string, seed []*model.Product) (*SQLiteRepository, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	repo := &SQLiteRepository{db: db}
+	if err := repo.Seed(ctx, seed); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return repo, nil
+}
+
+// Close releases the database
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+// Seed inserts products, keeping their IDs, into a database that never
+// held a product. Once any product was stored it does nothing, so deleting
+// every product does not bring the seed back on the next start.
+func (r *SQLiteRepository) Seed(ctx context.Context, products []*model.Product) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var used int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_sequence WHERE name = 'products'`).Scan(&used); err != nil {
+		return fmt.Errorf("checking seed: %w", err)
+	}
+	if used > 0 {
+		return nil
+	}
+
+	for _, product := range products {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO products (`+productColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+			product.ID, product.Name, product.Description, product.Price, product.Category, product.Owner.ID); err != nil {
+			return fmt.Errorf("seeding product %s: %w", product.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// List returns every product ordered by ID
+func (r *SQLiteRepository) List(ctx context.Context) ([]*model.Product, error) {
+	return r.query(ctx, `SELECT `+productColumns+` FROM products ORDER BY id`)
+}
+
+// ListByCategory returns the products of a category ordered by ID
+func (r *SQLiteRepository) ListByCategory(ctx context.Context, category string) ([]*model.Product, error) {
+	return r.query(ctx, `SELECT `+productColumns+` FROM products WHERE category = ? ORDER BY id`, category)
+}
+
+// Find returns the products matching filter ordered by sort, or by ID
+func (r *SQLiteRepository) Find(ctx context.Context, filter *model.ProductFilter, sort *model.ProductSort) ([]*model.Product, error) {
+	if err := ValidateFilter(filter); err != nil {
+		return nil, err
+	}
+
+	where, args := filterSQL(filter)
+	return r.query(ctx, `SELECT `+productColumns+` FROM products`+where+` ORDER BY `+orderSQL(sort), args...)
+}
+
+// Get returns the product with id or ErrNotFound
+func (r *SQLiteRepository) Get(ctx context.Context, id string) (*model.Product, error) {
+	return get(ctx, r.db, id)
+}
+
+// GetMany returns the products aligned with ids, nil for unknown IDs
+func (r *SQLiteRepository) GetMany(ctx context.Context, ids []string) ([]*model.Product, error) {
+	if len(ids) == 0 {
+		return []*model.Product{}, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	found, err := r.query(ctx, `SELECT `+productColumns+` FROM products WHERE id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*model.Product, len(found))
+	for _, product := range found {
+		byID[product.ID] = product
+	}
+	products := make([]*model.Product, len(ids))
+	for i, id := range ids {
+		products[i] = clone(byID[id])
+	}
+	return products, nil
+}
+
+// Create stores a new product with the next free ID
+func (r *SQLiteRepository) Create(ctx context.Context, input model.CreateProductInput) (*model.Product, error) {
+	product := &model.Product{
+		Name:        input.Name,
+		Description: input.Description,
+		Price:       input.Price,
+		Category:    input.Category,
+		Owner:       &model.User{ID: input.OwnerID},
+	}
+	if err := Validate(product); err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO products (name, description, price, category, owner_id) VALUES (?, ?, ?, ?, ?)`,
+		product.Name, product.Description, product.Price, product.Category, product.Owner.ID)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	product.ID = strconv.FormatInt(id, 10)
+	return product, nil
+}
+
+// Update changes the fields set in input and returns the updated product
+func (r *SQLiteRepository) Update(ctx context.Context, id string, input model.UpdateProductInput) (*model.Product, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	product, err := get(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	apply(product, input)
+	if err := Validate(product); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE products SET name = ?, description = ?, price = ?, category = ?, owner_id = ? WHERE id = ?`,
+		product.Name, product.Description, product.Price, product.Category, product.Owner.ID, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// Delete removes the product with id or returns ErrNotFound
+func (r *SQLiteRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM products WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// filterSQL builds the WHERE clause for filter, with the same rules as
+// Matches
+func filterSQL(filter *model.ProductFilter) (string, []interface{}) {
+	if filter == nil {
+		return "", nil
+	}
+
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "price >= ?")
+		args = append(args, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "price <= ?")
+		args = append(args, *filter.MaxPrice)
+	}
+	if len(filter.Categories) > 0 {
+		conditions = append(conditions, "fold(category) IN ("+placeholders(len(filter.Categories))+")")
+		for _, category := range filter.Categories {
+			args = append(args, fold(category))
+		}
+	}
+	if len(filter.OwnerIds) > 0 {
+		conditions = append(conditions, "owner_id IN ("+placeholders(len(filter.OwnerIds))+")")
+		for _, id := range filter.OwnerIds {
+			args = append(args, id)
+		}
+	}
+	if text := filterText(filter); text != "" {
+		conditions = append(conditions, "(instr(fold(name), ?) > 0 OR instr(fold(description), ?) > 0)")
+		args = append(args, text, text)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// orderSQL builds the ORDER BY terms for sort, ties ordered by ID
+func orderSQL(sort *model.ProductSort) string {
+	if sort == nil {
+		return "id"
+	}
+
+	var column string
+	switch sort.Field {
+	case model.ProductSortFieldPrice:
+		column = "price"
+	case model.ProductSortFieldName:
+		column = "fold(name)"
+	case model.ProductSortFieldCategory:
+		column = "fold(category)"
+	default:
+		return "id"
+	}
+	if sort.Direction == model.SortDirectionDesc {
+		column += " DESC"
+	}
+	return column + ", id"
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func get(ctx context.Context, q queryer, id string) (*model.Product, error) {
+	product, err := scanProduct(q.QueryRowContext(ctx, `SELECT `+productColumns+` FROM products WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return product, err
+}
+
+func (r *SQLiteRepository) query(ctx context.Context, query string, args ...interface{}) ([]*model.Product, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []*model.Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
+
+// scanProduct reads a row selected with productColumns
+func scanProduct(row interface{ Scan(...interface{}) error }) (*model.Product, error) {
+	var (
+		id      int64
+		product model.Product
+		ownerID string
+	)
+	if err := row.Scan(&id, &product.Name, &product.Description, &product.Price, &product.Category, &ownerID); err != nil {
+		return nil, err
+	}
+	product.ID = strconv.FormatInt(id, 10)
+	product.Owner = &model.User{ID: ownerID}
+	return &product, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"products/graph/model"
+)
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	version, err := Migrate(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := migrations[len(migrations)-1].Version; version != want {
+		t.Errorf("Migrate() version = %d, want %d", version, want)
+	}
+
+	if _, err := Migrate(ctx, db); err != nil {
+		t.Fatalf("second Migrate() error = %v", err)
+	}
+	var applied int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(migrations) {
+		t.Errorf("%d migrations recorded, want %d", applied, len(migrations))
+	}
+}
+
+func TestMigrateResumesFromRecordedVersion(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "resume.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	// A database created before the indexes existed
+	all := migrations
+	migrations = all[:1]
+	_, err = Migrate(ctx, db)
+	migrations = all
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := Migrate(ctx, db)
+	if err != nil || version != 2 {
+		t.Fatalf("Migrate() = %d, %v, want version 2", version, err)
+	}
+	var indexes int
+	db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name LIKE 'idx_products_%'`).Scan(&indexes)
+	if indexes != 2 {
+		t.Errorf("found %d product indexes, want 2", indexes)
+	}
+}
+
+func TestSQLitePersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.db")
+	ctx := context.Background()
+
+	repo, err := OpenSQLite(ctx, path, SeedProducts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := repo.Create(ctx, model.CreateProductInput{Name: "Kindle", Price: 10, Category: "Books", OwnerID: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	repo.Close()
+
+	reopened, err := OpenSQLite(ctx, path, SeedProducts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	products, _ := reopened.List(ctx)
+	if len(products) != 8 {
+		t.Errorf("reopened database has %d products, want 8 (seed not applied twice)", len(products))
+	}
+	if product, err := reopened.Get(ctx, created.ID); err != nil || product.Owner.ID != "2" {
+		t.Errorf("created product was not persisted: %v, %v", product, err)
+	}
+	if _, err := reopened.Get(ctx, "1"); err != ErrNotFound {
+		t.Errorf("deleted seed product came back: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is a normalized term and where it was found in the original text
+type Token struct {
+	Term string
+	// Start and End are byte offsets of the word in the analyzed text
+	Start, End int
+}
+
+// accents maps the accented letters used in Portuguese to their base letter
+var accents = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+}
+
+// stopwords are folded Portuguese words too common to help ranking
+var stopwords = map[string]bool{
+	"a": true, "o": true, "as": true, "os": true, "ao": true, "aos": true,
+	"de": true, "da": true, "do": true, "das": true, "dos": true,
+	"e": true, "em": true, "no": true, "na": true, "nos": true, "nas": true,
+	"um": true, "uma": true, "uns": true, "umas": true,
+	"com": true, "sem": true, "para": true, "por": true, "pra": true,
+	"que": true, "se": true, "ou": true, "mais": true,
+}
+
+// Analyze splits text into words and normalizes each one with Normalize.
+// Stopwords are dropped but the offsets of the remaining tokens still point
+// into text, which is what highlighting relies on.
+func Analyze(text string) []Token {
+	var tokens []Token
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		if term := Normalize(text[start:end]); term != "" {
+			tokens = append(tokens, Token{Term: term, Start: start, End: end})
+		}
+		start = -1
+	}
+
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+	return tokens
+}
+
+// Normalize folds case and accents of a single word and stems it. It
+// returns "" for stopwords.
+func Normalize(word string) string {
+	folded := Fold(word)
+	if stopwords[folded] {
+		return ""
+	}
+	return Stem(folded)
+}
+
+// Fold lower cases s and removes Portuguese accents, so "Tênis" and
+// "tenis" are the same word
+func Fold(s string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if base, ok := accents[r]; ok {
+			return base
+		}
+		return r
+	}, s)
+}
+
+// minStem is the shortest stem a suffix rule may leave behind
+const minStem = 3
+
+// Stem is a light Portuguese stemmer for folded words. It removes the
+// adverb suffix, plurals, diminutives and the final gender vowel, enough
+// to match "máquinas" with "máquina" and "automático" with "automática"
+// without the aggressive suffix stripping of a full RSLP stemmer.
+func Stem(word string) string {
+	word = trimSuffix(word, "mente")
+	word = stemPlural(word)
+	for _, suffix := range []string{"zinho", "zinha", "inho", "inha"} {
+		if trimmed := trimSuffix(word, suffix); trimmed != word {
+			word = trimmed
+			break
+		}
+	}
+	// "botão" keeps its ending, otherwise it would turn into "bota"
+	if strings.HasSuffix(word, "ao") {
+		return word
+	}
+	for _, vowel := range []string{"a", "e", "o"} {
+		if trimmed := trimSuffix(word, vowel); trimmed != word {
+			return trimmed
+		}
+	}
+	return word
+}
+
+// plurals rewrite plural endings, checked in order
+var plurals = []struct{ suffix, replacement string }{
+	{"oes", "ao"},
+	{"aes", "ao"},
+	{"ais", "al"},
+	{"eis", "el"},
+	{"ois", "ol"},
+	{"res", "r"},
+	{"zes", "z"},
+	{"ns", "m"},
+}
+
+func stemPlural(word string) string {
+	for _, rule := range plurals {
+		if strings.HasSuffix(word, rule.suffix) && len(word)-len(rule.suffix)+len(rule.replacement) >= minStem {
+			return strings.TrimSuffix(word, rule.suffix) + rule.replacement
+		}
+	}
+	// "tenis", "onibus" and "cross" are not plurals
+	if strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "is") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "ss") {
+		return trimSuffix(word, "s")
+	}
+	return word
+}
+
+// trimSuffix removes suffix when enough of the word is left
+func trimSuffix(word, suffix string) string {
+	if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= minStem {
+		return strings.TrimSuffix(word, suffix)
+	}
+	return word
+}
@@ -0,0 +1,53 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"Tênis", "tenis"},
+		{"tenis", "tenis"},
+		{"Máquina", "maquin"},
+		{"máquinas", "maquin"},
+		{"automática", "automatic"},
+		{"automáticos", "automatic"},
+		{"Café", "caf"},
+		{"cafezinho", "caf"},
+		{"botões", "botao"},
+		{"botão", "botao"},
+		{"portáteis", "portatel"},
+		{"cores", "cor"},
+		{"rapidamente", "rapid"},
+		{"A17", "a17"},
+		{"de", ""},
+		{"com", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.word); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeKeepsOffsets(t *testing.T) {
+	text := "Máquina de café automática"
+	tokens := Analyze(text)
+
+	var terms, words []string
+	for _, token := range tokens {
+		terms = append(terms, token.Term)
+		words = append(words, text[token.Start:token.End])
+	}
+	if want := []string{"maquin", "caf", "automatic"}; !reflect.DeepEqual(terms, want) {
+		t.Errorf("terms = %v, want %v", terms, want)
+	}
+	if want := []string{"Máquina", "café", "automática"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("words = %v, want %v", words, want)
+	}
+}
@@ -0,0 +1,322 @@
+package search
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"products/graph/model"
+)
+
+// Default and maximum number of results a search returns
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// ErrInvalidLimit is returned for a limit outside 1..MaxLimit
+var ErrInvalidLimit = errors.New("invalid search limit")
+
+// Highlight markers wrapped around matched words
+const (
+	HighlightStart = "<em>"
+	HighlightEnd   = "</em>"
+)
+
+// field is a searchable product field and its weight in the score
+type field struct {
+	name   string
+	weight float64
+	value  func(*model.Product) string
+}
+
+var fields = []field{
+	{"name", 2, func(p *model.Product) string { return p.Name }},
+	{"description", 1, func(p *model.Product) string { return p.Description }},
+	{"category", 0.5, func(p *model.Product) string { return p.Category }},
+}
+
+// BM25 parameters
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Result is a product matching a search
+type Result struct {
+	Product    *model.Product
+	Score      float64
+	Highlights []Highlight
+}
+
+// Highlight is a field of the product with the matched words marked
+type Highlight struct {
+	Field   string
+	Snippet string
+}
+
+// document is an indexed product
+type document struct {
+	product *model.Product
+	tokens  [][]Token
+}
+
+// snapshot is an immutable view of the index. Writers build a new one and
+// swap it in, so searches never wait for them.
+type snapshot struct {
+	docs map[string]*document
+	// postings maps a term to the frequency of the term in each field of
+	// each product that has it
+	postings map[string]map[string][]int
+	lengths  []int
+}
+
+// Index is an in-process inverted index over the products. Searches read
+// the current snapshot without locking; writes are serialized and copy
+// only the posting lists they change.
+type Index struct {
+	mu      sync.Mutex
+	current atomic.Pointer[snapshot]
+}
+
+// NewIndex creates an index holding products
+func NewIndex(products []*model.Product) *Index {
+	idx := &Index{}
+	idx.Replace(products)
+	return idx
+}
+
+// Replace rebuilds the index from products
+func (idx *Index) Replace(products []*model.Product) {
+	snap := &snapshot{
+		docs:     make(map[string]*document, len(products)),
+		postings: make(map[string]map[string][]int),
+		lengths:  make([]int, len(fields)),
+	}
+	for _, product := range products {
+		snap.add(analyze(product), nil)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.current.Store(snap)
+}
+
+// Put adds a product or replaces the indexed version of it
+func (idx *Index) Put(product *model.Product) {
+	doc := analyze(product)
+	idx.write(func(next *snapshot, copied map[string]bool) {
+		if old, ok := next.docs[product.ID]; ok {
+			next.remove(old, copied)
+		}
+		next.add(doc, copied)
+	})
+}
+
+// Delete removes a product from the index
+func (idx *Index) Delete(id string) {
+	idx.write(func(next *snapshot, copied map[string]bool) {
+		if old, ok := next.docs[id]; ok {
+			next.remove(old, copied)
+		}
+	})
+}
+
+// Len returns the number of indexed products
+func (idx *Index) Len() int {
+	return len(idx.current.Load().docs)
+}
+
+// write applies change to a copy of the current snapshot and publishes it
+func (idx *Index) write(change func(next *snapshot, copied map[string]bool)) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	cur := idx.current.Load()
+	next := &snapshot{
+		docs:     make(map[string]*document, len(cur.docs)+1),
+		postings: make(map[string]map[string][]int, len(cur.postings)),
+		lengths:  append([]int(nil), cur.lengths...),
+	}
+	for id, doc := range cur.docs {
+		next.docs[id] = doc
+	}
+	for term, list := range cur.postings {
+		next.postings[term] = list
+	}
+
+	// Posting lists are shared with the published snapshot until a write
+	// copies them
+	change(next, make(map[string]bool))
+	idx.current.Store(next)
+}
+
+// Search returns up to limit products matching query, best matches first.
+// Every query word adds to the score of the products containing it, so
+// products matching more words rank higher.
+func (idx *Index) Search(query string, limit int) ([]Result, error) {
+	if limit < 1 || limit > MaxLimit {
+		return nil, ErrInvalidLimit
+	}
+
+	snap := idx.current.Load()
+	terms := uniqueTerms(Analyze(query))
+	if len(terms) == 0 || len(snap.docs) == 0 {
+		return []Result{}, nil
+	}
+
+	scores := make(map[string]float64)
+	for _, term := range terms {
+		list := snap.postings[term]
+		if len(list) == 0 {
+			continue
+		}
+		idf := math.Log(1 + (float64(len(snap.docs))-float64(len(list))+0.5)/(float64(len(list))+0.5))
+		for id, freqs := range list {
+			doc := snap.docs[id]
+			for f, freq := range freqs {
+				if freq == 0 {
+					continue
+				}
+				avg := float64(snap.lengths[f]) / float64(len(snap.docs))
+				norm := 1 - b + b*float64(len(doc.tokens[f]))/avg
+				scores[id] += fields[f].weight * idf * float64(freq) * (k1 + 1) / (float64(freq) + k1*norm)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return lessID(ids[i], ids[j])
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	matched := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		matched[term] = true
+	}
+	results := make([]Result, len(ids))
+	for i, id := range ids {
+		doc := snap.docs[id]
+		results[i] = Result{
+			Product:    clone(doc.product),
+			Score:      scores[id],
+			Highlights: doc.highlights(matched),
+		}
+	}
+	return results, nil
+}
+
+// add indexes doc, copying the posting lists not copied yet by this write
+func (s *snapshot) add(doc *document, copied map[string]bool) {
+	id := doc.product.ID
+	s.docs[id] = doc
+	for f, tokens := range doc.tokens {
+		s.lengths[f] += len(tokens)
+		for _, token := range tokens {
+			list := s.writable(token.Term, copied)
+			freqs, ok := list[id]
+			if !ok {
+				freqs = make([]int, len(fields))
+				list[id] = freqs
+			}
+			freqs[f]++
+		}
+	}
+}
+
+// remove drops doc from the snapshot
+func (s *snapshot) remove(doc *document, copied map[string]bool) {
+	id := doc.product.ID
+	delete(s.docs, id)
+	for f, tokens := range doc.tokens {
+		s.lengths[f] -= len(tokens)
+		for _, token := range tokens {
+			list := s.writable(token.Term, copied)
+			delete(list, id)
+			if len(list) == 0 {
+				delete(s.postings, token.Term)
+			}
+		}
+	}
+}
+
+// writable returns a posting list that is safe to change. With a nil
+// copied map the snapshot is still private and lists are changed in place.
+func (s *snapshot) writable(term string, copied map[string]bool) map[string][]int {
+	list, ok := s.postings[term]
+	if copied == nil || copied[term] {
+		if !ok {
+			list = make(map[string][]int)
+			s.postings[term] = list
+		}
+		return list
+	}
+
+	next := make(map[string][]int, len(list)+1)
+	for id, freqs := range list {
+		next[id] = freqs
+	}
+	// The frequency slices stay shared: remove drops the entry of the
+	// product being written and add gives it a fresh slice
+	s.postings[term] = next
+	copied[term] = true
+	return next
+}
+
+// highlights marks the matched words of every field that has one
+func (d *document) highlights(matched map[string]bool) []Highlight {
+	var out []Highlight
+	for f, tokens := range d.tokens {
+		text := fields[f].value(d.product)
+		var sb strings.Builder
+		last, found := 0, false
+		for _, token := range tokens {
+			if !matched[token.Term] {
+				continue
+			}
+			found = true
+			sb.WriteString(text[last:token.Start])
+			sb.WriteString(HighlightStart)
+			sb.WriteString(text[token.Start:token.End])
+			sb.WriteString(HighlightEnd)
+			last = token.End
+		}
+		if found {
+			sb.WriteString(text[last:])
+			out = append(out, Highlight{Field: fields[f].name, Snippet: sb.String()})
+		}
+	}
+	return out
+}
+
+func analyze(product *model.Product) *document {
+	doc := &document{product: clone(product), tokens: make([][]Token, len(fields))}
+	for f, fd := range fields {
+		doc.tokens[f] = Analyze(fd.value(product))
+	}
+	return doc
+}
+
+func uniqueTerms(tokens []Token) []string {
+	seen := make(map[string]bool, len(tokens))
+	var terms []string
+	for _, token := range tokens {
+		if !seen[token.Term] {
+			seen[token.Term] = true
+			terms = append(terms, token.Term)
+		}
+	}
+	return terms
+}
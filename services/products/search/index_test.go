@@ -0,0 +1,238 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"products/graph/model"
+	"products/repository"
+)
+
+func ids(results []Result) []string {
+	out := make([]string, len(results))
+	for i, result := range results {
+		out[i] = result.Product.ID
+	}
+	return out
+}
+
+func TestSearchFoldsAccentsAndStems(t *testing.T) {
+	idx := NewIndex(repository.SeedProducts())
+
+	for _, query := range []string{"tenis", "TÊNIS", "tênis nike"} {
+		results, err := idx.Search(query, DefaultLimit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) == 0 || results[0].Product.ID != "3" {
+			t.Errorf("Search(%q) = %v, want the Nike product first", query, ids(results))
+		}
+	}
+
+	results, _ := idx.Search("maquinas de cafe", DefaultLimit)
+	if got := ids(results); len(got) != 1 || got[0] != "4" {
+		t.Errorf("Search(maquinas de cafe) = %v, want [4]", got)
+	}
+}
+
+func TestSearchRanksByRelevance(t *testing.T) {
+	idx := NewIndex(repository.SeedProducts())
+
+	// "apple" aparece só na descrição; "iphone" no nome pesa mais
+	results, _ := idx.Search("iphone apple", DefaultLimit)
+	if got := ids(results); len(got) != 2 || got[0] != "1" || got[1] != "2" {
+		t.Fatalf("Search(iphone apple) = %v, want [1 2]", got)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("scores %v and %v are not decreasing", results[0].Score, results[1].Score)
+	}
+
+	results, _ = idx.Search("apple", 1)
+	if len(results) != 1 {
+		t.Errorf("limit 1 returned %d results", len(results))
+	}
+}
+
+func TestSearchHighlights(t *testing.T) {
+	idx := NewIndex(repository.SeedProducts())
+
+	results, _ := idx.Search("cafe automatico", DefaultLimit)
+	if len(results) != 1 {
+		t.Fatalf("Search() returned %d results, want 1", len(results))
+	}
+	want := []Highlight{{Field: "description", Snippet: "Máquina de <em>café</em> <em>automática</em>"}}
+	if got := results[0].Highlights; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("Highlights = %+v, want %+v", got, want)
+	}
+}
+
+func TestSearchEdgeCases(t *testing.T) {
+	idx := NewIndex(repository.SeedProducts())
+
+	for _, query := range []string{"", "  ", "de com para", "inexistente"} {
+		results, err := idx.Search(query, DefaultLimit)
+		if err != nil || len(results) != 0 {
+			t.Errorf("Search(%q) = %v, %v, want no results", query, ids(results), err)
+		}
+	}
+	for _, limit := range []int{0, MaxLimit + 1} {
+		if _, err := idx.Search("apple", limit); !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("Search() with limit %d error = %v, want ErrInvalidLimit", limit, err)
+		}
+	}
+}
+
+func TestIndexUpdates(t *testing.T) {
+	idx := NewIndex(repository.SeedProducts())
+
+	idx.Put(&model.Product{ID: "3", Name: "Chuteira Adidas", Description: "Chuteira de campo", Category: "Sports", Owner: &model.User{ID: "1"}})
+	if results, _ := idx.Search("nike", DefaultLimit); len(results) != 0 {
+		t.Errorf("old words still match: %v", ids(results))
+	}
+	if results, _ := idx.Search("chuteiras", DefaultLimit); len(results) != 1 || results[0].Product.Name != "Chuteira Adidas" {
+		t.Errorf("updated product not found: %v", ids(results))
+	}
+
+	idx.Delete("3")
+	if results, _ := idx.Search("chuteira", DefaultLimit); len(results) != 0 {
+		t.Errorf("deleted product still matches: %v", ids(results))
+	}
+	if idx.Len() != 7 {
+		t.Errorf("Len() = %d, want 7", idx.Len())
+	}
+}
+
+func TestIndexedRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewIndexedRepository(repository.NewMemoryRepository(repository.SeedProducts()))
+
+	// Escrita feita antes da primeira busca entra pela carga inicial
+	created, err := repo.Create(ctx, model.CreateProductInput{Name: "Cafeteira Italiana", Description: "Cafeteira de alumínio", Price: 150, Category: "Home", OwnerID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, _ := repo.Search(ctx, "cafeteira", DefaultLimit)
+	if got := ids(results); len(got) != 1 || got[0] != created.ID {
+		t.Errorf("Search(cafeteira) = %v, want [%s]", got, created.ID)
+	}
+
+	name := "Moka Italiana"
+	if _, err := repo.Update(ctx, created.ID, model.UpdateProductInput{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	if results, _ := repo.Search(ctx, "moka", DefaultLimit); len(results) != 1 {
+		t.Errorf("updated name not indexed: %v", ids(results))
+	}
+
+	if err := repo.Delete(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if results, _ := repo.Search(ctx, "moka", DefaultLimit); len(results) != 0 {
+		t.Errorf("deleted product still indexed: %v", ids(results))
+	}
+}
+
+func TestIndexConcurrentReadsAndWrites(t *testing.T) {
+	idx := NewIndex(repository.SeedProducts())
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				id := fmt.Sprintf("%d", 100+w*50+i)
+				idx.Put(&model.Product{ID: id, Name: "Produto " + id, Description: "Tênis de corrida", Category: "Sports", Owner: &model.User{ID: "1"}})
+				if i%2 == 0 {
+					idx.Delete(id)
+				}
+			}
+		}(w)
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if _, err := idx.Search("tenis corrida", MaxLimit); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if idx.Len() != 8+100 {
+		t.Errorf("Len() = %d, want %d", idx.Len(), 8+100)
+	}
+}
+
+// pausedRepository holds an update named paused after storing it, until
+// release is closed
+type pausedRepository struct {
+	repository.ProductRepository
+	paused  string
+	stored  chan struct{}
+	release chan struct{}
+}
+
+func (r *pausedRepository) Update(ctx context.Context, id string, input model.UpdateProductInput) (*model.Product, error) {
+	product, err := r.ProductRepository.Update(ctx, id, input)
+	if input.Name != nil && *input.Name == r.paused {
+		close(r.stored)
+		<-r.release
+	}
+	return product, err
+}
+
+func TestIndexedRepositoryKeepsWriteOrder(t *testing.T) {
+	ctx := context.Background()
+	paused := &pausedRepository{
+		ProductRepository: repository.NewMemoryRepository(repository.SeedProducts()),
+		paused:            "Cafeteira Antiga",
+		stored:            make(chan struct{}),
+		release:           make(chan struct{}),
+	}
+	repo := NewIndexedRepository(paused)
+	if _, err := repo.Search(ctx, "cafeteira", DefaultLimit); err != nil {
+		t.Fatal(err)
+	}
+
+	older, newer := "Cafeteira Antiga", "Cafeteira Moderna"
+	done := make(chan error, 1)
+	go func() {
+		_, err := repo.Update(ctx, "4", model.UpdateProductInput{Name: &older})
+		done <- err
+	}()
+	<-paused.stored
+
+	// A segunda escrita chega ao repositório depois da primeira e não pode
+	// ser sobrescrita no índice pela versão antiga
+	go func() {
+		_, err := repo.Update(ctx, "4", model.UpdateProductInput{Name: &newer})
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(paused.release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stored, _ := repo.Get(ctx, "4")
+	if stored.Name != newer {
+		t.Fatalf("repository name = %q, want %q", stored.Name, newer)
+	}
+	if results, _ := repo.Search(ctx, "antiga", DefaultLimit); len(results) != 0 {
+		t.Errorf("index kept the older name: %v", ids(results))
+	}
+	if results, _ := repo.Search(ctx, "moderna", DefaultLimit); len(results) != 1 {
+		t.Errorf("index missed the newer name: %v", ids(results))
+	}
+}
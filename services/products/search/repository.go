@@ -0,0 +1,131 @@
+package search
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"products/graph/model"
+	"products/repository"
+)
+
+// IndexedRepository keeps an Index in step with the writes made through a
+// ProductRepository. The index is built from the repository on the first
+// search, and every successful write updates it afterwards.
+type IndexedRepository struct {
+	repository.ProductRepository
+	index *Index
+
+	// mu serializes the writes, each with its index update, and orders
+	// them against the initial load, so a write made while the index is
+	// loading is not lost
+	mu     sync.Mutex
+	loaded atomic.Bool
+}
+
+// NewIndexedRepository wraps repo with an empty index
+func NewIndexedRepository(repo repository.ProductRepository) *IndexedRepository {
+	return &IndexedRepository{ProductRepository: repo, index: NewIndex(nil)}
+}
+
+// Search loads the index if needed and searches it
+func (r *IndexedRepository) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	if err := r.load(ctx); err != nil {
+		return nil, err
+	}
+	return r.index.Search(query, limit)
+}
+
+// Index returns the index, which is empty until the first search
+func (r *IndexedRepository) Index() *Index {
+	return r.index
+}
+
+// Create stores the product and indexes it
+func (r *IndexedRepository) Create(ctx context.Context, input model.CreateProductInput) (*model.Product, error) {
+	var product *model.Product
+	err := r.write(func() (err error) {
+		product, err = r.ProductRepository.Create(ctx, input)
+		return err
+	}, func(idx *Index) { idx.Put(product) })
+	return product, err
+}
+
+// Update stores the changes and reindexes the product
+func (r *IndexedRepository) Update(ctx context.Context, id string, input model.UpdateProductInput) (*model.Product, error) {
+	var product *model.Product
+	err := r.write(func() (err error) {
+		product, err = r.ProductRepository.Update(ctx, id, input)
+		return err
+	}, func(idx *Index) { idx.Put(product) })
+	return product, err
+}
+
+// Delete removes the product and drops it from the index
+func (r *IndexedRepository) Delete(ctx context.Context, id string) error {
+	return r.write(func() error {
+		return r.ProductRepository.Delete(ctx, id)
+	}, func(idx *Index) { idx.Delete(id) })
+}
+
+// load builds the index from the repository once
+func (r *IndexedRepository) load(ctx context.Context) error {
+	if r.loaded.Load() {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.loaded.Load() {
+		return nil
+	}
+
+	products, err := r.ProductRepository.List(ctx)
+	if err != nil {
+		return err
+	}
+	r.index.Replace(products)
+	r.loaded.Store(true)
+	return nil
+}
+
+// write runs a repository write and, when it succeeds, applies it to the
+// index under the same lock. Concurrent writes then reach the index in the
+// order they reached the repository, so an older version of a product can
+// not be published over a newer one. Before the index is loaded the load
+// reads the write back from the repository.
+func (r *IndexedRepository) write(store func() error, apply func(*Index)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := store(); err != nil {
+		return err
+	}
+	if r.loaded.Load() {
+		apply(r.index)
+	}
+	return nil
+}
+
+func clone(product *model.Product) *model.Product {
+	if product == nil {
+		return nil
+	}
+	copied := *product
+	if product.Owner != nil {
+		owner := *product.Owner
+		copied.Owner = &owner
+	}
+	return &copied
+}
+
+// lessID orders numeric IDs by value, falling back to string order
+func lessID(a, b string) bool {
+	x, errX := strconv.Atoi(a)
+	y, errY := strconv.Atoi(b)
+	if errX == nil && errY == nil {
+		return x < y
+	}
+	return a < b
+}
@@ -0,0 +1,120 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shared/fanout"
+	"time"
+	"users/graph/model"
+	"users/repository"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	errTimeoutCode     = "TIMEOUT"
+	errCanceledCode    = "CANCELED"
+	errFetchFailedCode = "FETCH_FAILED"
+)
+
+// batchTimeout bounds a whole batch lookup
+const batchTimeout = 5 * time.Second
+
+// batchConcurrency is how many fetches of one batch lookup run at once, so
+// a long ID list does not start one goroutine per ID
+const batchConcurrency = 32
+
+// batchResult is the outcome of a batch lookup. Items are aligned to the
+// requested IDs, with nil where nothing was returned. Missing holds a
+// NOT_FOUND error per unknown ID and failed an error per ID whose fetch
+// failed, both in ID order.
+type batchResult[T any] struct {
+	items   []*T
+	missing gqlerror.List
+	failed  gqlerror.List
+}
+
+// resolve returns the items and reports the errors of the batch. With
+// partial unset any failed fetch fails the whole field with every error;
+// with partial set the items fetched are returned next to the errors.
+// Unknown IDs never fail the field.
+func (b *batchResult[T]) resolve(ctx context.Context, partial *bool) ([]*T, error) {
+	if len(b.failed) > 0 && (partial == nil || !*partial) {
+		return nil, append(append(gqlerror.List{}, b.failed...), b.missing...)
+	}
+	addErrors(ctx, b.missing)
+	addErrors(ctx, b.failed)
+	return b.items, nil
+}
+
+// fetchBatch calls fetch for every ID, batchConcurrency at a time, and
+// collects the results in the order of ids, whatever order the fetches
+// finish in. name labels the latency metrics of the fetches.
+func fetchBatch[T any](ctx context.Context, name string, ids []string, fetch func(ctx context.Context, id string) (*T, error)) *batchResult[T] {
+	results, _ := fanout.Run(ctx, fanout.Options{Service: "users", Name: name, Concurrency: batchConcurrency, Mode: fanout.CollectAll}, ids, fetch)
+
+	batch := &batchResult[T]{items: make([]*T, len(ids))}
+	for i, result := range results {
+		if result.Err == nil {
+			batch.items[i] = result.Value
+			continue
+		}
+		itemErr := batchItemError(ctx, i, ids[i], result.Err)
+		if errors.Is(result.Err, repository.ErrNotFound) {
+			batch.missing = append(batch.missing, itemErr)
+		} else {
+			batch.failed = append(batch.failed, itemErr)
+		}
+	}
+	return batch
+}
+
+// batchItemError builds the error of one ID of a batch lookup, pointing at
+// its position in the result list
+func batchItemError(ctx context.Context, index int, id string, err error) *gqlerror.Error {
+	code, message := errFetchFailedCode, fmt.Sprintf("can not fetch user %s: %v", id, err)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		code, message = errNotFoundCode, fmt.Sprintf("user %s not found", id)
+	case errors.Is(err, context.DeadlineExceeded):
+		code, message = errTimeoutCode, fmt.Sprintf("timed out fetching user %s", id)
+	case errors.Is(err, context.Canceled):
+		code, message = errCanceledCode, fmt.Sprintf("fetch of user %s was canceled", id)
+	}
+
+	path := append(ast.Path{}, graphql.GetPath(ctx)...)
+	return &gqlerror.Error{
+		Err:     err,
+		Message: message,
+		Path:    append(path, ast.PathIndex(index)),
+		Extensions: map[string]interface{}{
+			"code": code,
+			"id":   id,
+		},
+	}
+}
+
+// addErrors adds errs to the response. Resolvers called outside of an
+// operation, as in unit tests, have no response to add them to.
+func addErrors(ctx context.Context, errs gqlerror.List) {
+	if !graphql.HasOperationContext(ctx) {
+		return
+	}
+	for _, err := range errs {
+		graphql.AddError(ctx, err)
+	}
+}
+
+// fetchUser loads one user of a batch after the simulated latency
+func (r *Resolver) fetchUser(ctx context.Context, id string) (*model.User, error) {
+	// Simular latência de rede/database
+	select {
+	case <-time.After(100 * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return r.repo.Get(ctx, id)
+}
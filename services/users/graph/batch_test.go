@@ -0,0 +1,183 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+	"users/graph/model"
+	"users/repository"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+// slowRepository never answers for the IDs in stuck, so their fetches run
+// into the timeout
+type slowRepository struct {
+	repository.UserRepository
+	stuck map[string]bool
+}
+
+func (r *slowRepository) Get(ctx context.Context, id string) (*model.User, error) {
+	if r.stuck[id] {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return r.UserRepository.Get(ctx, id)
+}
+
+type batchResponse struct {
+	UsersByIds []*struct{ ID string }
+}
+
+type batchError struct {
+	Path       []interface{}
+	Extensions map[string]interface{}
+}
+
+func newBatchClient(resolver *Resolver) *client.Client {
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: resolver}))
+	srv.AddTransport(transport.POST{})
+	return client.New(srv)
+}
+
+// withTimeout limits the request, and with it the batch, to d
+func withTimeout(t *testing.T, d time.Duration) client.Option {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	t.Cleanup(cancel)
+	return func(bd *client.Request) { bd.HTTP = bd.HTTP.WithContext(ctx) }
+}
+
+func TestUsersByIdsKeepsInputOrder(t *testing.T) {
+	ids := []string{"5", "999", "1", "3"}
+	users, err := NewResolver().Query().UsersByIds(context.Background(), ids, nil)
+	if err != nil {
+		t.Fatalf("UsersByIds() error = %v", err)
+	}
+	if len(users) != len(ids) {
+		t.Fatalf("got %d users, want %d", len(users), len(ids))
+	}
+	for i, id := range ids {
+		switch {
+		case id == "999" && users[i] != nil:
+			t.Errorf("users[%d] = %+v, want nil", i, users[i])
+		case id != "999" && (users[i] == nil || users[i].ID != id):
+			t.Errorf("users[%d] = %+v, want user %s", i, users[i], id)
+		}
+	}
+}
+
+func TestUsersByIdsReportsMissingIDs(t *testing.T) {
+	c := newBatchClient(NewResolver())
+
+	var resp batchResponse
+	err := c.Post(`{ usersByIds(ids: ["1", "999", "2"]) { id } }`, &resp)
+	errs := decodeErrors(t, err)
+
+	if len(resp.UsersByIds) != 3 || resp.UsersByIds[1] != nil || resp.UsersByIds[0].ID != "1" || resp.UsersByIds[2].ID != "2" {
+		t.Errorf("usersByIds = %+v, want [1 null 2]", resp.UsersByIds)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1", len(errs))
+	}
+	assertBatchError(t, errs[0], 1, "999", errNotFoundCode)
+}
+
+func TestUsersByIdsTimeout(t *testing.T) {
+	resolver := NewResolver(WithRepository(&slowRepository{
+		UserRepository: repository.NewMemoryRepository(repository.SeedUsers()),
+		stuck:          map[string]bool{"2": true, "4": true},
+	}))
+	c := newBatchClient(resolver)
+	query := `query ($partial: Boolean) { usersByIds(ids: ["1", "2", "3", "4"], partial: $partial) { id } }`
+
+	// Sem partial, um timeout derruba o campo inteiro mas lista todos os IDs
+	var strict batchResponse
+	err := c.Post(query, &strict, withTimeout(t, 300*time.Millisecond))
+	errs := decodeErrors(t, err)
+	if strict.UsersByIds != nil {
+		t.Errorf("usersByIds = %+v, want null", strict.UsersByIds)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2", len(errs))
+	}
+	assertBatchError(t, errs[0], 1, "2", errTimeoutCode)
+	assertBatchError(t, errs[1], 3, "4", errTimeoutCode)
+
+	// Com partial, os usuários buscados a tempo voltam junto com os erros
+	var partial batchResponse
+	err = c.Post(query, &partial, client.Var("partial", true), withTimeout(t, 300*time.Millisecond))
+	errs = decodeErrors(t, err)
+	if len(partial.UsersByIds) != 4 || partial.UsersByIds[0].ID != "1" || partial.UsersByIds[1] != nil ||
+		partial.UsersByIds[2].ID != "3" || partial.UsersByIds[3] != nil {
+		t.Errorf("usersByIds = %+v, want [1 null 3 null]", partial.UsersByIds)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2", len(errs))
+	}
+	assertBatchError(t, errs[0], 1, "2", errTimeoutCode)
+	assertBatchError(t, errs[1], 3, "4", errTimeoutCode)
+}
+
+func decodeErrors(t *testing.T, err error) []batchError {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	raw, ok := err.(client.RawJsonError)
+	if !ok {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var errs []batchError
+	if jsonErr := json.Unmarshal(raw.RawMessage, &errs); jsonErr != nil {
+		t.Fatalf("can not decode errors %s: %v", raw.RawMessage, jsonErr)
+	}
+	return errs
+}
+
+func assertBatchError(t *testing.T, err batchError, index int, id, code string) {
+	t.Helper()
+
+	if len(err.Path) != 2 || err.Path[0] != "usersByIds" || err.Path[1] != float64(index) {
+		t.Errorf("path = %v, want [usersByIds %d]", err.Path, index)
+	}
+	if err.Extensions["id"] != id || err.Extensions["code"] != code {
+		t.Errorf("extensions = %v, want id %s and code %s", err.Extensions, id, code)
+	}
+}
+
+func TestFetchBatchBoundsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	fetch := func(ctx context.Context, id string) (*model.User, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return &model.User{ID: id}, nil
+	}
+
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+	}
+	batch := fetchBatch(context.Background(), "bounded", ids, fetch)
+
+	if peak.Load() > batchConcurrency {
+		t.Errorf("peak concurrent fetches = %d, want at most %d", peak.Load(), batchConcurrency)
+	}
+	for i, item := range batch.items {
+		if item == nil || item.ID != ids[i] {
+			t.Fatalf("items[%d] = %v, want %s", i, item, ids[i])
+		}
+	}
+}
@@ -1,19 +1,28 @@
 package graph
 
 import (
+	"context"
+	"errors"
+	"shared/cache"
+	"shared/eviction"
 	"sync"
+	"sync/atomic"
 	"time"
 	"users/graph/model"
-	"users/metrics"
 )
 
-// UserCache simulates a cache with intentional race condition
+// UserCache simulates a cache with intentional race condition. The safe
+// methods go through a generic cache.Cache; the unsafe map and sync.Map
+// variants are kept for comparison.
 type UserCache struct {
 	// PROBLEM: Shared map without protection - RACE CONDITION!
-	users map[string]*model.User
+	users map[string]*cacheEntry
 
-	// SOLUTION 1: Mutex for protection
-	mu sync.RWMutex
+	// SOLUTION 1: cache.Cache protected by a mutex, or cache.Sharded with
+	// one mutex per shard
+	safe cache.Store[string, *model.User]
+	// loading loads the misses of GetUserOrLoad, set by SetLoader
+	loading *cache.Loading[string, *model.User]
 
 	// SOLUTION 2: sync.Map for native thread-safety
 	safeMap sync.Map
@@ -21,15 +30,68 @@ type UserCache struct {
 	// Configurations
 	maxSize int
 	ttl     time.Duration
+
+	// now is the clock entries expire by, replaced in tests
+	now         func() time.Time
+	expirations atomic.Int64
+}
+
+// cacheEntry is a cached user and the moment it expires, zero for never
+type cacheEntry struct {
+	user      *model.User
+	expiresAt time.Time
 }
 
-// NewUserCache creates a new user cache
+func (e *cacheEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// NewUserCache creates a new user cache that evicts the least recently
+// used entry when full. Entries expire ttl after they are written; a ttl
+// of 0 keeps them until they are evicted.
 func NewUserCache(maxSize int, ttl time.Duration) *UserCache {
+	return NewUserCacheWithPolicy(maxSize, ttl, eviction.LRU)
+}
+
+// NewUserCacheWithPolicy creates a new user cache that evicts with the
+// policy of the given kind when full
+func NewUserCacheWithPolicy(maxSize int, ttl time.Duration, kind eviction.Kind) *UserCache {
+	return NewUserCacheSharded(maxSize, ttl, kind, 1)
+}
+
+// NewUserCacheSharded creates a new user cache whose safe methods spread
+// the users over shards locks, 1 for a single lock
+func NewUserCacheSharded(maxSize int, ttl time.Duration, kind eviction.Kind, shards int) *UserCache {
+	return newUserCache(maxSize, ttl, time.Now, shards, cache.WithPolicy(kind))
+}
+
+func newUserCache(maxSize int, ttl time.Duration, now func() time.Time, shards int, opts ...cache.Option) *UserCache {
+	opts = append([]cache.Option{cache.WithMaxSize(maxSize), cache.WithTTL(ttl), cache.WithClock(now)}, opts...)
 	return &UserCache{
-		users:   make(map[string]*model.User),
+		users:   make(map[string]*cacheEntry),
+		safe:    cache.NewStore[string, *model.User]("users", "users", shards, opts...),
 		maxSize: maxSize,
 		ttl:     ttl,
+		now:     now,
+	}
+}
+
+// newEntry wraps user with an expiry ttl from now
+func (c *UserCache) newEntry(user *model.User, ttl time.Duration) *cacheEntry {
+	entry := &cacheEntry{user: user}
+	if ttl > 0 {
+		entry.expiresAt = c.now().Add(ttl)
+	}
+	return entry
+}
+
+// recordExpirations counts sync.Map entries dropped because they expired
+func (c *UserCache) recordExpirations(n int) {
+	if n == 0 {
+		return
 	}
+	c.expirations.Add(int64(n))
+	cache.RecordExpirations("users", "users_syncmap", n)
 }
 
 // ===== IMPLEMENTATION WITH RACE CONDITION (PROBLEM) =====
@@ -37,95 +99,116 @@ func NewUserCache(maxSize int, ttl time.Duration) *UserCache {
 // GetUserUnsafe - RACE CONDITION! Do not use in production
 func (c *UserCache) GetUserUnsafe(id string) (*model.User, bool) {
 	// PROBLEM: Concurrent access without protection
-	user, exists := c.users[id]
-	return user, exists
+	entry, exists := c.users[id]
+	if !exists || entry.expired(c.now()) {
+		return nil, false
+	}
+	return entry.user, true
 }
 
 // SetUserUnsafe - RACE CONDITION! Do not use in production
 func (c *UserCache) SetUserUnsafe(user *model.User) {
 	// PROBLEM: Concurrent write without protection
-	c.users[user.ID] = user
+	c.users[user.ID] = c.newEntry(user, c.ttl)
 }
 
 // GetUsersUnsafe - RACE CONDITION! Do not use in production
 func (c *UserCache) GetUsersUnsafe() []*model.User {
 	// PROBLEM: Concurrent read without protection
 	users := make([]*model.User, 0, len(c.users))
-	for _, user := range c.users {
-		users = append(users, user)
+	for _, entry := range c.users {
+		users = append(users, entry.user)
 	}
 	return users
 }
 
 // ===== SOLUTION 1: MUTEX =====
 
-// GetUserSafe - Thread-safe with mutex
+// GetUserSafe - Thread-safe with mutex. An expired entry is a miss and is
+// dropped on the spot.
 func (c *UserCache) GetUserSafe(id string) (*model.User, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	user, exists := c.users[id]
-	if exists {
-		metrics.RecordCacheHit("users")
-	} else {
-		metrics.RecordCacheMiss("users")
-	}
-	return user, exists
+	return c.safe.Get(id)
 }
 
-// SetUserSafe - Thread-safe with mutex
+// SetUserSafe - Thread-safe with mutex, the entry expires after the cache TTL
 func (c *UserCache) SetUserSafe(user *model.User) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	// Check size limit
-	if len(c.users) >= c.maxSize {
-		// Remove oldest item (simple implementation)
-		for key := range c.users {
-			delete(c.users, key)
-			break
-		}
-	}
+	c.writes().Set(user.ID, user)
+}
 
-	c.users[user.ID] = user
+// SetUserWithTTL - Thread-safe with mutex, the entry expires after ttl
+// instead of the cache TTL, 0 for never
+func (c *UserCache) SetUserWithTTL(user *model.User, ttl time.Duration) {
+	c.writes().SetWithTTL(user.ID, user, ttl)
 }
 
-// GetUsersSafe - Thread-safe with mutex
-func (c *UserCache) GetUsersSafe() []*model.User {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+// DeleteUserSafe - Thread-safe with mutex, drops a user from the cache
+func (c *UserCache) DeleteUserSafe(id string) {
+	c.writes().Delete(id)
+	c.safeMap.Delete(id)
+}
 
-	users := make([]*model.User, 0, len(c.users))
-	for _, user := range c.users {
-		users = append(users, user)
+// writes returns the store the safe writes go through: with a loader, the
+// loading cache, so a write keeps a load in flight for the same user from
+// putting the old user back
+func (c *UserCache) writes() cache.Store[string, *model.User] {
+	if c.loading != nil {
+		return c.loading
 	}
-	return users
+	return c.safe
+}
+
+// SetLoader makes GetUserOrLoad load missing users with load, each load
+// limited to timeout
+func (c *UserCache) SetLoader(load cache.Loader[string, *model.User], timeout time.Duration) {
+	c.loading = cache.NewLoading(c.safe, "users", load, timeout)
+}
+
+// GetUserOrLoad - Thread-safe, loads the user on a miss. Concurrent misses
+// for one ID share a single load and its error.
+func (c *UserCache) GetUserOrLoad(ctx context.Context, id string) (*model.User, error) {
+	if c.loading == nil {
+		return nil, errors.New("user cache has no loader")
+	}
+	return c.loading.GetOrLoad(ctx, id)
+}
+
+// GetUsersSafe - Thread-safe with mutex, leaving out expired entries
+func (c *UserCache) GetUsersSafe() []*model.User {
+	return c.safe.Values()
 }
 
 // ===== SOLUTION 2: SYNC.MAP =====
 
 // GetUserSyncMap - Thread-safe with sync.Map
 func (c *UserCache) GetUserSyncMap(id string) (*model.User, bool) {
-	if value, ok := c.safeMap.Load(id); ok {
-		if user, ok := value.(*model.User); ok {
-			return user, true
+	value, ok := c.safeMap.Load(id)
+	if !ok {
+		return nil, false
+	}
+	entry := value.(*cacheEntry)
+	if entry.expired(c.now()) {
+		// Só remove se ninguém regravou a chave depois da leitura
+		if c.safeMap.CompareAndDelete(id, entry) {
+			c.recordExpirations(1)
 		}
+		return nil, false
 	}
-	return nil, false
+	return entry.user, true
 }
 
 // SetUserSyncMap - Thread-safe with sync.Map
 func (c *UserCache) SetUserSyncMap(user *model.User) {
-	c.safeMap.Store(user.ID, user)
+	c.safeMap.Store(user.ID, c.newEntry(user, c.ttl))
 }
 
 // GetUsersSyncMap - Thread-safe with sync.Map
 func (c *UserCache) GetUsersSyncMap() []*model.User {
 	users := make([]*model.User, 0)
 
+	now := c.now()
 	c.safeMap.Range(func(key, value interface{}) bool {
-		if user, ok := value.(*model.User); ok {
-			users = append(users, user)
+		if entry := value.(*cacheEntry); !entry.expired(now) {
+			users = append(users, entry.user)
 		}
 		return true
 	})
@@ -133,34 +216,72 @@ func (c *UserCache) GetUsersSyncMap() []*model.User {
 	return users
 }
 
+// ===== EXPIRATION =====
+
+// DeleteExpired drops every expired entry and returns how many it dropped
+func (c *UserCache) DeleteExpired() int {
+	removed := c.safe.DeleteExpired()
+
+	now := c.now()
+	c.safeMap.Range(func(key, value interface{}) bool {
+		if value.(*cacheEntry).expired(now) && c.safeMap.CompareAndDelete(key, value) {
+			c.recordExpirations(1)
+			removed++
+		}
+		return true
+	})
+	return removed
+}
+
+// StartJanitor drops expired entries every interval until ctx is done, so
+// entries nobody reads again do not hold memory. The returned channel is
+// closed once the janitor stops.
+func (c *UserCache) StartJanitor(ctx context.Context, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.DeleteExpired()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return done
+}
+
 // ===== UTILITY METHODS =====
 
 // Clear clears the cache
 func (c *UserCache) Clear() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.users = make(map[string]*model.User)
-	c.safeMap = sync.Map{}
+	// O mapa sem proteção continua sujeito a race condition
+	clear(c.users)
+	c.writes().Clear()
+	c.safeMap.Clear()
 }
 
-// Size returns the size of the cache
+// Size returns the size of the cache. Expired entries count until a read
+// or the janitor drops them.
 func (c *UserCache) Size() int {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	return len(c.users)
+	return c.safe.Len()
 }
 
 // Stats returns statistics of the cache
 func (c *UserCache) Stats() map[string]interface{} {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	stats := c.safe.Stats()
 
 	return map[string]interface{}{
-		"size":     len(c.users),
-		"max_size": c.maxSize,
-		"ttl":      c.ttl.String(),
+		"size":        stats.Size,
+		"max_size":    stats.MaxSize,
+		"ttl":         stats.TTL.String(),
+		"expirations": stats.Expirations + int(c.expirations.Load()),
+		"policy":      string(stats.Policy),
+		"evictions":   stats.Evictions,
 	}
 }
 
@@ -1,10 +1,20 @@
 package graph
 
 import (
+	"context"
+	"fmt"
+	"runtime"
+	"shared/cache"
+	"shared/eviction"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 	"users/graph/model"
+	"users/repository"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestUserCache(t *testing.T) {
@@ -143,6 +153,336 @@ func TestUserCacheMaxSize(t *testing.T) {
 	t.Log("UserCache max size test passed")
 }
 
+// fakeClock is a clock the tests move by hand
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newClockedCache(maxSize int, ttl time.Duration) (*UserCache, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	return newUserCache(maxSize, ttl, clock.Now, 1), clock
+}
+
+func TestUserCacheExpiresEntriesOnRead(t *testing.T) {
+	userCache, clock := newClockedCache(10, time.Minute)
+	expired := testutil.ToFloat64(cache.Expirations.WithLabelValues("users", "users"))
+
+	userCache.SetUserSafe(&model.User{ID: "1", Name: "Alice"})
+	clock.Advance(59 * time.Second)
+	if _, exists := userCache.GetUserSafe("1"); !exists {
+		t.Fatal("entry expired before its TTL")
+	}
+
+	clock.Advance(time.Second)
+	if _, exists := userCache.GetUserSafe("1"); exists {
+		t.Error("entry still cached after its TTL")
+	}
+	// A leitura que encontra a entrada vencida já a remove
+	if userCache.Size() != 0 {
+		t.Errorf("Size() = %d, want the expired entry dropped", userCache.Size())
+	}
+	if stats := userCache.Stats(); stats["expirations"] != 1 {
+		t.Errorf("expirations = %v, want 1", stats["expirations"])
+	}
+	if got := testutil.ToFloat64(cache.Expirations.WithLabelValues("users", "users")) - expired; got != 1 {
+		t.Errorf("cache_expirations_total grew by %v, want 1", got)
+	}
+}
+
+func TestUserCachePerEntryTTL(t *testing.T) {
+	cache, clock := newClockedCache(10, time.Minute)
+
+	cache.SetUserWithTTL(&model.User{ID: "short"}, 10*time.Second)
+	cache.SetUserWithTTL(&model.User{ID: "forever"}, 0)
+	cache.SetUserSafe(&model.User{ID: "default"})
+
+	clock.Advance(30 * time.Second)
+	if _, exists := cache.GetUserSafe("short"); exists {
+		t.Error("short entry still cached after its own TTL")
+	}
+	if _, exists := cache.GetUserSafe("default"); !exists {
+		t.Error("default entry expired before the cache TTL")
+	}
+
+	clock.Advance(time.Hour)
+	if _, exists := cache.GetUserSafe("default"); exists {
+		t.Error("default entry still cached after the cache TTL")
+	}
+	if _, exists := cache.GetUserSafe("forever"); !exists {
+		t.Error("entry written without TTL expired")
+	}
+	if users := cache.GetUsersSafe(); len(users) != 1 || users[0].ID != "forever" {
+		t.Errorf("GetUsersSafe() = %v, want only the entry without TTL", users)
+	}
+}
+
+func TestUserCacheSyncMapExpires(t *testing.T) {
+	cache, clock := newClockedCache(10, time.Minute)
+
+	cache.SetUserSyncMap(&model.User{ID: "1"})
+	clock.Advance(time.Minute)
+	if _, exists := cache.GetUserSyncMap("1"); exists {
+		t.Error("sync.Map entry still cached after its TTL")
+	}
+	if users := cache.GetUsersSyncMap(); len(users) != 0 {
+		t.Errorf("GetUsersSyncMap() = %v, want none", users)
+	}
+}
+
+func TestUserCacheFullDropsExpiredFirst(t *testing.T) {
+	cache, clock := newClockedCache(2, time.Minute)
+
+	cache.SetUserWithTTL(&model.User{ID: "old"}, time.Second)
+	cache.SetUserSafe(&model.User{ID: "valid"})
+	clock.Advance(2 * time.Second)
+	cache.SetUserSafe(&model.User{ID: "new"})
+
+	if _, exists := cache.GetUserSafe("valid"); !exists {
+		t.Error("a valid entry was evicted while an expired one was cached")
+	}
+	if _, exists := cache.GetUserSafe("new"); !exists {
+		t.Error("new entry not cached")
+	}
+}
+
+func TestUserCacheJanitor(t *testing.T) {
+	cache, clock := newClockedCache(10, time.Minute)
+	for _, id := range []string{"1", "2", "3"} {
+		cache.SetUserSafe(&model.User{ID: id})
+	}
+	cache.SetUserSyncMap(&model.User{ID: "4"})
+	cache.SetUserWithTTL(&model.User{ID: "kept"}, time.Hour)
+	clock.Advance(2 * time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := cache.StartJanitor(ctx, 5*time.Millisecond)
+
+	// O janitor remove as entradas vencidas sem nenhuma leitura
+	deadline := time.Now().Add(time.Second)
+	for cache.Size() != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if cache.Size() != 1 {
+		t.Errorf("Size() = %d, want only the entry still valid", cache.Size())
+	}
+	if stats := cache.Stats(); stats["expirations"] != 4 {
+		t.Errorf("expirations = %v, want 4", stats["expirations"])
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("janitor did not stop when its context was canceled")
+	}
+}
+
+func TestUserCacheLRUEviction(t *testing.T) {
+	userCache := NewUserCacheWithPolicy(2, time.Minute, eviction.LRU)
+	evicted := testutil.ToFloat64(cache.Evictions.WithLabelValues("users", "users", "lru", eviction.ReasonCapacity))
+
+	userCache.SetUserSafe(&model.User{ID: "1"})
+	userCache.SetUserSafe(&model.User{ID: "2"})
+	userCache.GetUserSafe("1")
+	userCache.SetUserSafe(&model.User{ID: "3"})
+
+	// 2 é a menos usada recentemente, mesmo tendo sido gravada depois de 1
+	if _, exists := userCache.GetUserSafe("2"); exists {
+		t.Error("the least recently used entry was kept")
+	}
+	for _, id := range []string{"1", "3"} {
+		if _, exists := userCache.GetUserSafe(id); !exists {
+			t.Errorf("entry %s was evicted", id)
+		}
+	}
+	if got := testutil.ToFloat64(cache.Evictions.WithLabelValues("users", "users", "lru", eviction.ReasonCapacity)) - evicted; got != 1 {
+		t.Errorf("capacity evictions grew by %v, want 1", got)
+	}
+}
+
+func TestUserCacheLFUEviction(t *testing.T) {
+	cache := NewUserCacheWithPolicy(2, time.Minute, eviction.LFU)
+
+	cache.SetUserSafe(&model.User{ID: "hot"})
+	cache.SetUserSafe(&model.User{ID: "cold"})
+	for i := 0; i < 3; i++ {
+		cache.GetUserSafe("hot")
+	}
+	cache.GetUserSafe("cold")
+	cache.SetUserSafe(&model.User{ID: "new"})
+
+	if _, exists := cache.GetUserSafe("cold"); exists {
+		t.Error("the least frequently used entry was kept")
+	}
+	if _, exists := cache.GetUserSafe("hot"); !exists {
+		t.Error("the most frequently used entry was evicted")
+	}
+}
+
+func TestUserCacheTinyLFURejectsOneOffKeys(t *testing.T) {
+	cache := NewUserCacheWithPolicy(2, time.Minute, eviction.TinyLFU)
+
+	cache.SetUserSafe(&model.User{ID: "a"})
+	cache.SetUserSafe(&model.User{ID: "b"})
+	for i := 0; i < 3; i++ {
+		cache.GetUserSafe("a")
+		cache.GetUserSafe("b")
+	}
+
+	// Uma varredura de chaves pedidas uma vez só não tira as populares
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("scan-%d", i)
+		cache.GetUserSafe(id)
+		cache.SetUserSafe(&model.User{ID: id})
+	}
+	for _, id := range []string{"a", "b"} {
+		if _, exists := cache.GetUserSafe(id); !exists {
+			t.Errorf("popular entry %s was evicted by a scan", id)
+		}
+	}
+
+	// Uma chave que passa a ser pedida com frequência entra no lugar da vítima
+	for i := 0; i < 6; i++ {
+		cache.GetUserSafe("rising")
+	}
+	cache.SetUserSafe(&model.User{ID: "rising"})
+	if _, exists := cache.GetUserSafe("rising"); !exists {
+		t.Error("a frequently requested entry was rejected")
+	}
+
+	stats := cache.Stats()
+	evictions := stats["evictions"].(map[string]int)
+	if stats["policy"] != "tinylfu" || evictions[eviction.ReasonRejected] != 10 || evictions[eviction.ReasonCapacity] != 1 {
+		t.Errorf("Stats() = %v, want 10 rejected and 1 capacity eviction", stats)
+	}
+	if cache.Size() != 2 {
+		t.Errorf("Size() = %d, want 2", cache.Size())
+	}
+}
+
+func TestUserCacheCountsExpiredEvictions(t *testing.T) {
+	cache, clock := newClockedCache(10, time.Minute)
+	cache.SetUserSafe(&model.User{ID: "1"})
+	cache.SetUserSafe(&model.User{ID: "2"})
+	clock.Advance(time.Minute)
+
+	cache.GetUserSafe("1")
+	cache.DeleteExpired()
+	if evictions := cache.Stats()["evictions"].(map[string]int); evictions[eviction.ReasonExpired] != 2 {
+		t.Errorf("evictions = %v, want 2 expired", evictions)
+	}
+}
+
+// gatedRepository reads every Get right away but holds its result until
+// release is closed, as a slow repository would
+type gatedRepository struct {
+	*countingRepository
+	release chan struct{}
+	waiting atomic.Int32
+}
+
+func (r *gatedRepository) Get(ctx context.Context, id string) (*model.User, error) {
+	user, err := r.countingRepository.Get(ctx, id)
+	r.waiting.Add(1)
+	<-r.release
+	return user, err
+}
+
+func TestUserFromCacheMergesConcurrentMisses(t *testing.T) {
+	repo := &gatedRepository{
+		countingRepository: &countingRepository{UserRepository: repository.NewMemoryRepository(repository.SeedUsers())},
+		release:            make(chan struct{}),
+	}
+	resolver := NewResolver(WithRepository(repo))
+	merged := testutil.ToFloat64(cache.LoadsMerged.WithLabelValues("users", "users"))
+
+	const callers = 8
+	users := make([]*model.User, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			users[i], _ = resolver.Query().UserFromCache(context.Background(), "1")
+		}(i)
+	}
+
+	// Libera o repositório só depois que todas as faltas se juntaram
+	deadline := time.Now().Add(time.Second)
+	for testutil.ToFloat64(cache.LoadsMerged.WithLabelValues("users", "users"))-merged < callers-1 {
+		if time.Now().After(deadline) {
+			t.Fatal("concurrent misses did not merge")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(repo.release)
+	wg.Wait()
+
+	for i, user := range users {
+		if user == nil || user.ID != "1" {
+			t.Errorf("users[%d] = %v, want user 1", i, user)
+		}
+	}
+	if repo.gets != 1 {
+		t.Errorf("repository gets = %d, want 1", repo.gets)
+	}
+	if _, exists := resolver.Cache().GetUserSafe("1"); !exists {
+		t.Error("loaded user was not cached")
+	}
+}
+
+func TestDeleteUserDuringLoad(t *testing.T) {
+	repo := &gatedRepository{
+		countingRepository: &countingRepository{UserRepository: repository.NewMemoryRepository(repository.SeedUsers())},
+		release:            make(chan struct{}),
+	}
+	resolver := NewResolver(WithRepository(repo))
+
+	loaded := make(chan *model.User, 1)
+	go func() {
+		user, _ := resolver.Query().UserFromCache(context.Background(), "1")
+		loaded <- user
+	}()
+	// A carga já leu o usuário quando a remoção acontece
+	deadline := time.Now().Add(time.Second)
+	for repo.waiting.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the load did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := resolver.Mutation().DeleteUser(context.Background(), "1"); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	close(repo.release)
+	<-loaded
+
+	if user, exists := resolver.Cache().GetUserSafe("1"); exists {
+		t.Errorf("deleted user %v was cached by the load in flight", user)
+	}
+}
+
+func TestUserFromCacheUnknownUser(t *testing.T) {
+	resolver := NewResolver()
+	user, err := resolver.Query().UserFromCache(context.Background(), "999")
+	if user != nil || err != nil {
+		t.Errorf("UserFromCache(999) = %v, %v, want nil, nil", user, err)
+	}
+}
+
 func TestRaceConditionSimulation(t *testing.T) {
 	cache := NewUserCache(100, 5*time.Minute)
 
@@ -223,3 +563,98 @@ func BenchmarkCacheSyncMapAccess(b *testing.B) {
 		cache.GetUsersSyncMap()
 	}
 }
+
+// benchmarkKeys is the key space of BenchmarkCacheContention
+const benchmarkKeys = 1024
+
+// benchmarkCapacity is the max size of the benchmarked caches. The keys do
+// not hash evenly over the shards, so it leaves each of the 16 shards room
+// for four times its share of keys and no variant evicts.
+const benchmarkCapacity = 4 * benchmarkKeys
+
+// cacheVariant is one way of reading and writing users under contention
+type cacheVariant struct {
+	name string
+	get  func(c *UserCache, id string)
+	set  func(c *UserCache, user *model.User)
+	new  func() *UserCache
+}
+
+var cacheVariants = []cacheVariant{
+	{
+		name: "mutex",
+		get:  func(c *UserCache, id string) { c.GetUserSafe(id) },
+		set:  func(c *UserCache, user *model.User) { c.SetUserSafe(user) },
+		new:  func() *UserCache { return NewUserCache(benchmarkCapacity, 5*time.Minute) },
+	},
+	{
+		name: "sharded",
+		get:  func(c *UserCache, id string) { c.GetUserSafe(id) },
+		set:  func(c *UserCache, user *model.User) { c.SetUserSafe(user) },
+		new: func() *UserCache {
+			return NewUserCacheSharded(benchmarkCapacity, 5*time.Minute, eviction.LRU, 16)
+		},
+	},
+	{
+		name: "syncmap",
+		get:  func(c *UserCache, id string) { c.GetUserSyncMap(id) },
+		set:  func(c *UserCache, user *model.User) { c.SetUserSyncMap(user) },
+		new:  func() *UserCache { return NewUserCache(benchmarkCapacity, 5*time.Minute) },
+	},
+}
+
+// BenchmarkCacheContention compares the mutex, sharded and sync.Map caches
+// with parallel readers and writers, for several read ratios and
+// GOMAXPROCS values:
+//
+//	go test -bench=BenchmarkCacheContention -benchmem ./graph/
+func BenchmarkCacheContention(b *testing.B) {
+	users := make([]*model.User, benchmarkKeys)
+	for i := range users {
+		id := strconv.Itoa(i)
+		users[i] = &model.User{ID: id, Name: "User" + id, Email: "user" + id + "@example.com"}
+	}
+
+	for _, procs := range []int{1, 2, 4, 8} {
+		for _, reads := range []int{50, 90, 99} {
+			for _, variant := range cacheVariants {
+				name := fmt.Sprintf("procs=%d/reads=%d%%/%s", procs, reads, variant.name)
+				b.Run(name, func(b *testing.B) {
+					defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+
+					cache := variant.new()
+					for _, user := range users {
+						variant.set(cache, user)
+					}
+
+					var seed atomic.Uint64
+					b.ReportAllocs()
+					b.ResetTimer()
+					b.RunParallel(func(pb *testing.PB) {
+						// xorshift por goroutine, sem disputar um gerador global
+						x := seed.Add(0x9E3779B97F4A7C15)
+						for pb.Next() {
+							x ^= x << 13
+							x ^= x >> 7
+							x ^= x << 17
+							user := users[x%benchmarkKeys]
+							if int(x>>32%100) < reads {
+								variant.get(cache, user.ID)
+							} else {
+								variant.set(cache, user)
+							}
+						}
+					})
+
+					b.StopTimer()
+					// Um despejo mediria o custo da política, não o da disputa
+					for reason, n := range cache.Stats()["evictions"].(map[string]int) {
+						if n > 0 {
+							b.Fatalf("%s evicted %d entries (%s)", variant.name, n, reason)
+						}
+					}
+				})
+			}
+		}
+	}
+}
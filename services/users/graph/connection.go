@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"shared/pagination"
+	"users/graph/model"
+)
+
+const (
+	errInvalidCursorCode   = "INVALID_CURSOR"
+	errInvalidPageSizeCode = "INVALID_PAGE_SIZE"
+)
+
+// userConnection pages users, which must be sorted by ID
+func userConnection(ctx context.Context, users []*model.User, args pagination.Args) (*model.UserConnection, error) {
+	page, err := pagination.Paginate(users, func(user *model.User) string { return user.ID }, args)
+	if err != nil {
+		return nil, paginationError(ctx, err)
+	}
+
+	edges := make([]*model.UserEdge, len(page.Items))
+	for i, user := range page.Items {
+		edges[i] = &model.UserEdge{Cursor: page.Cursors[i], Node: user}
+	}
+	return &model.UserConnection{
+		Edges:      edges,
+		PageInfo:   pageInfo(page),
+		TotalCount: page.TotalCount,
+	}, nil
+}
+
+func pageInfo[T any](page *pagination.Page[T]) *model.PageInfo {
+	return &model.PageInfo{
+		HasNextPage:     page.HasNextPage,
+		HasPreviousPage: page.HasPreviousPage,
+		StartCursor:     page.StartCursor(),
+		EndCursor:       page.EndCursor(),
+	}
+}
+
+// paginationError gives invalid connection arguments a code in extensions
+func paginationError(ctx context.Context, err error) error {
+	code := errInvalidPageSizeCode
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		code = errInvalidCursorCode
+	}
+	return codedError(ctx, err, err.Error(), map[string]interface{}{"code": code})
+}
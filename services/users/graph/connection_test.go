@@ -0,0 +1,57 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"users/graph/model"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestUsersConnectionPages(t *testing.T) {
+	resolver := NewResolver()
+	ctx := context.Background()
+
+	first, err := resolver.Query().UsersConnection(ctx, intPtr(3), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("UsersConnection() error = %v", err)
+	}
+	if len(first.Edges) != 3 || first.Edges[0].Node.ID != "1" || !first.PageInfo.HasNextPage || first.PageInfo.HasPreviousPage {
+		t.Fatalf("first page = %+v, info %+v", first.Edges, first.PageInfo)
+	}
+	if first.TotalCount != 8 {
+		t.Errorf("TotalCount = %d, want 8", first.TotalCount)
+	}
+
+	// Um usuário criado entre as páginas não desloca o cursor
+	if _, err := resolver.Mutation().CreateUser(ctx, model.CreateUserInput{Name: "Ivy", Email: "ivy@example.com"}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	second, err := resolver.Query().UsersConnection(ctx, intPtr(3), first.PageInfo.EndCursor, nil, nil)
+	if err != nil {
+		t.Fatalf("UsersConnection() error = %v", err)
+	}
+	if len(second.Edges) != 3 || second.Edges[0].Node.ID != "4" || !second.PageInfo.HasPreviousPage {
+		t.Errorf("second page = %+v, info %+v", second.Edges, second.PageInfo)
+	}
+	if second.TotalCount != 9 {
+		t.Errorf("TotalCount = %d, want 9", second.TotalCount)
+	}
+}
+
+func TestUsersConnectionErrors(t *testing.T) {
+	resolver := NewResolver()
+	ctx := context.Background()
+	bad := "not-a-cursor"
+
+	_, err := resolver.Query().UsersConnection(ctx, nil, &bad, nil, nil)
+	if code, _ := errorCode(t, err); code != errInvalidCursorCode {
+		t.Errorf("code = %q, want %q", code, errInvalidCursorCode)
+	}
+
+	_, err = resolver.Query().UsersConnection(ctx, intPtr(101), nil, nil, nil)
+	if code, _ := errorCode(t, err); code != errInvalidPageSizeCode {
+		t.Errorf("code = %q, want %q", code, errInvalidPageSizeCode)
+	}
+}
@@ -0,0 +1,18 @@
+package graph
+
+import (
+	"shared/extensions"
+	"shared/pagination"
+)
+
+// FieldCosts prices the users fields that do more work than a plain
+// field read. Batch lookups scale with the number of IDs requested and
+// connections with the page size, the default one when neither first
+// nor last is given.
+var FieldCosts = map[string]extensions.FieldCost{
+	"Query.users":           {Cost: 10},
+	"Query.usersFromCache":  {Cost: 10},
+	"Query.usersByIds":      {Cost: 1, ListArgument: "ids"},
+	"Query.usersConnection": {Cost: 1, ListArgument: "first|last", DefaultSize: pagination.DefaultPageSize},
+	"Query._entities":       {Cost: 1, ListArgument: "representations"},
+}
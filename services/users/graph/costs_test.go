@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"shared/extensions"
+	"strconv"
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+const queryTooComplexCode = "QUERY_TOO_COMPLEX"
+
+// newLimitedClient serves the schema behind the operation policies priced
+// with FieldCosts
+func newLimitedClient(t *testing.T, cfg extensions.Config) *client.Client {
+	t.Helper()
+
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: NewResolver()}))
+	srv.AddTransport(transport.POST{})
+	if err := extensions.Install(srv, "users", FieldCosts, cfg); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	return client.New(srv)
+}
+
+// rejectedCost returns the cost reported when the operation was rejected
+// as too complex, or 0 when it was accepted
+func rejectedCost(t *testing.T, err error) float64 {
+	t.Helper()
+
+	errs := decodeErrors(t, err)
+	if len(errs) == 0 {
+		return 0
+	}
+	if code := errs[0].Extensions["code"]; code != queryTooComplexCode {
+		t.Fatalf("error code = %v, want %s", code, queryTooComplexCode)
+	}
+	cost, _ := errs[0].Extensions["cost"].(float64)
+	return cost
+}
+
+func TestFieldCostsScaleUsersByIds(t *testing.T) {
+	c := newLimitedClient(t, extensions.Config{PersistedQueryCacheSize: 10, MaxQueryCost: 100})
+	query := `query ($ids: [ID!]!) { usersByIds(ids: $ids) { id name email } }`
+
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i%3 + 1)
+	}
+
+	// (1 + 3 seleções) * 10 IDs
+	var resp map[string]interface{}
+	if cost := rejectedCost(t, c.Post(query, &resp, client.Var("ids", ids[:10]))); cost != 0 {
+		t.Fatalf("10 ids should be accepted, rejected with cost %v", cost)
+	}
+	if cost := rejectedCost(t, c.Post(query, &resp, client.Var("ids", ids))); cost != 4000 {
+		t.Errorf("reported cost = %v, want 4000", cost)
+	}
+}
+
+func TestFieldCostsScaleEntities(t *testing.T) {
+	c := newLimitedClient(t, extensions.Config{PersistedQueryCacheSize: 10, MaxQueryCost: 50})
+
+	representations := make([]interface{}, 20)
+	for i := range representations {
+		representations[i] = map[string]interface{}{"__typename": "User", "id": strconv.Itoa(i)}
+	}
+	var resp map[string]interface{}
+	err := c.Post(`query ($r: [_Any!]!) { _entities(representations: $r) { ... on User { id name } } }`,
+		&resp, client.Var("r", representations))
+	if cost := rejectedCost(t, err); cost != 60 {
+		t.Errorf("reported cost = %v, want 60", cost)
+	}
+}
+
+func TestFieldCostsScaleUsersConnection(t *testing.T) {
+	c := newLimitedClient(t, extensions.Config{PersistedQueryCacheSize: 10, MaxQueryCost: 100})
+
+	// (1 + edges 1 + node 1 + id 1) * tamanho da página
+	var resp map[string]interface{}
+	if cost := rejectedCost(t, c.Post(`{ usersConnection(first: 20) { edges { node { id } } } }`, &resp)); cost != 0 {
+		t.Fatalf("a page of 20 should be accepted, rejected with cost %v", cost)
+	}
+	if cost := rejectedCost(t, c.Post(`{ usersConnection(first: 5, last: 50) { edges { node { id } } } }`, &resp)); cost != 200 {
+		t.Errorf("reported cost = %v, want 200", cost)
+	}
+
+	// Sem first nem last vale a página padrão de 20
+	c = newLimitedClient(t, extensions.Config{PersistedQueryCacheSize: 10, MaxQueryCost: 60})
+	if cost := rejectedCost(t, c.Post(`{ usersConnection { edges { node { id } } } }`, &resp)); cost != 80 {
+		t.Errorf("reported cost without first or last = %v, want 80", cost)
+	}
+}
+
+func TestShippedManifestAllowsProductOwner(t *testing.T) {
+	// O serviço de produtos confere o dono com esta query
+	c := newLimitedClient(t, extensions.Config{SafelistManifest: "../persisted-queries.json", Strict: true})
+
+	var resp struct {
+		User *struct{ ID string }
+	}
+	err := c.Post(`query ProductOwner($id: ID!) { user(id: $id) { id } }`, &resp, client.Var("id", "1"))
+	if err != nil {
+		t.Fatalf("errors = %v", err)
+	}
+	if resp.User == nil || resp.User.ID != "1" {
+		t.Errorf("user = %+v, want user 1", resp.User)
+	}
+}
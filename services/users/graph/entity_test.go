@@ -0,0 +1,36 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"users/graph/model"
+)
+
+func TestFindManyUserByIDs(t *testing.T) {
+	resolver := NewResolver()
+
+	reps := []*model.UserByIDsInput{
+		{ID: "3"},
+		{ID: "999"},
+		{ID: "1"},
+	}
+
+	result, err := resolver.Entity().FindManyUserByIDs(context.Background(), reps)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Results must be aligned with the representations
+	if len(result) != len(reps) {
+		t.Fatalf("Expected %d entities, got %d", len(reps), len(result))
+	}
+	if result[0] == nil || result[0].Name != "Charlie" {
+		t.Errorf("Expected Charlie at index 0, got %v", result[0])
+	}
+	if result[1] != nil {
+		t.Errorf("Expected nil for unknown ID, got %v", result[1])
+	}
+	if result[2] == nil || result[2].Name != "Alice" {
+		t.Errorf("Expected Alice at index 2, got %v", result[2])
+	}
+}
@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"users/repository"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const errNotFoundCode = "NOT_FOUND"
+
+// codedError builds a GraphQL error with a code in extensions
+func codedError(ctx context.Context, err error, message string, extensions map[string]interface{}) error {
+	return &gqlerror.Error{
+		Err:        err,
+		Message:    message,
+		Path:       graphql.GetPath(ctx),
+		Extensions: extensions,
+	}
+}
+
+// repositoryError turns repository errors into GraphQL errors with a code
+// in extensions. Validation errors also carry the offending input field.
+func repositoryError(ctx context.Context, id string, err error) error {
+	var validation *repository.ValidationError
+	switch {
+	case errors.As(err, &validation):
+		return codedError(ctx, err, validation.Message, map[string]interface{}{
+			"code":  validation.Code,
+			"field": validation.Field,
+		})
+	case errors.Is(err, repository.ErrNotFound):
+		return codedError(ctx, err, fmt.Sprintf("user %s not found", id), map[string]interface{}{
+			"code": errNotFoundCode,
+			"id":   id,
+		})
+	}
+	return err
+}
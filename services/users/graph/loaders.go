@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"shared/dataloader"
+	"users/graph/model"
+	"users/repository"
+)
+
+// Loaders are the DataLoaders of one request
+type Loaders struct {
+	Users *dataloader.Loader[string, *model.User]
+}
+
+type loadersKey struct{}
+
+// AttachLoaders returns ctx with fresh loaders reading from the resolver
+// repository. dataloader.Middleware calls it for every request.
+func (r *Resolver) AttachLoaders(ctx context.Context) context.Context {
+	loaders := &Loaders{
+		Users: dataloader.New(ctx, "users", "users", r.loaderConfig, r.repo.GetMany),
+	}
+	return context.WithValue(ctx, loadersKey{}, loaders)
+}
+
+// loadersFrom returns the loaders of the request, nil when the request did
+// not go through dataloader.Middleware
+func loadersFrom(ctx context.Context) *Loaders {
+	loaders, _ := ctx.Value(loadersKey{}).(*Loaders)
+	return loaders
+}
+
+// loadUser returns the user with id, nil when there is none
+func (r *Resolver) loadUser(ctx context.Context, id string) (*model.User, error) {
+	if loaders := loadersFrom(ctx); loaders != nil {
+		return loaders.Users.Load(ctx, id)
+	}
+	user, err := r.repo.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return user, err
+}
+
+// loadUsers returns the users aligned with ids, nil for unknown IDs
+func (r *Resolver) loadUsers(ctx context.Context, ids []string) ([]*model.User, error) {
+	if loaders := loadersFrom(ctx); loaders != nil {
+		return loaders.Users.LoadMany(ctx, ids)
+	}
+	return r.repo.GetMany(ctx, ids)
+}
@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"shared/dataloader"
+	"strings"
+	"sync"
+	"testing"
+	"users/graph/model"
+	"users/repository"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+// countingRepository counts the lookups that reach the repository
+type countingRepository struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	gets  int
+	batch [][]string
+}
+
+func (r *countingRepository) Get(ctx context.Context, id string) (*model.User, error) {
+	r.mu.Lock()
+	r.gets++
+	r.mu.Unlock()
+	return r.UserRepository.Get(ctx, id)
+}
+
+func (r *countingRepository) GetMany(ctx context.Context, ids []string) ([]*model.User, error) {
+	r.mu.Lock()
+	r.batch = append(r.batch, ids)
+	r.mu.Unlock()
+	return r.UserRepository.GetMany(ctx, ids)
+}
+
+func TestUserLookupsShareOneBatch(t *testing.T) {
+	repo := &countingRepository{UserRepository: repository.NewMemoryRepository(repository.SeedUsers())}
+	resolver := NewResolver(WithRepository(repo))
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: resolver}))
+	srv.AddTransport(transport.POST{})
+	server := httptest.NewServer(dataloader.Middleware(resolver.AttachLoaders)(srv))
+	defer server.Close()
+
+	query := `{"query": "{ a: user(id: \"1\") { name } b: user(id: \"2\") { name } c: user(id: \"1\") { email } d: user(id: \"999\") { id } }"}`
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(query))
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	resp.Body.Close()
+
+	// Os quatro campos viram uma busca só, sem repetir o ID 1
+	if repo.gets != 0 || len(repo.batch) != 1 || len(repo.batch[0]) != 3 {
+		t.Errorf("gets = %d, batches = %v, want one batch of 3 IDs", repo.gets, repo.batch)
+	}
+}
+
+func TestUserLookupsWithoutLoaders(t *testing.T) {
+	repo := &countingRepository{UserRepository: repository.NewMemoryRepository(repository.SeedUsers())}
+	query := NewResolver(WithRepository(repo)).Query()
+
+	// Fora de uma requisição o resolver busca direto no repositório
+	user, err := query.User(context.Background(), "1")
+	if err != nil || user == nil || user.ID != "1" {
+		t.Fatalf("User() = %+v, %v", user, err)
+	}
+	if missing, err := query.User(context.Background(), "999"); missing != nil || err != nil {
+		t.Errorf("User(999) = %+v, %v, want nil", missing, err)
+	}
+	if repo.gets != 2 {
+		t.Errorf("gets = %d, want 2", repo.gets)
+	}
+}
@@ -2,10 +2,33 @@
 
 package model
 
+type CacheEviction struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
 type CacheStats struct {
-	Size    int    `json:"size"`
-	MaxSize int    `json:"maxSize"`
-	TTL     string `json:"ttl"`
+	Size        int              `json:"size"`
+	MaxSize     int              `json:"maxSize"`
+	TTL         string           `json:"ttl"`
+	Expirations int              `json:"expirations"`
+	Policy      string           `json:"policy"`
+	Evictions   []*CacheEviction `json:"evictions"`
+}
+
+type CreateUserInput struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type Mutation struct {
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor,omitempty"`
+	EndCursor       *string `json:"endCursor,omitempty"`
 }
 
 type Query struct {
@@ -23,8 +46,33 @@ type SafeAccessResult struct {
 	Duration string `json:"duration"`
 }
 
+type Subscription struct {
+}
+
+type UpdateUserInput struct {
+	Name  *string `json:"name,omitempty"`
+	Email *string `json:"email,omitempty"`
+}
+
 type User struct {
 	ID    string `json:"id"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
 }
+
+func (User) IsEntity() {}
+
+type UserByIDsInput struct {
+	ID string `json:"ID"`
+}
+
+type UserConnection struct {
+	Edges      []*UserEdge `json:"edges"`
+	PageInfo   *PageInfo   `json:"pageInfo"`
+	TotalCount int         `json:"totalCount"`
+}
+
+type UserEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *User  `json:"node"`
+}
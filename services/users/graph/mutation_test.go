@@ -0,0 +1,161 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"users/graph/model"
+	"users/repository"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func errorCode(t *testing.T, err error) (string, string) {
+	t.Helper()
+
+	var gqlErr *gqlerror.Error
+	if !errors.As(err, &gqlErr) {
+		t.Fatalf("expected a GraphQL error, got %T: %v", err, err)
+	}
+	code, _ := gqlErr.Extensions["code"].(string)
+	field, _ := gqlErr.Extensions["field"].(string)
+	return code, field
+}
+
+func TestCreateUser(t *testing.T) {
+	resolver := NewResolver()
+	ctx := context.Background()
+
+	user, err := resolver.Mutation().CreateUser(ctx, model.CreateUserInput{Name: "Ivy", Email: " Ivy@Example.com "})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if user.ID != "9" || user.Email != "ivy@example.com" {
+		t.Errorf("CreateUser() = %+v, want ID 9 and a normalized email", user)
+	}
+
+	found, _ := resolver.Query().User(ctx, user.ID)
+	if found == nil || found.Name != "Ivy" {
+		t.Errorf("created user is not readable: %v", found)
+	}
+}
+
+func TestCreateUserValidation(t *testing.T) {
+	resolver := NewResolver()
+
+	tests := []struct {
+		name      string
+		input     model.CreateUserInput
+		wantCode  string
+		wantField string
+	}{
+		{"blank name", model.CreateUserInput{Name: " ", Email: "x@example.com"}, repository.CodeRequired, "name"},
+		{"missing email", model.CreateUserInput{Name: "X"}, repository.CodeRequired, "email"},
+		{"malformed email", model.CreateUserInput{Name: "X", Email: "not-an-email"}, repository.CodeInvalidEmail, "email"},
+		{"email without domain", model.CreateUserInput{Name: "X", Email: "x@localhost"}, repository.CodeInvalidEmail, "email"},
+		{"display name", model.CreateUserInput{Name: "X", Email: "X <x@example.com>"}, repository.CodeInvalidEmail, "email"},
+		{"duplicate email", model.CreateUserInput{Name: "X", Email: "ALICE@example.com"}, repository.CodeEmailTaken, "email"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolver.Mutation().CreateUser(context.Background(), tt.input)
+			code, field := errorCode(t, err)
+			if code != tt.wantCode || field != tt.wantField {
+				t.Errorf("error = %s on %s, want %s on %s", code, field, tt.wantCode, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	resolver := NewResolver()
+	ctx := context.Background()
+
+	name := "Alicia"
+	user, err := resolver.Mutation().UpdateUser(ctx, "1", model.UpdateUserInput{Name: &name})
+	if err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if user.Name != "Alicia" || user.Email != "alice@example.com" {
+		t.Errorf("UpdateUser() = %+v, want only the name changed", user)
+	}
+
+	// Keeping the own email is not a conflict
+	email := "alice@example.com"
+	if _, err := resolver.Mutation().UpdateUser(ctx, "1", model.UpdateUserInput{Email: &email}); err != nil {
+		t.Errorf("UpdateUser() with the same email error = %v", err)
+	}
+
+	taken := "bob@example.com"
+	_, err = resolver.Mutation().UpdateUser(ctx, "1", model.UpdateUserInput{Email: &taken})
+	if code, _ := errorCode(t, err); code != repository.CodeEmailTaken {
+		t.Errorf("error code = %s, want %s", code, repository.CodeEmailTaken)
+	}
+
+	_, err = resolver.Mutation().UpdateUser(ctx, "999", model.UpdateUserInput{Name: &name})
+	if code, _ := errorCode(t, err); code != errNotFoundCode {
+		t.Errorf("error code = %s, want %s", code, errNotFoundCode)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	resolver := NewResolver()
+	ctx := context.Background()
+
+	id, err := resolver.Mutation().DeleteUser(ctx, "2")
+	if err != nil || id != "2" {
+		t.Fatalf("DeleteUser() = %q, %v", id, err)
+	}
+	if user, _ := resolver.Query().User(ctx, "2"); user != nil {
+		t.Errorf("deleted user is still readable: %v", user)
+	}
+
+	_, err = resolver.Mutation().DeleteUser(ctx, "2")
+	if code, _ := errorCode(t, err); code != errNotFoundCode {
+		t.Errorf("error code = %s, want %s", code, errNotFoundCode)
+	}
+
+	// The email of a deleted user can be used again
+	if _, err := resolver.Mutation().CreateUser(ctx, model.CreateUserInput{Name: "Bob", Email: "bob@example.com"}); err != nil {
+		t.Errorf("CreateUser() with a released email error = %v", err)
+	}
+}
+
+func TestMutationsKeepCacheConsistent(t *testing.T) {
+	resolver := NewResolver()
+	ctx := context.Background()
+
+	// Warm the cache
+	if _, err := resolver.Query().UsersFromCache(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	name := "Charles"
+	if _, err := resolver.Mutation().UpdateUser(ctx, "3", model.UpdateUserInput{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := resolver.Query().UserFromCache(ctx, "3"); user == nil || user.Name != "Charles" {
+		t.Errorf("cache served a stale user: %v", user)
+	}
+
+	if _, err := resolver.Mutation().DeleteUser(ctx, "4"); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := resolver.Query().UserFromCache(ctx, "4"); user != nil {
+		t.Errorf("cache served a deleted user: %v", user)
+	}
+
+	created, err := resolver.Mutation().CreateUser(ctx, model.CreateUserInput{Name: "Ivy", Email: "ivy@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, _ := resolver.Query().UsersFromCache(ctx)
+	found := false
+	for _, user := range users {
+		found = found || user.ID == created.ID
+	}
+	if !found || len(users) != 8 {
+		t.Errorf("usersFromCache returned %d users, created user present = %v", len(users), found)
+	}
+}
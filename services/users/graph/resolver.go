@@ -1,19 +1,87 @@
 package graph
 
 import (
+	"shared/dataloader"
+	"shared/eviction"
+	"shared/pubsub"
 	"time"
 	"users/graph/model"
+	"users/repository"
 )
 
+// userLoadTimeout limits each load of a user missing from the cache
+const userLoadTimeout = 5 * time.Second
+
 type Resolver struct {
-	cache *UserCache
+	cache   *UserCache
+	repo    repository.UserRepository
+	buffer  int
+	updates *pubsub.Broker[*model.User]
+
+	loaderConfig dataloader.Config
+	cachePolicy  eviction.Kind
+	cacheShards  int
+}
+
+// Option configures a Resolver
+type Option func(*Resolver)
+
+// WithRepository sets the repository the resolvers read users from
+func WithRepository(repo repository.UserRepository) Option {
+	return func(r *Resolver) {
+		r.repo = repo
+	}
+}
+
+// WithSubscriptionBuffer sets how many events a subscriber may fall behind
+// by before events are dropped for it
+func WithSubscriptionBuffer(size int) Option {
+	return func(r *Resolver) {
+		r.buffer = size
+	}
+}
+
+// WithLoaderConfig sets how the request DataLoaders batch their keys
+func WithLoaderConfig(config dataloader.Config) Option {
+	return func(r *Resolver) {
+		r.loaderConfig = config
+	}
+}
+
+// WithCachePolicy sets the eviction policy of the user cache
+func WithCachePolicy(kind eviction.Kind) Option {
+	return func(r *Resolver) {
+		r.cachePolicy = kind
+	}
 }
 
-// NewResolver cria um novo resolver com cache configurado
-func NewResolver() *Resolver {
-	return &Resolver{
-		cache: NewUserCache(100, 5*time.Minute), // Cache com 100 itens, TTL 5min
+// WithCacheShards spreads the user cache over shards locks
+func WithCacheShards(shards int) Option {
+	return func(r *Resolver) {
+		r.cacheShards = shards
+	}
+}
+
+// NewResolver cria um novo resolver com cache configurado. Sem
+// WithRepository os usuários ficam em memória, a partir de SeedUsers.
+func NewResolver(opts ...Option) *Resolver {
+	r := &Resolver{
+		loaderConfig: dataloader.Config{Wait: dataloader.DefaultWait, MaxBatch: dataloader.DefaultMaxBatch},
+		cachePolicy:  eviction.LRU,
+		cacheShards:  1,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.cache = NewUserCacheSharded(100, 5*time.Minute, r.cachePolicy, r.cacheShards) // Cache com 100 itens, TTL 5min
+	if r.repo == nil {
+		r.repo = repository.NewMemoryRepository(repository.SeedUsers())
 	}
+	// Faltas no cache carregam do repositório, uma carga por ID
+	r.cache.SetLoader(r.repo.Get, userLoadTimeout)
+	// Atualizações publicadas pelas mutations, por ID de usuário
+	r.updates = pubsub.NewBroker[*model.User]("users", "userUpdated", r.buffer)
+	return r
 }
 
 // Cache retorna o cache do resolver
@@ -21,46 +89,7 @@ func (r *Resolver) Cache() *UserCache {
 	return r.cache
 }
 
-// Mock data para usuários
-var users = []*model.User{
-	{
-		ID:    "1",
-		Name:  "Alice",
-		Email: "alice@example.com",
-	},
-	{
-		ID:    "2",
-		Name:  "Bob",
-		Email: "bob@example.com",
-	},
-	{
-		ID:    "3",
-		Name:  "Charlie",
-		Email: "charlie@example.com",
-	},
-	{
-		ID:    "4",
-		Name:  "Diana",
-		Email: "diana@example.com",
-	},
-	{
-		ID:    "5",
-		Name:  "Eve",
-		Email: "eve@example.com",
-	},
-	{
-		ID:    "6",
-		Name:  "Frank",
-		Email: "frank@example.com",
-	},
-	{
-		ID:    "7",
-		Name:  "Grace",
-		Email: "grace@example.com",
-	},
-	{
-		ID:    "8",
-		Name:  "Henry",
-		Email: "henry@example.com",
-	},
+// Repository retorna o repositório de usuários do resolver
+func (r *Resolver) Repository() repository.UserRepository {
+	return r.repo
 }
@@ -4,22 +4,20 @@ import (
 	"context"
 	"testing"
 	"time"
+	"users/repository"
 )
 
 // Benchmark for sequential user resolution (simulated)
 func BenchmarkSequentialUserResolution(b *testing.B) {
 	ids := []string{"1", "2", "3", "4", "5"}
+	repo := repository.NewMemoryRepository(repository.SeedUsers())
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		for _, id := range ids {
 			time.Sleep(100 * time.Millisecond)
 
-			for _, user := range users {
-				if user.ID == id {
-					break
-				}
-			}
+			_, _ = repo.Get(context.Background(), id)
 		}
 	}
 }
@@ -27,35 +25,35 @@ func BenchmarkSequentialUserResolution(b *testing.B) {
 // Benchmark for concurrent user resolution
 func BenchmarkConcurrentUserResolution(b *testing.B) {
 	ids := []string{"1", "2", "3", "4", "5"}
-	resolver := &Resolver{}
+	resolver := NewResolver().Query()
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		ctx := context.Background()
-		_, _ = resolver.UsersByIds(ctx, ids)
+		_, _ = resolver.UsersByIds(ctx, ids, nil)
 	}
 }
 
 // Benchmark for different array sizes
 func BenchmarkConcurrentUserResolution_Small(b *testing.B) {
 	ids := []string{"1", "2"}
-	resolver := &Resolver{}
+	resolver := NewResolver().Query()
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		ctx := context.Background()
-		_, _ = resolver.UsersByIds(ctx, ids)
+		_, _ = resolver.UsersByIds(ctx, ids, nil)
 	}
 }
 
 func BenchmarkConcurrentUserResolution_Medium(b *testing.B) {
 	ids := []string{"1", "2", "3", "4", "5", "6", "7", "8"}
-	resolver := &Resolver{}
+	resolver := NewResolver().Query()
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		ctx := context.Background()
-		_, _ = resolver.UsersByIds(ctx, ids)
+		_, _ = resolver.UsersByIds(ctx, ids, nil)
 	}
 }
 
@@ -64,25 +62,25 @@ func BenchmarkConcurrentUserResolution_Large(b *testing.B) {
 	for i := 0; i < 20; i++ {
 		ids[i] = string(rune('1' + i))
 	}
-	resolver := &Resolver{}
+	resolver := NewResolver().Query()
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		ctx := context.Background()
-		_, _ = resolver.UsersByIds(ctx, ids)
+		_, _ = resolver.UsersByIds(ctx, ids, nil)
 	}
 }
 
 // Test for race condition
 func TestConcurrentUserResolution_RaceCondition(t *testing.T) {
 	ids := []string{"1", "2", "3", "4", "5"}
-	resolver := &Resolver{}
+	resolver := NewResolver().Query()
 
 	// Execute multiple goroutines simultaneously
 	for i := 0; i < 10; i++ {
 		go func() {
 			ctx := context.Background()
-			_, _ = resolver.UsersByIds(ctx, ids)
+			_, _ = resolver.UsersByIds(ctx, ids, nil)
 		}()
 	}
 
@@ -93,12 +91,12 @@ func TestConcurrentUserResolution_RaceCondition(t *testing.T) {
 // Test for timeout
 func TestConcurrentUserResolution_Timeout(t *testing.T) {
 	ids := []string{"1", "2", "3", "4", "5"}
-	resolver := &Resolver{}
+	resolver := NewResolver().Query()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
 	defer cancel()
 
-	_, err := resolver.UsersByIds(ctx, ids)
+	_, err := resolver.UsersByIds(ctx, ids, nil)
 
 	if err == nil {
 		t.Error("Expected timeout error, got nil")
@@ -108,13 +106,13 @@ func TestConcurrentUserResolution_Timeout(t *testing.T) {
 // Test for cancellation
 func TestConcurrentUserResolution_Cancellation(t *testing.T) {
 	ids := []string{"1", "2", "3", "4", "5"}
-	resolver := &Resolver{}
+	resolver := NewResolver().Query()
 
 	ctx, cancel := context.WithCancel(context.Background())
 
 	cancel()
 
-	_, err := resolver.UsersByIds(ctx, ids)
+	_, err := resolver.UsersByIds(ctx, ids, nil)
 
 	if err == nil {
 		t.Error("Expected cancellation error, got nil")
@@ -124,10 +122,10 @@ func TestConcurrentUserResolution_Cancellation(t *testing.T) {
 // Test for valid data
 func TestConcurrentUserResolution_ValidData(t *testing.T) {
 	ids := []string{"1", "2", "3", "4", "5"}
-	resolver := &Resolver{}
+	resolver := NewResolver().Query()
 
 	ctx := context.Background()
-	result, err := resolver.UsersByIds(ctx, ids)
+	result, err := resolver.UsersByIds(ctx, ids, nil)
 
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
@@ -148,31 +146,36 @@ func TestConcurrentUserResolution_ValidData(t *testing.T) {
 // Test for invalid data
 func TestConcurrentUserResolution_InvalidData(t *testing.T) {
 	ids := []string{"999", "998", "997"}
-	resolver := &Resolver{}
+	resolver := NewResolver().Query()
 
 	ctx := context.Background()
-	result, err := resolver.UsersByIds(ctx, ids)
+	result, err := resolver.UsersByIds(ctx, ids, nil)
 
 	if err != nil {
 		t.Errorf("Expected no error for invalid IDs, got %v", err)
 	}
 
-	if len(result) != 0 {
-		t.Errorf("Expected 0 users for invalid IDs, got %d", len(result))
+	if len(result) != 3 {
+		t.Errorf("Expected one entry per invalid ID, got %d", len(result))
+	}
+	for i, user := range result {
+		if user != nil {
+			t.Errorf("Expected nil for invalid ID %s, got %+v", ids[i], user)
+		}
 	}
 }
 
 // Test for stress with multiple concurrent executions
 func TestConcurrentUserResolution_Stress(t *testing.T) {
 	ids := []string{"1", "2", "3", "4", "5"}
-	resolver := &Resolver{}
+	resolver := NewResolver().Query()
 
 	done := make(chan bool, 50)
 
 	for i := 0; i < 50; i++ {
 		go func() {
 			ctx := context.Background()
-			_, _ = resolver.UsersByIds(ctx, ids)
+			_, _ = resolver.UsersByIds(ctx, ids, nil)
 			done <- true
 		}()
 	}
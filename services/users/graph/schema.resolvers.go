@@ -6,189 +6,93 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"shared/pagination"
+	"sort"
+	"time"
 	"users/graph/model"
+	"users/repository"
 )
 
-// Users is the resolver for the users field.
-func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
-	panic(fmt.Errorf("not implemented: Users - users"))
-}
+// CreateUser is the resolver for the createUser field.
+func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUserInput) (*model.User, error) {
+	user, err := r.repo.Create(ctx, input)
+	if err != nil {
+		return nil, repositoryError(ctx, "", err)
+	}
 
-// User is the resolver for the user field.
-func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
-	panic(fmt.Errorf("not implemented: User - user"))
+	// Manter o cache consistente com o repositório
+	r.cache.SetUserSafe(user)
+	return user, nil
 }
 
-// UsersByIds is the resolver for the usersByIds field.
-func (r *queryResolver) UsersByIds(ctx context.Context, ids []string) ([]*model.User, error) {
-	panic(fmt.Errorf("not implemented: UsersByIds - usersByIds"))
-}
+// UpdateUser is the resolver for the updateUser field.
+func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input model.UpdateUserInput) (*model.User, error) {
+	user, err := r.repo.Update(ctx, id, input)
+	if err != nil {
+		return nil, repositoryError(ctx, id, err)
+	}
 
-// UsersFromCache is the resolver for the usersFromCache field.
-func (r *queryResolver) UsersFromCache(ctx context.Context) ([]*model.User, error) {
-	panic(fmt.Errorf("not implemented: UsersFromCache - usersFromCache"))
+	// Atualizar a entrada do cache com os novos dados
+	r.cache.SetUserSafe(user)
+	r.updates.Publish(user.ID, user)
+	return user, nil
 }
 
-// UserFromCache is the resolver for the userFromCache field.
-func (r *queryResolver) UserFromCache(ctx context.Context, id string) (*model.User, error) {
-	panic(fmt.Errorf("not implemented: UserFromCache - userFromCache"))
-}
+// DeleteUser is the resolver for the deleteUser field.
+func (r *mutationResolver) DeleteUser(ctx context.Context, id string) (string, error) {
+	if err := r.repo.Delete(ctx, id); err != nil {
+		return "", repositoryError(ctx, id, err)
+	}
 
-// CacheStats is the resolver for the cacheStats field.
-func (r *queryResolver) CacheStats(ctx context.Context) (*model.CacheStats, error) {
-	panic(fmt.Errorf("not implemented: CacheStats - cacheStats"))
+	// Remover o usuário do cache e encerrar as subscriptions dele
+	r.cache.DeleteUserSafe(id)
+	r.updates.CloseTopic(id)
+	return id, nil
 }
 
-// SimulateRaceCondition is the resolver for the simulateRaceCondition field.
-func (r *queryResolver) SimulateRaceCondition(ctx context.Context) (*model.RaceConditionResult, error) {
-	panic(fmt.Errorf("not implemented: SimulateRaceCondition - simulateRaceCondition"))
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
+	return r.repo.List(ctx)
 }
 
-// SimulateSafeAccess is the resolver for the simulateSafeAccess field.
-func (r *queryResolver) SimulateSafeAccess(ctx context.Context) (*model.SafeAccessResult, error) {
-	panic(fmt.Errorf("not implemented: SimulateSafeAccess - simulateSafeAccess"))
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
+	// Campos user irmãos da mesma requisição viram uma única busca
+	return r.loadUser(ctx, id)
 }
 
-// Query returns QueryResolver implementation.
-func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
-
-type queryResolver struct{ *Resolver }
-
-// !!! WARNING !!!
-// The code below was going to be deleted when updating resolvers. It has been copied here so you have
-// one last chance to move it out of harms way if you want. There are two reasons this happens:
-//  - When renaming or deleting a resolver the old code will be put in here. You can safely delete
-//    it when you're done.
-//  - You have helper methods in this file. Move them out to keep these resolver files clean.
-/*
-	func (r *Resolver) __resolveReference(ctx context.Context, obj interface{}) (interface{}, error) {
-	// Extrair o ID da referência
-	var id string
-	switch v := obj.(type) {
-	case map[string]interface{}:
-		if idVal, ok := v["id"]; ok {
-			if idStr, ok := idVal.(string); ok {
-				id = idStr
-			}
-		}
-	case *model.User:
-		id = v.ID
-	}
-
-	if id == "" {
-		return nil, nil
-	}
-
-	// Buscar o usuário pelo ID
-	for _, user := range users {
-		if user.ID == id {
-			return user, nil
-		}
-	}
-
-	return nil, nil
-}
-func (r *Resolver) Users(ctx context.Context) ([]*model.User, error) {
-	return users, nil
-}
-func (r *Resolver) User(ctx context.Context, id string) (*model.User, error) {
-	for _, user := range users {
-		if user.ID == id {
-			return user, nil
-		}
-	}
-	return nil, nil
-}
-func (r *Resolver) UsersByIds(ctx context.Context, ids []string) ([]*model.User, error) {
+// UsersByIds is the resolver for the usersByIds field.
+func (r *queryResolver) UsersByIds(ctx context.Context, ids []string, partial *bool) ([]*model.User, error) {
 	// Configurar contexto com timeout
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, batchTimeout)
 	defer cancel()
 
-	// Criar canal para resultados e erros
-	resultChan := make(chan *model.User, len(ids))
-	errorChan := make(chan error, len(ids))
-
-	// WaitGroup para aguardar todas as goroutines
-	var wg sync.WaitGroup
-
-	// Função para buscar um usuário individual
-	fetchUser := func(userID string) {
-		defer wg.Done()
-
-		// Simular latência de rede/database
-		select {
-		case <-time.After(100 * time.Millisecond):
-			// Simular latência variável
-		case <-ctx.Done():
-			errorChan <- ctx.Err()
-			return
-		}
-
-		// Verificar se o contexto foi cancelado
-		select {
-		case <-ctx.Done():
-			errorChan <- ctx.Err()
-			return
-		default:
-		}
-
-		// Buscar usuário
-		for _, user := range users {
-			if user.ID == userID {
-				resultChan <- user
-				return
-			}
-		}
-
-		// Usuário não encontrado
-		errorChan <- nil
-	}
-
-	// Iniciar goroutines para cada ID
-	for _, id := range ids {
-		wg.Add(1)
-		go fetchUser(id)
-	}
-
-	// Goroutine para aguardar conclusão e fechar canais
-	go func() {
-		wg.Wait()
-		close(resultChan)
-		close(errorChan)
-	}()
-
-	// Coletar resultados
-	var results []*model.User
-	var errors []error
-
-	// Coletar usuários encontrados
-	for user := range resultChan {
-		if user != nil {
-			results = append(results, user)
-		}
-	}
-
-	// Verificar erros
-	for err := range errorChan {
-		if err != nil {
-			errors = append(errors, err)
-		}
-	}
+	// Os resultados seguem a ordem dos IDs, com nil para os não encontrados
+	return fetchBatch(ctx, "usersByIds", ids, r.fetchUser).resolve(ctx, partial)
+}
 
-	// Se houve erros de contexto, retornar o primeiro
-	if len(errors) > 0 {
-		return results, errors[0]
+// UsersConnection is the resolver for the usersConnection field.
+func (r *queryResolver) UsersConnection(ctx context.Context, first *int, after *string, last *int, before *string) (*model.UserConnection, error) {
+	users, err := r.repo.List(ctx)
+	if err != nil {
+		return nil, err
 	}
-
-	return results, nil
+	return userConnection(ctx, users, pagination.Args{First: first, After: after, Last: last, Before: before})
 }
-func (r *Resolver) UsersFromCache(ctx context.Context) ([]*model.User, error) {
+
+// UsersFromCache is the resolver for the usersFromCache field.
+func (r *queryResolver) UsersFromCache(ctx context.Context) ([]*model.User, error) {
 	// Buscar usuários do cache (thread-safe)
 	cachedUsers := r.cache.GetUsersSafe()
 
-	// Se cache vazio, popular com dados mock
+	// Se cache vazio, popular a partir do repositório
 	if len(cachedUsers) == 0 {
+		users, err := r.repo.List(ctx)
+		if err != nil {
+			return nil, err
+		}
 		for _, user := range users {
 			r.cache.SetUserSafe(user)
 		}
@@ -197,33 +101,49 @@ func (r *Resolver) UsersFromCache(ctx context.Context) ([]*model.User, error) {
 
 	return cachedUsers, nil
 }
-func (r *Resolver) UserFromCache(ctx context.Context, id string) (*model.User, error) {
-	// Buscar usuário do cache (thread-safe)
-	if user, exists := r.cache.GetUserSafe(id); exists {
-		return user, nil
-	}
 
-	// Se não encontrado no cache, buscar nos dados mock
-	for _, user := range users {
-		if user.ID == id {
-			// Adicionar ao cache
-			r.cache.SetUserSafe(user)
-			return user, nil
-		}
+// UserFromCache is the resolver for the userFromCache field.
+func (r *queryResolver) UserFromCache(ctx context.Context, id string) (*model.User, error) {
+	// Buscar do cache; faltas simultâneas do mesmo ID viram uma só busca
+	// no repositório, que grava o usuário no cache
+	user, err := r.cache.GetUserOrLoad(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
 	}
-
-	return nil, nil
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
 }
-func (r *Resolver) CacheStats(ctx context.Context) (*model.CacheStats, error) {
+
+// CacheStats is the resolver for the cacheStats field.
+func (r *queryResolver) CacheStats(ctx context.Context) (*model.CacheStats, error) {
 	stats := r.cache.Stats()
 
+	// Motivos em ordem alfabética para uma resposta estável
+	counts := stats["evictions"].(map[string]int)
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	evictions := make([]*model.CacheEviction, len(reasons))
+	for i, reason := range reasons {
+		evictions[i] = &model.CacheEviction{Reason: reason, Count: counts[reason]}
+	}
+
 	return &model.CacheStats{
-		Size:    stats["size"].(int),
-		MaxSize: stats["max_size"].(int),
-		Ttl:     stats["ttl"].(string),
+		Size:        stats["size"].(int),
+		MaxSize:     stats["max_size"].(int),
+		TTL:         stats["ttl"].(string),
+		Expirations: stats["expirations"].(int),
+		Policy:      stats["policy"].(string),
+		Evictions:   evictions,
 	}, nil
 }
-func (r *Resolver) SimulateRaceCondition(ctx context.Context) (*model.RaceConditionResult, error) {
+
+// SimulateRaceCondition is the resolver for the simulateRaceCondition field.
+func (r *queryResolver) SimulateRaceCondition(ctx context.Context) (*model.RaceConditionResult, error) {
 	start := time.Now()
 
 	// Simular race condition (PODE CAUSAR PANIC!)
@@ -243,7 +163,9 @@ func (r *Resolver) SimulateRaceCondition(ctx context.Context) (*model.RaceCondit
 		Duration: duration.String(),
 	}, nil
 }
-func (r *Resolver) SimulateSafeAccess(ctx context.Context) (*model.SafeAccessResult, error) {
+
+// SimulateSafeAccess is the resolver for the simulateSafeAccess field.
+func (r *queryResolver) SimulateSafeAccess(ctx context.Context) (*model.SafeAccessResult, error) {
 	start := time.Now()
 
 	// Simular acesso seguro (thread-safe)
@@ -257,4 +179,30 @@ func (r *Resolver) SimulateSafeAccess(ctx context.Context) (*model.SafeAccessRes
 		Duration: duration.String(),
 	}, nil
 }
-*/
+
+// UserUpdated is the resolver for the userUpdated field.
+func (r *subscriptionResolver) UserUpdated(ctx context.Context, id string) (<-chan *model.User, error) {
+	// Assinar antes de conferir o usuário, para não perder uma remoção
+	// feita entre as duas etapas
+	updates := r.updates.Subscribe(ctx, id)
+	if _, err := r.repo.Get(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			r.updates.CloseTopic(id)
+		}
+		return nil, repositoryError(ctx, id, err)
+	}
+	return updates, nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+	"users/graph/model"
+)
+
+func TestUserUpdatedSubscription(t *testing.T) {
+	resolver := NewResolver()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates, err := resolver.Subscription().UserUpdated(ctx, "1")
+	if err != nil {
+		t.Fatalf("UserUpdated() error = %v", err)
+	}
+	other, _ := resolver.Subscription().UserUpdated(ctx, "2")
+
+	name := "Alice Cooper"
+	if _, err := resolver.Mutation().UpdateUser(context.Background(), "1", model.UpdateUserInput{Name: &name}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+
+	select {
+	case user := <-updates:
+		if user.ID != "1" || user.Name != name {
+			t.Errorf("received %+v, want the updated user", user)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no update received")
+	}
+	select {
+	case user := <-other:
+		t.Errorf("subscriber of user 2 received %+v", user)
+	default:
+	}
+
+	// Remover o usuário encerra a subscription
+	if _, err := resolver.Mutation().DeleteUser(context.Background(), "1"); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Error("received an update after the user was deleted")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription not closed after delete")
+	}
+}
+
+func TestUserUpdatedUnknownUser(t *testing.T) {
+	resolver := NewResolver()
+
+	_, err := resolver.Subscription().UserUpdated(context.Background(), "999")
+	if code, _ := errorCode(t, err); code != errNotFoundCode {
+		t.Errorf("code = %q, want %s", code, errNotFoundCode)
+	}
+	if n := resolver.updates.Subscribers("999"); n != 0 {
+		t.Errorf("%d subscribers left for an unknown user", n)
+	}
+}
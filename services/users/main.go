@@ -1,22 +1,39 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"os"
+	"shared/cache"
+	"shared/dataloader"
+	"shared/eviction"
+	"shared/extensions"
+	"shared/pubsub"
+	"shared/registry"
+	"time"
 	"users/graph"
 	"users/handlers"
 	"users/logger"
 	"users/middleware"
+	"users/repository"
 
 	"users/metrics"
 
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 const defaultPort = "8081"
 
+// cacheJanitorInterval is how often expired cache entries are dropped
+const cacheJanitorInterval = time.Minute
+
 func main() {
 	port := os.Getenv("USERS_SERVICE_PORT")
 	if port == "" {
@@ -26,16 +43,73 @@ func main() {
 	// Configure logger
 	logger := logger.SetupLogger()
 
-	// Create resolver with cache
-	resolver := graph.NewResolver()
+	// Create resolver with cache and the configured user repository
+	repo, err := repository.FromEnv()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to open user repository")
+	}
+	// Subscription events are buffered per subscriber
+	bufferSize, err := pubsub.BufferSizeFromEnv()
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid subscription configuration")
+	}
+	// Request DataLoaders batch the user lookups of one request
+	loaderConfig, err := dataloader.ConfigFromEnv()
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid DataLoader configuration")
+	}
+	// The cache evicts with the configured policy once it is full
+	cachePolicy, err := eviction.KindFromEnv("USERS_CACHE_POLICY")
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid cache configuration")
+	}
+	// Sharding spreads the cache over several locks to reduce contention
+	cacheShards, err := cache.ShardsFromEnv("USERS_CACHE_SHARDS")
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid cache configuration")
+	}
+	resolver := graph.NewResolver(
+		graph.WithRepository(repo),
+		graph.WithSubscriptionBuffer(bufferSize),
+		graph.WithLoaderConfig(loaderConfig),
+		graph.WithCachePolicy(cachePolicy),
+		graph.WithCacheShards(cacheShards),
+	)
+
+	// Drop expired cache entries in the background until the service stops
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	defer stopJanitor()
+	resolver.Cache().StartJanitor(janitorCtx, cacheJanitorInterval)
 
 	// Configure GraphQL
-	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	schema := graph.NewExecutableSchema(graph.Config{Resolvers: resolver})
+	srv := handler.New(schema)
+	srv.AddTransport(transport.Websocket{KeepAlivePingInterval: 10 * time.Second})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	// SSE and multipart/mixed must come before POST, which would also accept
+	// their requests: SSE streams subscriptions over plain HTTP and
+	// multipart/mixed delivers @defer fragments as they resolve
+	srv.AddTransport(transport.SSE{})
+	srv.AddTransport(transport.MultipartMixed{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.Use(extension.Introspection{})
+
+	// Persisted queries and safelist
+	extensionsConfig, err := extensions.ConfigFromEnv()
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid operation policy configuration")
+	}
+	if err := extensions.Install(srv, "users", graph.FieldCosts, extensionsConfig); err != nil {
+		logger.WithError(err).Fatal("Failed to configure operation policies")
+	}
 
 	// Configure mux
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/query", srv.ServeHTTP)
+	mux.Handle("/query", dataloader.Middleware(resolver.AttachLoaders)(srv))
 	mux.HandleFunc("/healthz", handlers.HealthHandler(logger))
 	mux.Handle("/metrics", promhttp.Handler())
 
@@ -58,11 +132,29 @@ func main() {
 			"http://localhost:" + port + "/healthz (Health Check)",
 			"http://localhost:" + port + "/metrics (Prometheus Metrics)",
 		},
-		"cache_max_size": resolver.Cache().Size(),
-		"cache_ttl":      "5m",
-		"features":       []string{"cache", "metrics", "tracing"},
+		"cache_max_size":       resolver.Cache().Size(),
+		"cache_ttl":            "5m",
+		"cache_policy":         cachePolicy,
+		"cache_shards":         cacheShards,
+		"repository":           fmt.Sprintf("%T", repo),
+		"features":             []string{"cache", "metrics", "tracing", "apq", "subscriptions", "defer", "dataloader"},
+		"subscription_buffer":  bufferSize,
+		"dataloader_wait":      loaderConfig.Wait.String(),
+		"dataloader_max_batch": loaderConfig.MaxBatch,
+		"safelist":             extensionsConfig.Strict,
+		"max_query_depth":      extensionsConfig.MaxQueryDepth,
+		"max_query_cost":       extensionsConfig.MaxQueryCost,
 	}).Info("Users service starting with cache, metrics and tracing")
 
+	// Register the schema with the registry when one is configured
+	if registryURL := os.Getenv("SCHEMA_REGISTRY_URL"); registryURL != "" {
+		routingURL := os.Getenv("USERS_SERVICE_URL")
+		if routingURL == "" {
+			routingURL = "http://localhost:" + port + "/query"
+		}
+		go registry.PushWithRetry(context.Background(), logger, registryURL, "users", routingURL, registry.SDL(schema.Schema()))
+	}
+
 	if err := http.ListenAndServe(":"+port, handlerWithMiddleware); err != nil {
 		logger.WithError(err).Fatal("Failed to start server")
 	}
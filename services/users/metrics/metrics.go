@@ -43,22 +43,6 @@ var (
 		},
 		[]string{"service", "error_type"},
 	)
-
-	CacheHits = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "cache_hits_total",
-			Help: "Total of cache hits by service",
-		},
-		[]string{"service"},
-	)
-
-	CacheMisses = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "cache_misses_total",
-			Help: "Total of cache misses by service",
-		},
-		[]string{"service"},
-	)
 )
 
 // MetricsMiddleware - Middleware to collect metrics
@@ -90,16 +74,6 @@ func RecordError(serviceName, errorType string) {
 	ErrorCounter.WithLabelValues(serviceName, errorType).Inc()
 }
 
-// RecordCacheHit - Record hit in cache
-func RecordCacheHit(serviceName string) {
-	CacheHits.WithLabelValues(serviceName).Inc()
-}
-
-// RecordCacheMiss - Record miss in cache
-func RecordCacheMiss(serviceName string) {
-	CacheMisses.WithLabelValues(serviceName).Inc()
-}
-
 // TraceMiddleware - Middleware to add TraceID to context
 func TraceMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
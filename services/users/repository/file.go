@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"users/graph/model"
+)
+
+// FileRepository serves users from memory and keeps them in a JSON file,
+// written atomically so a crash never leaves a partial file behind
+type FileRepository struct {
+	*MemoryRepository
+	path string
+}
+
+// NewFileRepository loads the users stored at path. A missing file is
+// created with seed.
+func NewFileRepository(path string, seed []*model.User) (*FileRepository, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		repo := newFileRepository(path, seed)
+		if err := repo.write(repo.snapshot()); err != nil {
+			return nil, fmt.Errorf("creating %s: %w", path, err)
+		}
+		return repo, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var users []*model.User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	for _, user := range users {
+		if user == nil || user.ID == "" {
+			return nil, fmt.Errorf("reading %s: user without an ID", path)
+		}
+	}
+	return newFileRepository(path, users), nil
+}
+
+func newFileRepository(path string, users []*model.User) *FileRepository {
+	repo := &FileRepository{MemoryRepository: NewMemoryRepository(users), path: path}
+	repo.persist = repo.write
+	return repo
+}
+
+// Path returns the file the users are stored in
+func (r *FileRepository) Path() string {
+	return r.path
+}
+
+// write replaces the file with users
+func (r *FileRepository) write(users []*model.User) error {
+	data, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".users-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"users/graph/model"
+)
+
+// MemoryRepository keeps users in a map guarded by a RWMutex
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	users  map[string]*model.User
+	emails map[string]string // normalized email -> user ID
+	lastID int
+
+	// persist is called with every user after a write, while the lock is
+	// held. A failed write is rolled back.
+	persist func(users []*model.User) error
+}
+
+// NewMemoryRepository creates a repository holding a copy of users
+func NewMemoryRepository(users []*model.User) *MemoryRepository {
+	repo := &MemoryRepository{
+		users:  make(map[string]*model.User, len(users)),
+		emails: make(map[string]string, len(users)),
+	}
+	for _, user := range users {
+		repo.put(clone(user))
+	}
+	return repo
+}
+
+// List returns every user ordered by ID
+func (r *MemoryRepository) List(ctx context.Context) ([]*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.snapshot(), nil
+}
+
+// Get returns the user with id or ErrNotFound
+func (r *MemoryRepository) Get(ctx context.Context, id string) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(user), nil
+}
+
+// GetMany returns the users aligned with ids, nil for unknown IDs
+func (r *MemoryRepository) GetMany(ctx context.Context, ids []string) ([]*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*model.User, len(ids))
+	for i, id := range ids {
+		users[i] = clone(r.users[id])
+	}
+	return users, nil
+}
+
+// Create stores a new user with the next free ID
+func (r *MemoryRepository) Create(ctx context.Context, input model.CreateUserInput) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	user := &model.User{Name: input.Name, Email: normalizeEmail(input.Email)}
+	if err := validateName(user.Name); err != nil {
+		return nil, err
+	}
+	if err := validateEmail(user.Email); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkEmailFree(user.Email, ""); err != nil {
+		return nil, err
+	}
+
+	lastID := r.lastID
+	user.ID = strconv.Itoa(lastID + 1)
+	r.put(user)
+
+	if err := r.save(); err != nil {
+		r.remove(user.ID)
+		r.lastID = lastID
+		return nil, err
+	}
+	return clone(user), nil
+}
+
+// Update changes the fields set in input and returns the updated user
+func (r *MemoryRepository) Update(ctx context.Context, id string, input model.UpdateUserInput) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	updated := clone(current)
+	if input.Name != nil {
+		if err := validateName(*input.Name); err != nil {
+			return nil, err
+		}
+		updated.Name = *input.Name
+	}
+	if input.Email != nil {
+		updated.Email = normalizeEmail(*input.Email)
+		if err := validateEmail(updated.Email); err != nil {
+			return nil, err
+		}
+		if err := r.checkEmailFree(updated.Email, id); err != nil {
+			return nil, err
+		}
+	}
+
+	r.remove(id)
+	r.put(updated)
+
+	if err := r.save(); err != nil {
+		r.remove(id)
+		r.put(current)
+		return nil, err
+	}
+	return clone(updated), nil
+}
+
+// Delete removes the user with id or returns ErrNotFound
+func (r *MemoryRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	r.remove(id)
+
+	if err := r.save(); err != nil {
+		r.put(current)
+		return err
+	}
+	return nil
+}
+
+// checkEmailFree fails when email belongs to a user other than id
+func (r *MemoryRepository) checkEmailFree(email, id string) error {
+	if owner, taken := r.emails[email]; taken && owner != id {
+		return &ValidationError{Field: "email", Code: CodeEmailTaken, Message: fmt.Sprintf("email %s is already in use", email)}
+	}
+	return nil
+}
+
+// put stores user and its indexes, the caller holds the write lock
+func (r *MemoryRepository) put(user *model.User) {
+	r.users[user.ID] = user
+	r.emails[normalizeEmail(user.Email)] = user.ID
+	if n, err := strconv.Atoi(user.ID); err == nil && n > r.lastID {
+		r.lastID = n
+	}
+}
+
+// remove deletes a user and its indexes, the caller holds the write lock
+func (r *MemoryRepository) remove(id string) {
+	if user, ok := r.users[id]; ok {
+		delete(r.emails, normalizeEmail(user.Email))
+		delete(r.users, id)
+	}
+}
+
+// save hands the users to persist, the caller holds the write lock
+func (r *MemoryRepository) save() error {
+	if r.persist == nil {
+		return nil
+	}
+	return r.persist(r.sorted())
+}
+
+// snapshot returns the stored users ordered by ID
+func (r *MemoryRepository) snapshot() []*model.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sorted()
+}
+
+// sorted copies the users ordered by ID, the caller holds the lock
+func (r *MemoryRepository) sorted() []*model.User {
+	users := make([]*model.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, clone(user))
+	}
+	sortUsers(users)
+	return users
+}
+
+// sortUsers orders users by numeric ID, falling back to string order
+func sortUsers(users []*model.User) {
+	sort.Slice(users, func(i, j int) bool {
+		a, errA := strconv.Atoi(users[i].ID)
+		b, errB := strconv.Atoi(users[j].ID)
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return users[i].ID < users[j].ID
+	})
+}
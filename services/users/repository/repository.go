@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"strings"
+
+	"users/graph/model"
+)
+
+// ErrNotFound is returned when no user has the requested ID
+var ErrNotFound = errors.New("user not found")
+
+// UserRepository stores the users served by the users subgraph
+type UserRepository interface {
+	// List returns every user ordered by ID
+	List(ctx context.Context) ([]*model.User, error)
+	// Get returns the user with id or ErrNotFound
+	Get(ctx context.Context, id string) (*model.User, error)
+	// GetMany returns the users aligned with ids, nil for unknown IDs
+	GetMany(ctx context.Context, ids []string) ([]*model.User, error)
+	// Create stores a new user with the next free ID
+	Create(ctx context.Context, input model.CreateUserInput) (*model.User, error)
+	// Update changes the fields set in input and returns the updated user
+	Update(ctx context.Context, id string, input model.UpdateUserInput) (*model.User, error)
+	// Delete removes the user with id or returns ErrNotFound
+	Delete(ctx context.Context, id string) error
+}
+
+// Validation error codes
+const (
+	CodeRequired     = "REQUIRED"
+	CodeInvalidEmail = "INVALID_EMAIL"
+	CodeEmailTaken   = "EMAIL_TAKEN"
+)
+
+// ValidationError reports an input field the repository refused to store
+type ValidationError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// normalizeEmail trims an email and lower-cases it, so uniqueness does not
+// depend on how the address was typed
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// validateName rejects blank names
+func validateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return &ValidationError{Field: "name", Code: CodeRequired, Message: "name is required"}
+	}
+	return nil
+}
+
+// validateEmail accepts a bare address with a domain, such as
+// alice@example.com, and rejects display names like "Alice <a@b.com>"
+func validateEmail(email string) error {
+	if email == "" {
+		return &ValidationError{Field: "email", Code: CodeRequired, Message: "email is required"}
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || !strings.Contains(email[strings.LastIndex(email, "@")+1:], ".") {
+		return &ValidationError{Field: "email", Code: CodeInvalidEmail, Message: fmt.Sprintf("%q is not a valid email address", email)}
+	}
+	return nil
+}
+
+const defaultDataFile = "users.json"
+
+// FromEnv builds the repository selected by USERS_REPOSITORY (memory or
+// file). The file repository keeps users in USERS_DATA_FILE.
+func FromEnv() (UserRepository, error) {
+	switch kind := os.Getenv("USERS_REPOSITORY"); kind {
+	case "", "memory":
+		return NewMemoryRepository(SeedUsers()), nil
+	case "file":
+		path := os.Getenv("USERS_DATA_FILE")
+		if path == "" {
+			path = defaultDataFile
+		}
+		return NewFileRepository(path, SeedUsers())
+	default:
+		return nil, fmt.Errorf("invalid USERS_REPOSITORY %q, expected memory or file", kind)
+	}
+}
+
+// SeedUsers returns the users the service starts with
+func SeedUsers() []*model.User {
+	return []*model.User{
+		{ID: "1", Name: "Alice", Email: "alice@example.com"},
+		{ID: "2", Name: "Bob", Email: "bob@example.com"},
+		{ID: "3", Name: "Charlie", Email: "charlie@example.com"},
+		{ID: "4", Name: "Diana", Email: "diana@example.com"},
+		{ID: "5", Name: "Eve", Email: "eve@example.com"},
+		{ID: "6", Name: "Frank", Email: "frank@example.com"},
+		{ID: "7", Name: "Grace", Email: "grace@example.com"},
+		{ID: "8", Name: "Henry", Email: "henry@example.com"},
+	}
+}
+
+// clone copies a user so callers can not change the stored value
+func clone(user *model.User) *model.User {
+	if user == nil {
+		return nil
+	}
+	copied := *user
+	return &copied
+}
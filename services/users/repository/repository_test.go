@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"users/graph/model"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	repo := NewMemoryRepository(SeedUsers())
+	ctx := context.Background()
+
+	users, err := repo.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 8 || users[0].ID != "1" || users[7].ID != "8" {
+		t.Fatalf("List() returned %d users, want the 8 seed users in ID order", len(users))
+	}
+
+	user, err := repo.Get(ctx, "3")
+	if err != nil || user.Name != "Charlie" {
+		t.Errorf("Get(3) = %v, %v", user, err)
+	}
+	if _, err := repo.Get(ctx, "999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(999) error = %v, want ErrNotFound", err)
+	}
+
+	many, err := repo.GetMany(ctx, []string{"2", "999", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(many) != 3 || many[0].Name != "Bob" || many[1] != nil || many[2].Name != "Alice" {
+		t.Errorf("GetMany() = %v, want results aligned with the IDs", many)
+	}
+}
+
+func TestMemoryRepositoryReturnsCopies(t *testing.T) {
+	repo := NewMemoryRepository(SeedUsers())
+	ctx := context.Background()
+
+	user, _ := repo.Get(ctx, "1")
+	user.Name = "Mallory"
+
+	again, _ := repo.Get(ctx, "1")
+	if again.Name != "Alice" {
+		t.Errorf("stored user changed through a returned value: %q", again.Name)
+	}
+}
+
+func TestMemoryRepositoryHonoursContext(t *testing.T) {
+	repo := NewMemoryRepository(SeedUsers())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := repo.List(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("List() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestFileRepositorySeedsMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+
+	repo, err := NewFileRepository(path, SeedUsers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("seed file was not written: %v", err)
+	}
+
+	reopened, err := NewFileRepository(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, _ := reopened.List(context.Background())
+	if len(users) != 8 {
+		t.Errorf("reopened repository has %d users, want 8", len(users))
+	}
+	if repo.Path() != path {
+		t.Errorf("Path() = %q, want %q", repo.Path(), path)
+	}
+}
+
+func TestFileRepositoryLoadsExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	data := `[{"id": "42", "name": "Zoe", "email": "zoe@example.com"}]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewFileRepository(path, SeedUsers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := repo.Get(context.Background(), "42")
+	if err != nil || user.Name != "Zoe" {
+		t.Errorf("Get(42) = %v, %v", user, err)
+	}
+	if _, err := repo.Get(context.Background(), "1"); !errors.Is(err, ErrNotFound) {
+		t.Error("an existing file should not be merged with the seed")
+	}
+}
+
+func TestFileRepositoryRejectsInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"invalid.json":    `{`,
+		"missing-id.json": `[{"name": "No ID"}]`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewFileRepository(path, []*model.User{}); err == nil {
+			t.Errorf("NewFileRepository(%s) expected an error", name)
+		}
+	}
+}
+
+func TestFileRepositoryPersistsWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	repo, err := NewFileRepository(path, SeedUsers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, model.CreateUserInput{Name: "Ivy", Email: "ivy@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := "Bobby"
+	if _, err := repo.Update(ctx, "2", model.UpdateUserInput{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, "3"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileRepository(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user, err := reopened.Get(ctx, created.ID); err != nil || user.Email != "ivy@example.com" {
+		t.Errorf("created user was not persisted: %v, %v", user, err)
+	}
+	if user, _ := reopened.Get(ctx, "2"); user == nil || user.Name != "Bobby" {
+		t.Errorf("update was not persisted: %v", user)
+	}
+	if _, err := reopened.Get(ctx, "3"); !errors.Is(err, ErrNotFound) {
+		t.Error("delete was not persisted")
+	}
+}
+
+func TestFailedPersistRollsBack(t *testing.T) {
+	repo := NewMemoryRepository(SeedUsers())
+	repo.persist = func([]*model.User) error { return errors.New("disk full") }
+	ctx := context.Background()
+
+	if _, err := repo.Create(ctx, model.CreateUserInput{Name: "Ivy", Email: "ivy@example.com"}); err == nil {
+		t.Fatal("Create() expected the persist error")
+	}
+	email := "new@example.com"
+	if _, err := repo.Update(ctx, "1", model.UpdateUserInput{Email: &email}); err == nil {
+		t.Fatal("Update() expected the persist error")
+	}
+	if err := repo.Delete(ctx, "2"); err == nil {
+		t.Fatal("Delete() expected the persist error")
+	}
+
+	repo.persist = nil
+	users, _ := repo.List(ctx)
+	if len(users) != 8 || users[0].Email != "alice@example.com" {
+		t.Errorf("failed writes were not rolled back: %+v", users)
+	}
+	created, err := repo.Create(ctx, model.CreateUserInput{Name: "Ivy", Email: "ivy@example.com"})
+	if err != nil || created.ID != "9" {
+		t.Errorf("Create() after rollback = %v, %v, want ID 9", created, err)
+	}
+}
+
+func TestConcurrentCreatesKeepEmailsUnique(t *testing.T) {
+	repo := NewMemoryRepository(nil)
+	ctx := context.Background()
+
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		go func() {
+			_, err := repo.Create(ctx, model.CreateUserInput{Name: "Same", Email: "same@example.com"})
+			errs <- err
+		}()
+	}
+
+	created := 0
+	for i := 0; i < 20; i++ {
+		if err := <-errs; err == nil {
+			created++
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d users created with the same email, want 1", created)
+	}
+}
@@ -0,0 +1,320 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"shared/eviction"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// options holds the settings of a Cache
+type options struct {
+	ttl     time.Duration
+	maxSize int
+	policy  eviction.Kind
+	now     func() time.Time
+}
+
+// Option configures a Cache
+type Option func(*options)
+
+// WithTTL expires entries ttl after they are written, 0 keeps them until
+// they are evicted
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithMaxSize limits the cache to size entries, 0 for no limit
+func WithMaxSize(size int) Option {
+	return func(o *options) {
+		o.maxSize = size
+	}
+}
+
+// WithPolicy sets the eviction policy of a full cache, LRU by default
+func WithPolicy(kind eviction.Kind) Option {
+	return func(o *options) {
+		o.policy = kind
+	}
+}
+
+// WithClock sets the clock entries expire by
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// Stats is a snapshot of a cache
+type Stats struct {
+	Size        int
+	MaxSize     int
+	TTL         time.Duration
+	Policy      eviction.Kind
+	Hits        int
+	Misses      int
+	Expirations int
+	// Evictions counts the entries evicted or refused, by reason
+	Evictions map[string]int
+}
+
+// Cache is a thread-safe map with per-entry TTL, an optional size limit
+// and an eviction policy. Its metrics carry the service and cache name.
+type Cache[K comparable, V any] struct {
+	service string
+	name    string
+	options
+
+	// Contadores resolvidos uma vez, fora do caminho quente de Get
+	hitCounter  prometheus.Counter
+	missCounter prometheus.Counter
+
+	mu      sync.Mutex
+	entries map[K]*entry[V]
+	policy  eviction.Policy[K]
+	hits    int
+	misses  int
+	expired int
+	evicted map[string]int
+}
+
+// entry is a cached value and the moment it expires, zero for never
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func (e *entry[V]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// New creates a cache. service and name label its metrics.
+func New[K comparable, V any](service, name string, opts ...Option) *Cache[K, V] {
+	o := options{policy: eviction.LRU, now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Cache[K, V]{
+		service:     service,
+		name:        name,
+		options:     o,
+		hitCounter:  Hits.WithLabelValues(service, name),
+		missCounter: Misses.WithLabelValues(service, name),
+		entries:     make(map[K]*entry[V]),
+		policy:      eviction.New[K](o.policy, o.maxSize),
+		evicted:     make(map[string]int),
+	}
+}
+
+// Name returns the name of the cache
+func (c *Cache[K, V]) Name() string {
+	return c.name
+}
+
+// Get returns the value of key. An expired entry is a miss and is dropped
+// on the spot. Reads take the lock exclusively because every read updates
+// the eviction policy.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, exists := c.entries[key]
+	if exists && e.expired(c.now()) {
+		c.deleteLocked(key)
+		c.recordExpiredLocked(1)
+		exists = false
+	}
+	c.policy.Access(key, exists)
+	if !exists {
+		c.misses++
+		c.missCounter.Inc()
+		var zero V
+		return zero, false
+	}
+	c.hits++
+	c.hitCounter.Inc()
+	return e.value, true
+}
+
+// Set writes value with the cache TTL
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.ttl)
+}
+
+// SetWithTTL writes value expiring after ttl instead of the cache TTL, 0
+// for never. A full cache first drops its expired entries, then asks the
+// eviction policy for a victim; an admission policy may keep the new key
+// out instead.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Regravar uma chave não precisa de espaço
+	if _, cached := c.entries[key]; !cached && c.fullLocked() {
+		// Entradas vencidas saem antes de qualquer entrada válida
+		if c.deleteExpiredLocked() == 0 && !c.makeRoomLocked(key) {
+			c.recordEvictedLocked(eviction.ReasonRejected, 1)
+			return
+		}
+	}
+
+	e := &entry[V]{value: value}
+	if ttl > 0 {
+		e.expiresAt = c.now().Add(ttl)
+	}
+	c.entries[key] = e
+	c.policy.Add(key)
+}
+
+func (c *Cache[K, V]) fullLocked() bool {
+	return c.maxSize > 0 && len(c.entries) >= c.maxSize
+}
+
+// makeRoomLocked evicts policy victims until candidate fits, and reports
+// false when the policy rejects candidate instead
+func (c *Cache[K, V]) makeRoomLocked(candidate K) bool {
+	for c.fullLocked() {
+		victim, ok := c.policy.Victim()
+		if !ok {
+			return true
+		}
+		if !c.policy.Admit(candidate, victim) {
+			return false
+		}
+		c.deleteLocked(victim)
+		c.recordEvictedLocked(eviction.ReasonCapacity, 1)
+	}
+	return true
+}
+
+// Delete drops key from the cache
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.deleteLocked(key)
+}
+
+func (c *Cache[K, V]) deleteLocked(key K) {
+	delete(c.entries, key)
+	c.policy.Remove(key)
+}
+
+// Values returns the cached values that have not expired, in no
+// particular order
+func (c *Cache[K, V]) Values() []V {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	values := make([]V, 0, len(c.entries))
+	for _, e := range c.entries {
+		if !e.expired(now) {
+			values = append(values, e.value)
+		}
+	}
+	return values
+}
+
+// Len returns the number of entries. Expired entries count until a read
+// or the janitor drops them.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// Clear drops every entry
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[K]*entry[V])
+	c.policy.Reset()
+}
+
+// DeleteExpired drops every expired entry and returns how many it dropped
+func (c *Cache[K, V]) DeleteExpired() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.deleteExpiredLocked()
+}
+
+func (c *Cache[K, V]) deleteExpiredLocked() int {
+	now := c.now()
+	removed := 0
+	for key, e := range c.entries {
+		if e.expired(now) {
+			c.deleteLocked(key)
+			removed++
+		}
+	}
+	c.recordExpiredLocked(removed)
+	return removed
+}
+
+// StartJanitor drops expired entries every interval until ctx is done, so
+// entries nobody reads again do not hold memory. The returned channel is
+// closed once the janitor stops.
+func (c *Cache[K, V]) StartJanitor(ctx context.Context, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.DeleteExpired()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// recordExpiredLocked counts entries dropped because their TTL passed,
+// which are also evictions with reason expired
+func (c *Cache[K, V]) recordExpiredLocked(n int) {
+	if n == 0 {
+		return
+	}
+	c.expired += n
+	RecordExpirations(c.service, c.name, n)
+	c.recordEvictedLocked(eviction.ReasonExpired, n)
+}
+
+func (c *Cache[K, V]) recordEvictedLocked(reason string, n int) {
+	c.evicted[reason] += n
+	recordEvictions(c.service, c.name, string(c.policy.Kind()), reason, n)
+}
+
+// Stats returns a snapshot of the cache
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	evictions := make(map[string]int, len(c.evicted))
+	for reason, n := range c.evicted {
+		evictions[reason] = n
+	}
+	return Stats{
+		Size:        len(c.entries),
+		MaxSize:     c.maxSize,
+		TTL:         c.ttl,
+		Policy:      c.policy.Kind(),
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Expirations: c.expired,
+		Evictions:   evictions,
+	}
+}
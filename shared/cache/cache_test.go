@@ -0,0 +1,208 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"shared/eviction"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeClock is a clock the tests move by hand
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func TestGetAndSet(t *testing.T) {
+	c := New[int, string]("test", "basic")
+
+	if _, ok := c.Get(1); ok {
+		t.Fatal("Get() hit on an empty cache")
+	}
+	c.Set(1, "one")
+	c.Set(2, "two")
+	if value, ok := c.Get(1); !ok || value != "one" {
+		t.Errorf("Get(1) = %q, %v, want one", value, ok)
+	}
+
+	c.Delete(1)
+	if _, ok := c.Get(1); ok {
+		t.Error("Get(1) hit after Delete")
+	}
+	if values := c.Values(); len(values) != 1 || values[0] != "two" {
+		t.Errorf("Values() = %v, want [two]", values)
+	}
+
+	c.Clear()
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d after Clear, want 0", c.Len())
+	}
+}
+
+func TestMetricsAreLabelledByName(t *testing.T) {
+	hits := testutil.ToFloat64(Hits.WithLabelValues("test", "labelled"))
+	misses := testutil.ToFloat64(Misses.WithLabelValues("test", "labelled"))
+	other := testutil.ToFloat64(Hits.WithLabelValues("test", "other"))
+
+	c := New[string, int]("test", "labelled")
+	c.Set("a", 1)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+
+	if got := testutil.ToFloat64(Hits.WithLabelValues("test", "labelled")) - hits; got != 2 {
+		t.Errorf("hits grew by %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(Misses.WithLabelValues("test", "labelled")) - misses; got != 1 {
+		t.Errorf("misses grew by %v, want 1", got)
+	}
+	// Outro cache do mesmo serviço não é afetado
+	if got := testutil.ToFloat64(Hits.WithLabelValues("test", "other")) - other; got != 0 {
+		t.Errorf("hits of another cache grew by %v", got)
+	}
+	if stats := c.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("Stats() = %+v, want 2 hits and 1 miss", stats)
+	}
+}
+
+func TestEntriesExpire(t *testing.T) {
+	clock := newClock()
+	c := New[string, int]("test", "ttl", WithTTL(time.Minute), WithClock(clock.Now))
+
+	c.Set("default", 1)
+	c.SetWithTTL("short", 2, time.Second)
+	c.SetWithTTL("forever", 3, 0)
+
+	clock.Advance(time.Second)
+	if _, ok := c.Get("short"); ok {
+		t.Error("short entry still cached after its own TTL")
+	}
+	if _, ok := c.Get("default"); !ok {
+		t.Error("default entry expired before the cache TTL")
+	}
+
+	clock.Advance(time.Hour)
+	if removed := c.DeleteExpired(); removed != 1 {
+		t.Errorf("DeleteExpired() = %d, want 1", removed)
+	}
+	if values := c.Values(); len(values) != 1 || values[0] != 3 {
+		t.Errorf("Values() = %v, want only the entry without TTL", values)
+	}
+	if stats := c.Stats(); stats.Expirations != 2 || stats.Evictions[eviction.ReasonExpired] != 2 {
+		t.Errorf("Stats() = %+v, want 2 expirations", stats)
+	}
+}
+
+func TestMaxSizeEvictsWithPolicy(t *testing.T) {
+	c := New[int, int]("test", "size", WithMaxSize(2), WithPolicy(eviction.LFU))
+
+	c.Set(1, 1)
+	c.Set(2, 2)
+	c.Get(1)
+	c.Set(3, 3)
+
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+	if _, ok := c.Get(2); ok {
+		t.Error("the least frequently used entry was kept")
+	}
+	if stats := c.Stats(); stats.Policy != eviction.LFU || stats.Evictions[eviction.ReasonCapacity] != 1 {
+		t.Errorf("Stats() = %+v, want 1 capacity eviction by lfu", stats)
+	}
+}
+
+func TestFullCacheDropsExpiredFirst(t *testing.T) {
+	clock := newClock()
+	c := New[string, int]("test", "expired-first", WithMaxSize(2), WithClock(clock.Now))
+
+	c.SetWithTTL("old", 1, time.Second)
+	c.Set("valid", 2)
+	clock.Advance(2 * time.Second)
+	c.Set("new", 3)
+
+	for _, key := range []string{"valid", "new"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("entry %s not cached", key)
+		}
+	}
+}
+
+func TestWithoutMaxSizeNothingIsEvicted(t *testing.T) {
+	c := New[int, int]("test", "unbounded")
+	for i := 0; i < 1000; i++ {
+		c.Set(i, i)
+	}
+	if c.Len() != 1000 {
+		t.Errorf("Len() = %d, want 1000", c.Len())
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	c := New[int, int]("test", "concurrent", WithMaxSize(50), WithPolicy(eviction.TinyLFU))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := (g*31 + i) % 100
+				if _, ok := c.Get(key); !ok {
+					c.Set(key, key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if c.Len() > 50 {
+		t.Errorf("Len() = %d, want at most 50", c.Len())
+	}
+}
+
+func TestJanitor(t *testing.T) {
+	clock := newClock()
+	c := New[int, int]("test", "janitor", WithTTL(time.Minute), WithClock(clock.Now))
+	c.Set(1, 1)
+	c.Set(2, 2)
+	clock.Advance(time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := c.StartJanitor(ctx, 5*time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for c.Len() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d, want the expired entries dropped", c.Len())
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("janitor did not stop when its context was canceled")
+	}
+}
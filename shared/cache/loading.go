@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultLoadTimeout bounds a load when NewLoading gets no timeout
+const DefaultLoadTimeout = 5 * time.Second
+
+// Loader loads the value of a key the cache does not have
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// Loading puts a loader behind a Store. Concurrent misses for one key
+// share a single load, whose value is cached and whose error is returned
+// to every caller that waited on it. Errors are not cached, so the next
+// miss loads again.
+type Loading[K comparable, V any] struct {
+	Store[K, V]
+	load    Loader[K, V]
+	timeout time.Duration
+
+	// Contador resolvido uma vez, como os de Cache
+	mergedCounter prometheus.Counter
+
+	mu    sync.Mutex
+	calls map[K]*flight[V]
+}
+
+// flight is an in-flight load, its result is ready once done is closed.
+// A forgotten flight still answers its callers but is not cached.
+type flight[V any] struct {
+	done      chan struct{}
+	value     V
+	err       error
+	forgotten bool
+}
+
+// NewLoading loads the misses of store with fn, each load limited to
+// timeout. service and the store name label the merged calls metric.
+func NewLoading[K comparable, V any](store Store[K, V], service string, fn Loader[K, V], timeout time.Duration) *Loading[K, V] {
+	if timeout <= 0 {
+		timeout = DefaultLoadTimeout
+	}
+	return &Loading[K, V]{
+		Store:         store,
+		load:          fn,
+		timeout:       timeout,
+		mergedCounter: LoadsMerged.WithLabelValues(service, store.Name()),
+		calls:         make(map[K]*flight[V]),
+	}
+}
+
+// GetOrLoad returns the cached value of key, loading it on a miss. A
+// caller whose ctx ends stops waiting, but the load goes on for the
+// others.
+func (l *Loading[K, V]) GetOrLoad(ctx context.Context, key K) (V, error) {
+	if value, ok := l.Get(key); ok {
+		return value, nil
+	}
+
+	l.mu.Lock()
+	call, inFlight := l.calls[key]
+	if inFlight {
+		l.mergedCounter.Inc()
+	} else {
+		call = &flight[V]{done: make(chan struct{})}
+		l.calls[key] = call
+		// A carga não herda o cancelamento de quem a iniciou, só os valores
+		go l.run(context.WithoutCancel(ctx), key, call)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// run loads key, caches the value and wakes the callers waiting on call.
+// A load still running after the timeout fails with
+// context.DeadlineExceeded even if the loader ignores its ctx.
+func (l *Loading[K, V]) run(ctx context.Context, key K, call *flight[V]) {
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	result := make(chan flight[V], 1)
+	go func() {
+		var r flight[V]
+		defer func() {
+			if p := recover(); p != nil {
+				r.err = fmt.Errorf("cache %s: loading %v panicked: %v", l.Name(), key, p)
+			}
+			result <- r
+		}()
+		r.value, r.err = l.load(ctx, key)
+	}()
+
+	select {
+	case r := <-result:
+		call.value, call.err = r.value, r.err
+	case <-ctx.Done():
+		call.err = fmt.Errorf("cache %s: loading %v: %w", l.Name(), key, ctx.Err())
+	}
+	// A gravação fica sob o lock, então um Forget concorrente vem antes
+	// (e a carga não grava) ou depois (e apaga o que ela gravou)
+	l.mu.Lock()
+	if call.err == nil && !call.forgotten {
+		l.Store.Set(key, call.value)
+	}
+	// A chave sai do mapa depois de gravada, então quem chega agora
+	// encontra o valor no cache ou inicia uma nova carga
+	if l.calls[key] == call {
+		delete(l.calls, key)
+	}
+	l.mu.Unlock()
+	close(call.done)
+}
+
+// Forget keeps the load of key in flight, if any, from caching its value.
+// Writes and deletes of key call it, so a load that read the old value
+// before them does not write it back; the next miss loads again.
+func (l *Loading[K, V]) Forget(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.forget(key)
+}
+
+func (l *Loading[K, V]) forget(key K) {
+	if call, ok := l.calls[key]; ok {
+		call.forgotten = true
+		delete(l.calls, key)
+	}
+}
+
+// Set writes value and forgets the load of key in flight
+func (l *Loading[K, V]) Set(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.forget(key)
+	l.Store.Set(key, value)
+}
+
+// SetWithTTL writes value expiring after ttl and forgets the load of key
+// in flight
+func (l *Loading[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.forget(key)
+	l.Store.SetWithTTL(key, value, ttl)
+}
+
+// Delete drops key and forgets its load in flight
+func (l *Loading[K, V]) Delete(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.forget(key)
+	l.Store.Delete(key)
+}
+
+// Clear drops every entry and forgets every load in flight
+func (l *Loading[K, V]) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key := range l.calls {
+		l.forget(key)
+	}
+	l.Store.Clear()
+}
@@ -0,0 +1,243 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// gate is a loader that blocks until released and counts its calls
+type gate struct {
+	calls   atomic.Int32
+	release chan struct{}
+	err     error
+}
+
+func newGate() *gate {
+	return &gate{release: make(chan struct{})}
+}
+
+func (g *gate) load(ctx context.Context, key string) (string, error) {
+	g.calls.Add(1)
+	<-g.release
+	if g.err != nil {
+		return "", g.err
+	}
+	return "value-" + key, nil
+}
+
+// loadConcurrently calls GetOrLoad(key) from n goroutines and waits until
+// they all joined the load in flight
+func loadConcurrently(t *testing.T, l *Loading[string, string], g *gate, key string, n int) ([]string, []error) {
+	t.Helper()
+
+	merged := testutil.ToFloat64(LoadsMerged.WithLabelValues("test", l.Name()))
+	values := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i], errs[i] = l.GetOrLoad(context.Background(), key)
+		}(i)
+	}
+
+	// Só libera a carga depois que todas as chamadas se juntaram a ela
+	deadline := time.Now().Add(time.Second)
+	for testutil.ToFloat64(LoadsMerged.WithLabelValues("test", l.Name()))-merged < float64(n-1) {
+		if time.Now().After(deadline) {
+			t.Fatal("calls did not merge into one load")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(g.release)
+	wg.Wait()
+	return values, errs
+}
+
+func TestGetOrLoadMergesConcurrentMisses(t *testing.T) {
+	g := newGate()
+	l := NewLoading[string, string](New[string, string]("test", "merge"), "test", g.load, time.Second)
+
+	values, errs := loadConcurrently(t, l, g, "a", 10)
+	for i := range values {
+		if errs[i] != nil || values[i] != "value-a" {
+			t.Errorf("GetOrLoad() = %q, %v, want value-a", values[i], errs[i])
+		}
+	}
+	if calls := g.calls.Load(); calls != 1 {
+		t.Errorf("loader calls = %d, want 1", calls)
+	}
+
+	// O valor carregado fica no cache
+	if value, ok := l.Get("a"); !ok || value != "value-a" {
+		t.Errorf("Get() = %q, %v after the load", value, ok)
+	}
+	if _, err := l.GetOrLoad(context.Background(), "a"); err != nil || g.calls.Load() != 1 {
+		t.Errorf("GetOrLoad() loaded a cached key again, err = %v", err)
+	}
+}
+
+func TestGetOrLoadSharesErrors(t *testing.T) {
+	g := newGate()
+	g.err = errors.New("database down")
+	l := NewLoading[string, string](New[string, string]("test", "errors"), "test", g.load, time.Second)
+
+	_, errs := loadConcurrently(t, l, g, "a", 5)
+	for _, err := range errs {
+		if !errors.Is(err, g.err) {
+			t.Errorf("GetOrLoad() error = %v, want the loader error", err)
+		}
+	}
+	if calls := g.calls.Load(); calls != 1 {
+		t.Errorf("loader calls = %d, want 1", calls)
+	}
+
+	// Erros não ficam no cache: a próxima falta carrega de novo
+	g.err = nil
+	if value, err := l.GetOrLoad(context.Background(), "a"); err != nil || value != "value-a" {
+		t.Errorf("GetOrLoad() = %q, %v after the error, want a new load", value, err)
+	}
+	if calls := g.calls.Load(); calls != 2 {
+		t.Errorf("loader calls = %d, want 2", calls)
+	}
+}
+
+func TestGetOrLoadTimesOut(t *testing.T) {
+	// O loader ignora o contexto, mas o timeout vale mesmo assim
+	stuck := make(chan struct{})
+	defer close(stuck)
+	l := NewLoading[string, string](New[string, string]("test", "timeout"), "test", func(ctx context.Context, key string) (string, error) {
+		<-stuck
+		return "late", nil
+	}, 20*time.Millisecond)
+
+	start := time.Now()
+	_, err := l.GetOrLoad(context.Background(), "a")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetOrLoad() error = %v, want a deadline error", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetOrLoad() took %v, want the load timeout", elapsed)
+	}
+	if _, ok := l.Get("a"); ok {
+		t.Error("a timed out load was cached")
+	}
+}
+
+func TestGetOrLoadCallerCancelDoesNotStopTheLoad(t *testing.T) {
+	g := newGate()
+	l := NewLoading[string, string](New[string, string]("test", "cancel"), "test", g.load, time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := l.GetOrLoad(ctx, "a")
+		first <- err
+	}()
+	for g.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	second := make(chan string, 1)
+	go func() {
+		value, _ := l.GetOrLoad(context.Background(), "a")
+		second <- value
+	}()
+
+	// Quem iniciou a carga desiste, mas a outra chamada recebe o valor
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled caller error = %v, want context.Canceled", err)
+	}
+	close(g.release)
+	if value := <-second; value != "value-a" {
+		t.Errorf("second caller got %q, want value-a", value)
+	}
+}
+
+func TestGetOrLoadRecoversPanics(t *testing.T) {
+	l := NewLoading[string, string](New[string, string]("test", "panic"), "test", func(ctx context.Context, key string) (string, error) {
+		panic("boom")
+	}, time.Second)
+
+	if _, err := l.GetOrLoad(context.Background(), "a"); err == nil {
+		t.Error("GetOrLoad() error = nil, want the panic as an error")
+	}
+}
+
+func TestGetOrLoadOverShardedStore(t *testing.T) {
+	g := newGate()
+	l := NewLoading[string, string](NewSharded[string, string]("test", "sharded-load", 4), "test", g.load, time.Second)
+
+	loadConcurrently(t, l, g, "a", 4)
+	if calls := g.calls.Load(); calls != 1 {
+		t.Errorf("loader calls = %d, want 1", calls)
+	}
+}
+
+// startLoad calls GetOrLoad(key) in a goroutine and waits until the loader
+// is running, returning a channel with the value the caller got
+func startLoad(t *testing.T, l *Loading[string, string], g *gate, key string) <-chan string {
+	t.Helper()
+	calls := g.calls.Load()
+	got := make(chan string, 1)
+	go func() {
+		value, _ := l.GetOrLoad(context.Background(), key)
+		got <- value
+	}()
+	deadline := time.Now().Add(time.Second)
+	for g.calls.Load() == calls {
+		if time.Now().After(deadline) {
+			t.Fatal("the load did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return got
+}
+
+func TestDeleteDuringLoadIsNotUndone(t *testing.T) {
+	g := newGate()
+	l := NewLoading[string, string](New[string, string]("test", "forget-delete"), "test", g.load, time.Second)
+
+	got := startLoad(t, l, g, "a")
+	// A chave é removida enquanto a carga ainda lê o valor antigo
+	l.Delete("a")
+	close(g.release)
+
+	// Quem esperava recebe o valor carregado, mas ele não volta ao cache
+	if value := <-got; value != "value-a" {
+		t.Errorf("GetOrLoad() = %q, want value-a", value)
+	}
+	if value, ok := l.Get("a"); ok {
+		t.Errorf("Get() = %q after a delete during the load, want a miss", value)
+	}
+}
+
+func TestSetDuringLoadWins(t *testing.T) {
+	g := newGate()
+	l := NewLoading[string, string](New[string, string]("test", "forget-set"), "test", g.load, time.Second)
+
+	got := startLoad(t, l, g, "a")
+	l.Set("a", "updated")
+	close(g.release)
+	<-got
+
+	if value, ok := l.Get("a"); !ok || value != "updated" {
+		t.Errorf("Get() = %q, %v, want the value set during the load", value, ok)
+	}
+	// Depois da escrita, uma falta nova não reaproveita a carga esquecida
+	l.Delete("a")
+	if value, err := l.GetOrLoad(context.Background(), "a"); err != nil || value != "value-a" {
+		t.Errorf("GetOrLoad() = %q, %v after the delete", value, err)
+	}
+	if calls := g.calls.Load(); calls != 2 {
+		t.Errorf("loader calls = %d, want 2", calls)
+	}
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The cache metrics live with the cache instead of each service's metrics
+// package so they are registered once, whichever services import cache
+var (
+	Hits = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_hits_total",
+			Help: "Total of cache hits by service and cache",
+		},
+		[]string{"service", "cache"},
+	)
+
+	Misses = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_misses_total",
+			Help: "Total of cache misses by service and cache",
+		},
+		[]string{"service", "cache"},
+	)
+
+	Expirations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_expirations_total",
+			Help: "Total of cache entries dropped because their TTL passed, by service and cache",
+		},
+		[]string{"service", "cache"},
+	)
+
+	Evictions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Total of cache entries evicted or refused by service, cache, eviction policy and reason",
+		},
+		[]string{"service", "cache", "policy", "reason"},
+	)
+
+	LoadsMerged = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_loads_merged_total",
+			Help: "Total of cache misses that joined a load already in flight for the same key, by service and cache",
+		},
+		[]string{"service", "cache"},
+	)
+)
+
+// RecordExpirations - Record cache entries dropped after their TTL
+func RecordExpirations(serviceName, cacheName string, n int) {
+	Expirations.WithLabelValues(serviceName, cacheName).Add(float64(n))
+}
+
+// recordEvictions - Record cache entries evicted, or kept out by
+// admission, with the policy and reason
+func recordEvictions(serviceName, cacheName, policy, reason string, n int) {
+	Evictions.WithLabelValues(serviceName, cacheName, policy, reason).Add(float64(n))
+}
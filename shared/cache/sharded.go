@@ -0,0 +1,218 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Store is the API shared by Cache and Sharded
+type Store[K comparable, V any] interface {
+	Name() string
+	Get(key K) (V, bool)
+	Set(key K, value V)
+	SetWithTTL(key K, value V, ttl time.Duration)
+	Delete(key K)
+	Values() []V
+	Len() int
+	Clear()
+	DeleteExpired() int
+	StartJanitor(ctx context.Context, interval time.Duration) <-chan struct{}
+	Stats() Stats
+}
+
+var (
+	_ Store[string, int] = (*Cache[string, int])(nil)
+	_ Store[string, int] = (*Sharded[string, int])(nil)
+)
+
+// MaxShards bounds the shard count of NewSharded and ShardsFromEnv
+const MaxShards = 256
+
+// Sharded spreads keys over independent Cache shards by hash, so
+// goroutines working on different keys rarely wait on the same lock.
+// Size limits and eviction apply per shard: each shard holds its share of
+// the max size and evicts its own victims.
+type Sharded[K comparable, V any] struct {
+	name    string
+	maxSize int
+	seed    maphash.Seed
+	mask    uint64
+	shards  []*Cache[K, V]
+}
+
+// NewSharded creates a cache of at least shards shards, rounded up to a
+// power of two and capped at MaxShards. With a max size the count is also
+// capped at the largest power of two not above it, so no shard is left
+// without room. service and name label the metrics of every shard.
+func NewSharded[K comparable, V any](service, name string, shards int, opts ...Option) *Sharded[K, V] {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	limit := MaxShards
+	if o.maxSize > 0 {
+		limit = min(limit, o.maxSize)
+	}
+	count := 1
+	for count < shards && count*2 <= limit {
+		count <<= 1
+	}
+
+	s := &Sharded[K, V]{
+		name:    name,
+		maxSize: o.maxSize,
+		seed:    maphash.MakeSeed(),
+		mask:    uint64(count - 1),
+		shards:  make([]*Cache[K, V], count),
+	}
+	for i := range s.shards {
+		shardOpts := opts
+		// O limite é dividido exatamente: os primeiros maxSize%count shards
+		// ficam com uma entrada a mais, então a soma é o próprio maxSize
+		if o.maxSize > 0 {
+			size := o.maxSize / count
+			if i < o.maxSize%count {
+				size++
+			}
+			shardOpts = append(opts[:len(opts):len(opts)], WithMaxSize(size))
+		}
+		s.shards[i] = New[K, V](service, name, shardOpts...)
+	}
+	return s
+}
+
+// NewStore creates a Cache, or a Sharded cache when shards is above 1
+func NewStore[K comparable, V any](service, name string, shards int, opts ...Option) Store[K, V] {
+	if shards > 1 {
+		return NewSharded[K, V](service, name, shards, opts...)
+	}
+	return New[K, V](service, name, opts...)
+}
+
+// ShardsFromEnv reads the shard count from the environment variable key,
+// defaulting to 1 (no sharding)
+func ShardsFromEnv(key string) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return 1, nil
+	}
+	shards, err := strconv.Atoi(raw)
+	if err != nil || shards < 1 || shards > MaxShards {
+		return 0, fmt.Errorf("invalid %s %q, expected an integer from 1 to %d", key, raw, MaxShards)
+	}
+	return shards, nil
+}
+
+func (s *Sharded[K, V]) shard(key K) *Cache[K, V] {
+	return s.shards[maphash.Comparable(s.seed, key)&s.mask]
+}
+
+// Shards returns the number of shards
+func (s *Sharded[K, V]) Shards() int {
+	return len(s.shards)
+}
+
+// Name returns the name of the cache
+func (s *Sharded[K, V]) Name() string {
+	return s.name
+}
+
+// Get returns the value of key from its shard
+func (s *Sharded[K, V]) Get(key K) (V, bool) {
+	return s.shard(key).Get(key)
+}
+
+// Set writes value with the cache TTL
+func (s *Sharded[K, V]) Set(key K, value V) {
+	s.shard(key).Set(key, value)
+}
+
+// SetWithTTL writes value expiring after ttl instead of the cache TTL
+func (s *Sharded[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	s.shard(key).SetWithTTL(key, value, ttl)
+}
+
+// Delete drops key from the cache
+func (s *Sharded[K, V]) Delete(key K) {
+	s.shard(key).Delete(key)
+}
+
+// Values returns the values of every shard that have not expired
+func (s *Sharded[K, V]) Values() []V {
+	var values []V
+	for _, shard := range s.shards {
+		values = append(values, shard.Values()...)
+	}
+	return values
+}
+
+// Len returns the number of entries of every shard
+func (s *Sharded[K, V]) Len() int {
+	n := 0
+	for _, shard := range s.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+// Clear drops every entry
+func (s *Sharded[K, V]) Clear() {
+	for _, shard := range s.shards {
+		shard.Clear()
+	}
+}
+
+// DeleteExpired drops every expired entry and returns how many it dropped
+func (s *Sharded[K, V]) DeleteExpired() int {
+	removed := 0
+	for _, shard := range s.shards {
+		removed += shard.DeleteExpired()
+	}
+	return removed
+}
+
+// StartJanitor drops expired entries every interval until ctx is done. The
+// returned channel is closed once the janitor stops.
+func (s *Sharded[K, V]) StartJanitor(ctx context.Context, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.DeleteExpired()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// Stats returns the shard stats added together
+func (s *Sharded[K, V]) Stats() Stats {
+	total := Stats{MaxSize: s.maxSize, Evictions: make(map[string]int)}
+	for i, shard := range s.shards {
+		stats := shard.Stats()
+		if i == 0 {
+			total.TTL = stats.TTL
+			total.Policy = stats.Policy
+		}
+		total.Size += stats.Size
+		total.Hits += stats.Hits
+		total.Misses += stats.Misses
+		total.Expirations += stats.Expirations
+		for reason, n := range stats.Evictions {
+			total.Evictions[reason] += n
+		}
+	}
+	return total
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"shared/eviction"
+)
+
+func TestShardCountIsAPowerOfTwo(t *testing.T) {
+	for shards, want := range map[int]int{0: 1, 1: 1, 3: 4, 8: 8, 9: 16, 1000: MaxShards} {
+		if got := NewSharded[string, int]("test", "count", shards).Shards(); got != want {
+			t.Errorf("NewSharded(%d).Shards() = %d, want %d", shards, got, want)
+		}
+	}
+}
+
+func TestShardedGetAndSet(t *testing.T) {
+	c := NewSharded[string, int]("test", "sharded", 8)
+
+	for i := 0; i < 100; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+	for i := 0; i < 100; i++ {
+		if value, ok := c.Get(strconv.Itoa(i)); !ok || value != i {
+			t.Fatalf("Get(%d) = %d, %v", i, value, ok)
+		}
+	}
+	if c.Len() != 100 || len(c.Values()) != 100 {
+		t.Errorf("Len() = %d, Values() = %d, want 100", c.Len(), len(c.Values()))
+	}
+
+	// As chaves ficam espalhadas entre os shards
+	used := 0
+	for _, shard := range c.shards {
+		if shard.Len() > 0 {
+			used++
+		}
+	}
+	if used < 2 {
+		t.Errorf("keys landed on %d shard(s), want them spread", used)
+	}
+
+	c.Delete("1")
+	if _, ok := c.Get("1"); ok {
+		t.Error("Get(1) hit after Delete")
+	}
+	c.Clear()
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d after Clear, want 0", c.Len())
+	}
+}
+
+func TestShardedSplitsMaxSize(t *testing.T) {
+	c := NewSharded[int, int]("test", "sharded-size", 4, WithMaxSize(10), WithPolicy(eviction.LRU))
+	for i := 0; i < 100; i++ {
+		c.Set(i, i)
+	}
+
+	// Os 4 shards guardam 3, 3, 2 e 2 entradas, e 100 chaves enchem todos
+	if c.Len() != 10 {
+		t.Errorf("Len() = %d, want 10", c.Len())
+	}
+	stats := c.Stats()
+	if stats.MaxSize != 10 || stats.Size != c.Len() || stats.Evictions[eviction.ReasonCapacity] != 100-c.Len() {
+		t.Errorf("Stats() = %+v", stats)
+	}
+	for i, want := range []int{3, 3, 2, 2} {
+		if got := c.shards[i].Stats().MaxSize; got != want {
+			t.Errorf("shard %d MaxSize = %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestShardedNeverExceedsMaxSize(t *testing.T) {
+	for _, tc := range []struct{ shards, maxSize int }{{16, 100}, {16, 5}, {4, 1}, {64, 1000}} {
+		c := NewSharded[int, int]("test", "sharded-exact", tc.shards, WithMaxSize(tc.maxSize))
+		for i := 0; i < tc.maxSize*20; i++ {
+			c.Set(i, i)
+		}
+		if c.Len() > tc.maxSize {
+			t.Errorf("%d shards with max size %d hold %d entries", tc.shards, tc.maxSize, c.Len())
+		}
+		// Sem shards vazios, nenhum shard recusa tudo
+		if c.Shards() > tc.maxSize {
+			t.Errorf("%d shards for max size %d", c.Shards(), tc.maxSize)
+		}
+	}
+}
+
+func TestShardedExpires(t *testing.T) {
+	clock := newClock()
+	c := NewSharded[int, int]("test", "sharded-ttl", 4, WithTTL(time.Minute), WithClock(clock.Now))
+	for i := 0; i < 10; i++ {
+		c.Set(i, i)
+	}
+	clock.Advance(time.Minute)
+
+	if removed := c.DeleteExpired(); removed != 10 {
+		t.Errorf("DeleteExpired() = %d, want 10", removed)
+	}
+	if stats := c.Stats(); stats.Expirations != 10 || stats.TTL != time.Minute {
+		t.Errorf("Stats() = %+v, want 10 expirations", stats)
+	}
+}
+
+func TestShardedConcurrentAccess(t *testing.T) {
+	c := NewSharded[int, int]("test", "sharded-concurrent", 8, WithMaxSize(64))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := (g*17 + i) % 256
+				if _, ok := c.Get(key); !ok {
+					c.Set(key, key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if c.Len() > 64 {
+		t.Errorf("Len() = %d, want at most 64", c.Len())
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	if _, ok := NewStore[int, int]("test", "store", 1).(*Cache[int, int]); !ok {
+		t.Error("NewStore(1) is not a plain Cache")
+	}
+	if _, ok := NewStore[int, int]("test", "store", 4).(*Sharded[int, int]); !ok {
+		t.Error("NewStore(4) is not a Sharded cache")
+	}
+}
+
+func TestShardsFromEnv(t *testing.T) {
+	t.Setenv("TEST_CACHE_SHARDS", "")
+	if shards, err := ShardsFromEnv("TEST_CACHE_SHARDS"); err != nil || shards != 1 {
+		t.Errorf("ShardsFromEnv() = %d, %v, want 1 by default", shards, err)
+	}
+
+	t.Setenv("TEST_CACHE_SHARDS", "16")
+	if shards, err := ShardsFromEnv("TEST_CACHE_SHARDS"); err != nil || shards != 16 {
+		t.Errorf("ShardsFromEnv() = %d, %v, want 16", shards, err)
+	}
+
+	t.Setenv("TEST_CACHE_SHARDS", "0")
+	if _, err := ShardsFromEnv("TEST_CACHE_SHARDS"); err == nil {
+		t.Error("ShardsFromEnv() accepted 0 shards")
+	}
+
+	t.Setenv("TEST_CACHE_SHARDS", strconv.Itoa(MaxShards+1))
+	if _, err := ShardsFromEnv("TEST_CACHE_SHARDS"); err == nil {
+		t.Errorf("ShardsFromEnv() accepted %d shards", MaxShards+1)
+	}
+}
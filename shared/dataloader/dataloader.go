@@ -0,0 +1,218 @@
+package dataloader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults for Config
+const (
+	DefaultWait     = 2 * time.Millisecond
+	DefaultMaxBatch = 100
+)
+
+// Config sets how loaders batch their keys
+type Config struct {
+	// Wait is how long a batch collects keys after its first one
+	Wait time.Duration
+	// MaxBatch sends a batch as soon as it has this many keys
+	MaxBatch int
+}
+
+// ConfigFromEnv reads DATALOADER_WAIT (a duration such as 2ms) and
+// DATALOADER_MAX_BATCH, defaulting to DefaultWait and DefaultMaxBatch
+func ConfigFromEnv() (Config, error) {
+	config := Config{Wait: DefaultWait, MaxBatch: DefaultMaxBatch}
+
+	if raw := os.Getenv("DATALOADER_WAIT"); raw != "" {
+		wait, err := time.ParseDuration(raw)
+		if err != nil || wait < 0 {
+			return Config{}, fmt.Errorf("invalid DATALOADER_WAIT %q, expected a duration such as 2ms", raw)
+		}
+		config.Wait = wait
+	}
+	if raw := os.Getenv("DATALOADER_MAX_BATCH"); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size < 1 {
+			return Config{}, fmt.Errorf("invalid DATALOADER_MAX_BATCH %q, expected a positive integer", raw)
+		}
+		config.MaxBatch = size
+	}
+	return config, nil
+}
+
+// Middleware gives every request its own loaders. attach returns the
+// request context with fresh loaders in it, so values are never shared
+// between requests.
+func Middleware(attach func(ctx context.Context) context.Context) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(attach(r.Context())))
+		})
+	}
+}
+
+// BatchFunc fetches keys in one call. The values must be aligned with
+// keys; an error fails every key of the batch.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) ([]V, error)
+
+// Loader batches and deduplicates the loads of one request. Keys asked for
+// within the wait window go to a single BatchFunc call, and a key already
+// loaded or in flight is answered from the loader without a new fetch.
+type Loader[K comparable, V any] struct {
+	ctx     context.Context
+	service string
+	name    string
+	config  Config
+	fetch   BatchFunc[K, V]
+
+	mu      sync.Mutex
+	calls   map[K]*call[V]
+	pending *batch[K, V]
+}
+
+// call is the result of one key, ready once done is closed
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// batch is a group of keys waiting to be fetched together
+type batch[K comparable, V any] struct {
+	keys  []K
+	calls []*call[V]
+	timer *time.Timer
+}
+
+// New creates a loader bound to the request context ctx, which every batch
+// fetch runs with. service and name label its metrics.
+func New[K comparable, V any](ctx context.Context, service, name string, config Config, fetch BatchFunc[K, V]) *Loader[K, V] {
+	if config.MaxBatch < 1 {
+		config.MaxBatch = DefaultMaxBatch
+	}
+	return &Loader[K, V]{
+		ctx:     ctx,
+		service: service,
+		name:    name,
+		config:  config,
+		fetch:   fetch,
+		calls:   make(map[K]*call[V]),
+	}
+}
+
+// Load returns the value of key, fetched together with the other keys
+// asked for within the batch window
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	return wait(ctx, l.enqueue(key))
+}
+
+// LoadMany returns the values of keys in the same order. The keys join the
+// current batch, so they are fetched along with the loads of other fields.
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) ([]V, error) {
+	calls := make([]*call[V], len(keys))
+	for i, key := range keys {
+		calls[i] = l.enqueue(key)
+	}
+
+	values := make([]V, len(keys))
+	for i, c := range calls {
+		value, err := wait(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func wait[V any](ctx context.Context, c *call[V]) (V, error) {
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// enqueue returns the call of key, adding key to the pending batch unless
+// it was loaded before
+func (l *Loader[K, V]) enqueue(key K) *call[V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if c, ok := l.calls[key]; ok {
+		recordLoad(l.service, l.name, "hit")
+		return c
+	}
+	recordLoad(l.service, l.name, "miss")
+
+	c := &call[V]{done: make(chan struct{})}
+	l.calls[key] = c
+
+	if l.pending == nil {
+		b := &batch[K, V]{}
+		l.pending = b
+		b.timer = time.AfterFunc(l.config.Wait, func() { l.dispatch(b) })
+	}
+	b := l.pending
+	b.keys = append(b.keys, key)
+	b.calls = append(b.calls, c)
+
+	// Um lote cheio sai na hora, sem esperar a janela
+	if len(b.keys) >= l.config.MaxBatch {
+		l.pending = nil
+		b.timer.Stop()
+		go l.run(b)
+	}
+	return c
+}
+
+// dispatch sends b when its wait window ends, unless it already left full
+func (l *Loader[K, V]) dispatch(b *batch[K, V]) {
+	l.mu.Lock()
+	if l.pending != b {
+		l.mu.Unlock()
+		return
+	}
+	l.pending = nil
+	l.mu.Unlock()
+
+	l.run(b)
+}
+
+// run fetches a batch and completes its calls
+func (l *Loader[K, V]) run(b *batch[K, V]) {
+	recordBatch(l.service, l.name, len(b.keys))
+
+	values, err := l.fetch(l.ctx, b.keys)
+	if err == nil && len(values) != len(b.keys) {
+		err = fmt.Errorf("%s loader: batch returned %d values for %d keys", l.name, len(values), len(b.keys))
+	}
+
+	if err != nil {
+		// Erros não ficam no cache: uma nova carga da chave tenta de novo
+		l.mu.Lock()
+		for i, key := range b.keys {
+			if l.calls[key] == b.calls[i] {
+				delete(l.calls, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+
+	for i, c := range b.calls {
+		if err != nil {
+			c.err = err
+		} else {
+			c.value = values[i]
+		}
+		close(c.done)
+	}
+}
@@ -0,0 +1,188 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// recorder is a BatchFunc that doubles its keys and remembers each batch
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+	err     error
+}
+
+func (r *recorder) fetch(ctx context.Context, keys []int) ([]int, error) {
+	r.mu.Lock()
+	r.batches = append(r.batches, append([]int(nil), keys...))
+	err := r.err
+	r.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	values := make([]int, len(keys))
+	for i, key := range keys {
+		values[i] = key * 2
+	}
+	return values, nil
+}
+
+func (r *recorder) calls() [][]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.batches
+}
+
+// loadAll loads keys from concurrent goroutines, like sibling resolvers
+func loadAll(t *testing.T, loader *Loader[int, int], keys []int) []int {
+	t.Helper()
+
+	values := make([]int, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i, key int) {
+			defer wg.Done()
+			value, err := loader.Load(context.Background(), key)
+			if err != nil {
+				t.Errorf("Load(%d) error = %v", key, err)
+			}
+			values[i] = value
+		}(i, key)
+	}
+	wg.Wait()
+	return values
+}
+
+func TestLoadBatchesKeysWithinTheWindow(t *testing.T) {
+	rec := &recorder{}
+	loader := New(context.Background(), "test", "window", Config{Wait: 10 * time.Millisecond, MaxBatch: 100}, rec.fetch)
+
+	values := loadAll(t, loader, []int{1, 2, 3, 4})
+	for i, value := range values {
+		if value != (i+1)*2 {
+			t.Errorf("values[%d] = %d, want %d", i, value, (i+1)*2)
+		}
+	}
+	if batches := rec.calls(); len(batches) != 1 || len(batches[0]) != 4 {
+		t.Errorf("batches = %v, want one batch of 4 keys", batches)
+	}
+}
+
+func TestLoadDeduplicatesKeys(t *testing.T) {
+	rec := &recorder{}
+	loader := New(context.Background(), "test", "dedupe", Config{Wait: 10 * time.Millisecond, MaxBatch: 100}, rec.fetch)
+	hits := testutil.ToFloat64(Loads.WithLabelValues("test", "dedupe", "hit"))
+
+	loadAll(t, loader, []int{7, 7, 8, 7})
+	// Uma chave já carregada na requisição não gera uma nova busca
+	if value, _ := loader.Load(context.Background(), 8); value != 16 {
+		t.Errorf("Load(8) = %d, want 16", value)
+	}
+
+	if batches := rec.calls(); len(batches) != 1 || len(batches[0]) != 2 {
+		t.Errorf("batches = %v, want one batch with keys 7 and 8", batches)
+	}
+	if got := testutil.ToFloat64(Loads.WithLabelValues("test", "dedupe", "hit")) - hits; got != 3 {
+		t.Errorf("hits = %v, want 3", got)
+	}
+}
+
+func TestLoadSplitsFullBatches(t *testing.T) {
+	rec := &recorder{}
+	// Com uma janela longa, só o limite de tamanho dispara as buscas
+	loader := New(context.Background(), "test", "split", Config{Wait: time.Minute, MaxBatch: 2}, rec.fetch)
+
+	start := time.Now()
+	values, err := loader.LoadMany(context.Background(), []int{1, 2, 3, 4})
+	if err != nil {
+		t.Fatalf("LoadMany() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("LoadMany() took %v, want full batches sent right away", elapsed)
+	}
+	for i, value := range values {
+		if value != (i+1)*2 {
+			t.Errorf("values[%d] = %d, want %d", i, value, (i+1)*2)
+		}
+	}
+	if batches := rec.calls(); len(batches) != 2 {
+		t.Errorf("batches = %v, want 2 batches of 2 keys", batches)
+	}
+}
+
+func TestLoadDoesNotCacheErrors(t *testing.T) {
+	rec := &recorder{err: errors.New("database down")}
+	loader := New(context.Background(), "test", "errors", Config{Wait: time.Millisecond, MaxBatch: 100}, rec.fetch)
+
+	if _, err := loader.Load(context.Background(), 1); err == nil {
+		t.Fatal("Load() error = nil, want the batch error")
+	}
+
+	rec.mu.Lock()
+	rec.err = nil
+	rec.mu.Unlock()
+	if value, err := loader.Load(context.Background(), 1); err != nil || value != 2 {
+		t.Errorf("Load() = %d, %v after the error, want a new fetch", value, err)
+	}
+	if batches := rec.calls(); len(batches) != 2 {
+		t.Errorf("batches = %v, want the key fetched again", batches)
+	}
+}
+
+func TestLoadRecordsBatchSize(t *testing.T) {
+	rec := &recorder{}
+	loader := New(context.Background(), "test", "size", Config{Wait: 10 * time.Millisecond, MaxBatch: 100}, rec.fetch)
+
+	loadAll(t, loader, []int{1, 2, 3})
+	if n := testutil.CollectAndCount(BatchSize, "dataloader_batch_size"); n == 0 {
+		t.Error("no batch size recorded")
+	}
+}
+
+func TestMiddlewareAttachesFreshLoaders(t *testing.T) {
+	type key struct{}
+	var loaders []*Loader[int, int]
+	rec := &recorder{}
+
+	handler := Middleware(func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, key{}, New(ctx, "test", "middleware", Config{Wait: time.Millisecond}, rec.fetch))
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loader, _ := r.Context().Value(key{}).(*Loader[int, int])
+		if loader == nil {
+			t.Fatal("request without a loader")
+		}
+		loaders = append(loaders, loader)
+		_, _ = loader.Load(r.Context(), 1)
+	}))
+
+	for i := 0; i < 2; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/query", nil))
+	}
+	// Cada requisição tem o seu cache, então a chave é buscada duas vezes
+	if loaders[0] == loaders[1] || len(rec.calls()) != 2 {
+		t.Errorf("requests shared a loader, batches = %v", rec.calls())
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("DATALOADER_WAIT", "5ms")
+	t.Setenv("DATALOADER_MAX_BATCH", "50")
+	config, err := ConfigFromEnv()
+	if err != nil || config.Wait != 5*time.Millisecond || config.MaxBatch != 50 {
+		t.Errorf("ConfigFromEnv() = %+v, %v", config, err)
+	}
+
+	t.Setenv("DATALOADER_MAX_BATCH", "0")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("ConfigFromEnv() accepted DATALOADER_MAX_BATCH=0")
+	}
+}
@@ -0,0 +1,37 @@
+package dataloader
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The DataLoader metrics live with the loader so they are registered once,
+// whichever services import it
+var (
+	Loads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dataloader_loads_total",
+			Help: "Total of DataLoader key loads by service, loader and result (hit when the request already loaded the key)",
+		},
+		[]string{"service", "loader", "result"},
+	)
+
+	BatchSize = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "dataloader_batch_size",
+			Help:    "Number of keys per DataLoader batch fetch by service and loader",
+			Buckets: []float64{1, 2, 5, 10, 25, 50, 100, 250, 500},
+		},
+		[]string{"service", "loader"},
+	)
+)
+
+// recordLoad - Record a DataLoader key load (hit or miss)
+func recordLoad(serviceName, loader, result string) {
+	Loads.WithLabelValues(serviceName, loader, result).Inc()
+}
+
+// recordBatch - Record the size of a DataLoader batch fetch
+func recordBatch(serviceName, loader string, size int) {
+	BatchSize.WithLabelValues(serviceName, loader).Observe(float64(size))
+}
@@ -0,0 +1,81 @@
+package eviction
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Kind names an eviction policy
+type Kind string
+
+const (
+	// LRU evicts the key read least recently
+	LRU Kind = "lru"
+	// LFU evicts the key read least often, the least recent among ties
+	LFU Kind = "lfu"
+	// TinyLFU evicts like LRU but only admits a new key when it is
+	// requested more often than the key it would replace
+	TinyLFU Kind = "tinylfu"
+)
+
+// Reasons an entry leaves a cache, as reported in cache_evictions_total
+const (
+	// ReasonCapacity is an entry evicted to make room for a new one
+	ReasonCapacity = "capacity"
+	// ReasonRejected is a new entry the admission policy kept out
+	ReasonRejected = "rejected"
+	// ReasonExpired is an entry dropped because its TTL passed
+	ReasonExpired = "expired"
+)
+
+// Policy decides which key leaves a full cache. The cache calls it while
+// holding its own lock, so policies are not safe for concurrent use.
+type Policy[K comparable] interface {
+	// Kind returns the kind of the policy
+	Kind() Kind
+	// Access records a read of key, hit tells whether the cache had it
+	Access(key K, hit bool)
+	// Add records a key written to the cache
+	Add(key K)
+	// Remove forgets a key that left the cache
+	Remove(key K)
+	// Victim returns the key to evict next, false when the policy tracks
+	// no keys
+	Victim() (K, bool)
+	// Admit reports whether candidate may replace victim in a full cache
+	Admit(candidate, victim K) bool
+	// Reset forgets every key
+	Reset()
+}
+
+// ParseKind parses a policy name, case insensitive
+func ParseKind(name string) (Kind, error) {
+	switch kind := Kind(strings.ToLower(strings.TrimSpace(name))); kind {
+	case LRU, LFU, TinyLFU:
+		return kind, nil
+	}
+	return "", fmt.Errorf("unknown cache eviction policy %q, expected lru, lfu or tinylfu", name)
+}
+
+// KindFromEnv reads the policy name from the environment variable key,
+// defaulting to LRU
+func KindFromEnv(key string) (Kind, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return LRU, nil
+	}
+	return ParseKind(raw)
+}
+
+// New creates a policy for a cache holding up to capacity entries.
+// Unknown kinds get LRU.
+func New[K comparable](kind Kind, capacity int) Policy[K] {
+	switch kind {
+	case LFU:
+		return NewLFU[K]()
+	case TinyLFU:
+		return NewTinyLFU[K](capacity)
+	}
+	return NewLRU[K]()
+}
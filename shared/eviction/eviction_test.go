@@ -0,0 +1,154 @@
+package eviction
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fill adds keys to p in order
+func fill(p Policy[string], keys ...string) {
+	for _, key := range keys {
+		p.Add(key)
+	}
+}
+
+func assertVictim(t *testing.T, p Policy[string], want string) {
+	t.Helper()
+	if victim, ok := p.Victim(); !ok || victim != want {
+		t.Errorf("Victim() = %q, %v, want %q", victim, ok, want)
+	}
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	p := NewLRU[string]()
+	fill(p, "a", "b", "c")
+	assertVictim(t, p, "a")
+
+	// Uma leitura torna a chave a mais recente
+	p.Access("a", true)
+	assertVictim(t, p, "b")
+
+	// Regravar também conta como uso
+	p.Add("b")
+	assertVictim(t, p, "c")
+
+	p.Remove("c")
+	assertVictim(t, p, "a")
+}
+
+func TestLRUIgnoresMisses(t *testing.T) {
+	p := NewLRU[string]()
+	fill(p, "a", "b")
+	p.Access("z", false)
+	assertVictim(t, p, "a")
+}
+
+func TestLFUEvictsLeastFrequentlyUsed(t *testing.T) {
+	p := NewLFU[string]()
+	fill(p, "a", "b", "c")
+	for i := 0; i < 3; i++ {
+		p.Access("a", true)
+	}
+	p.Access("b", true)
+
+	// c nunca foi lida, mesmo sendo a mais recente
+	assertVictim(t, p, "c")
+	p.Remove("c")
+	assertVictim(t, p, "b")
+}
+
+func TestLFUBreaksTiesByRecency(t *testing.T) {
+	p := NewLFU[string]()
+	fill(p, "a", "b", "c")
+	p.Access("a", true)
+	p.Access("b", true)
+	p.Access("c", true)
+
+	// Todas com a mesma contagem: sai a usada há mais tempo
+	assertVictim(t, p, "a")
+	p.Access("a", true)
+	assertVictim(t, p, "b")
+}
+
+func TestLRUAndLFUAlwaysAdmit(t *testing.T) {
+	for _, p := range []Policy[string]{NewLRU[string](), NewLFU[string]()} {
+		fill(p, "hot")
+		for i := 0; i < 10; i++ {
+			p.Access("hot", true)
+		}
+		if !p.Admit("new", "hot") {
+			t.Errorf("%s rejected a new key", p.Kind())
+		}
+	}
+}
+
+func TestTinyLFUAdmitsOnlyMoreFrequentKeys(t *testing.T) {
+	p := NewTinyLFU[string](10)
+	fill(p, "hot")
+	for i := 0; i < 5; i++ {
+		p.Access("hot", true)
+	}
+
+	if p.Admit("one-off", "hot") {
+		t.Error("a key never requested replaced a hot key")
+	}
+
+	// As falhas também contam: uma chave pedida com frequência entra
+	for i := 0; i < 6; i++ {
+		p.Access("rising", false)
+	}
+	if !p.Admit("rising", "hot") {
+		t.Error("a key requested more often than the victim was rejected")
+	}
+}
+
+func TestTinyLFUEvictsInLRUOrder(t *testing.T) {
+	p := NewTinyLFU[string](10)
+	fill(p, "a", "b", "c")
+	p.Access("a", true)
+	assertVictim(t, p, "b")
+}
+
+func TestTinyLFUAgesFrequencies(t *testing.T) {
+	p := NewTinyLFU[string](10)
+	for i := 0; i < 8; i++ {
+		p.Access("old", false)
+	}
+	before := p.(*tinyLFU[string]).freq.estimate("old")
+
+	// Depois de 10x a capacidade em leituras os contadores caem pela metade
+	for i := 0; i < 100; i++ {
+		p.Access(fmt.Sprintf("key-%d", i), false)
+	}
+	if after := p.(*tinyLFU[string]).freq.estimate("old"); after >= before {
+		t.Errorf("estimate = %d after aging, want less than %d", after, before)
+	}
+}
+
+func TestResetForgetsKeys(t *testing.T) {
+	for _, kind := range []Kind{LRU, LFU, TinyLFU} {
+		p := New[string](kind, 10)
+		fill(p, "a", "b")
+		p.Reset()
+		if victim, ok := p.Victim(); ok {
+			t.Errorf("%s Victim() = %q after Reset", kind, victim)
+		}
+	}
+}
+
+func TestKindFromEnv(t *testing.T) {
+	t.Setenv("TEST_CACHE_POLICY", "")
+	if kind, err := KindFromEnv("TEST_CACHE_POLICY"); err != nil || kind != LRU {
+		t.Errorf("KindFromEnv() = %q, %v, want lru by default", kind, err)
+	}
+
+	t.Setenv("TEST_CACHE_POLICY", " TinyLFU ")
+	if kind, err := KindFromEnv("TEST_CACHE_POLICY"); err != nil || kind != TinyLFU {
+		t.Errorf("KindFromEnv() = %q, %v, want tinylfu", kind, err)
+	}
+
+	t.Setenv("TEST_CACHE_POLICY", "fifo")
+	if _, err := KindFromEnv("TEST_CACHE_POLICY"); err == nil {
+		t.Error("KindFromEnv() accepted TEST_CACHE_POLICY=fifo")
+	}
+}
@@ -0,0 +1,105 @@
+package eviction
+
+import "container/heap"
+
+// lfuItem is a tracked key, its read count and when it was last used
+type lfuItem[K comparable] struct {
+	key   K
+	freq  int
+	used  uint64
+	index int
+}
+
+// lfuHeap orders items by frequency, then by last use, so the root is the
+// victim
+type lfuHeap[K comparable] []*lfuItem[K]
+
+func (h lfuHeap[K]) Len() int { return len(h) }
+
+func (h lfuHeap[K]) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].used < h[j].used
+}
+
+func (h lfuHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap[K]) Push(x interface{}) {
+	item := x.(*lfuItem[K])
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *lfuHeap[K]) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return item
+}
+
+// lfu evicts the key with the fewest reads. The counts only cover the time
+// a key is cached: a key evicted and written again starts over.
+type lfu[K comparable] struct {
+	items map[K]*lfuItem[K]
+	heap  lfuHeap[K]
+	clock uint64
+}
+
+// NewLFU creates a least frequently used policy
+func NewLFU[K comparable]() Policy[K] {
+	return &lfu[K]{items: make(map[K]*lfuItem[K])}
+}
+
+func (p *lfu[K]) Kind() Kind { return LFU }
+
+func (p *lfu[K]) tick() uint64 {
+	p.clock++
+	return p.clock
+}
+
+func (p *lfu[K]) Access(key K, hit bool) {
+	if item, ok := p.items[key]; ok {
+		item.freq++
+		item.used = p.tick()
+		heap.Fix(&p.heap, item.index)
+	}
+}
+
+func (p *lfu[K]) Add(key K) {
+	if item, ok := p.items[key]; ok {
+		item.used = p.tick()
+		heap.Fix(&p.heap, item.index)
+		return
+	}
+	item := &lfuItem[K]{key: key, freq: 1, used: p.tick()}
+	p.items[key] = item
+	heap.Push(&p.heap, item)
+}
+
+func (p *lfu[K]) Remove(key K) {
+	if item, ok := p.items[key]; ok {
+		heap.Remove(&p.heap, item.index)
+		delete(p.items, key)
+	}
+}
+
+func (p *lfu[K]) Victim() (K, bool) {
+	if len(p.heap) == 0 {
+		var zero K
+		return zero, false
+	}
+	return p.heap[0].key, true
+}
+
+func (p *lfu[K]) Admit(candidate, victim K) bool { return true }
+
+func (p *lfu[K]) Reset() {
+	p.items = make(map[K]*lfuItem[K])
+	p.heap = nil
+}
@@ -0,0 +1,57 @@
+package eviction
+
+import "container/list"
+
+// lru keeps keys from the most to the least recently used
+type lru[K comparable] struct {
+	order *list.List
+	keys  map[K]*list.Element
+}
+
+// NewLRU creates a least recently used policy
+func NewLRU[K comparable]() Policy[K] {
+	return newLRU[K]()
+}
+
+func newLRU[K comparable]() *lru[K] {
+	return &lru[K]{order: list.New(), keys: make(map[K]*list.Element)}
+}
+
+func (p *lru[K]) Kind() Kind { return LRU }
+
+func (p *lru[K]) Access(key K, hit bool) {
+	if elem, ok := p.keys[key]; ok {
+		p.order.MoveToFront(elem)
+	}
+}
+
+func (p *lru[K]) Add(key K) {
+	if elem, ok := p.keys[key]; ok {
+		p.order.MoveToFront(elem)
+		return
+	}
+	p.keys[key] = p.order.PushFront(key)
+}
+
+func (p *lru[K]) Remove(key K) {
+	if elem, ok := p.keys[key]; ok {
+		p.order.Remove(elem)
+		delete(p.keys, key)
+	}
+}
+
+func (p *lru[K]) Victim() (K, bool) {
+	back := p.order.Back()
+	if back == nil {
+		var zero K
+		return zero, false
+	}
+	return back.Value.(K), true
+}
+
+func (p *lru[K]) Admit(candidate, victim K) bool { return true }
+
+func (p *lru[K]) Reset() {
+	p.order.Init()
+	p.keys = make(map[K]*list.Element)
+}
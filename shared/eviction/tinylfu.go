@@ -0,0 +1,116 @@
+package eviction
+
+import "hash/maphash"
+
+// sketchDepth is the number of rows of the count-min sketch
+const sketchDepth = 4
+
+// maxCount caps each counter, as in the 4-bit counters of the TinyLFU paper
+const maxCount = 15
+
+// sketch is a count-min sketch estimating how often each key was requested.
+// After sampleSize increments every counter is halved, so old popularity
+// fades and new hot keys can get in.
+type sketch[K comparable] struct {
+	seed       maphash.Seed
+	rows       [sketchDepth][]uint8
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+func newSketch[K comparable](capacity int) *sketch[K] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	width := 16
+	for width < capacity*4 {
+		width <<= 1
+	}
+	s := &sketch[K]{seed: maphash.MakeSeed(), mask: uint64(width - 1), sampleSize: capacity * 10}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// indexes returns the counter of key in each row, by double hashing
+func (s *sketch[K]) indexes(key K) [sketchDepth]uint64 {
+	sum := maphash.Comparable(s.seed, key)
+	lo, hi := sum, sum>>32|1
+
+	var idx [sketchDepth]uint64
+	for i := range idx {
+		idx[i] = (lo + uint64(i)*hi) & s.mask
+	}
+	return idx
+}
+
+func (s *sketch[K]) increment(key K) {
+	for i, j := range s.indexes(key) {
+		if s.rows[i][j] < maxCount {
+			s.rows[i][j]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.age()
+	}
+}
+
+func (s *sketch[K]) estimate(key K) uint8 {
+	min := uint8(maxCount)
+	for i, j := range s.indexes(key) {
+		if s.rows[i][j] < min {
+			min = s.rows[i][j]
+		}
+	}
+	return min
+}
+
+// age halves every counter
+func (s *sketch[K]) age() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+func (s *sketch[K]) reset() {
+	for i := range s.rows {
+		clear(s.rows[i])
+	}
+	s.additions = 0
+}
+
+// tinyLFU evicts in LRU order but counts every request, cached or not, and
+// lets a new key in only when it was requested more often than the victim.
+// A burst of one-off keys then cannot push the popular ones out.
+type tinyLFU[K comparable] struct {
+	*lru[K]
+	freq *sketch[K]
+}
+
+// NewTinyLFU creates a TinyLFU admission policy for a cache of capacity
+// entries
+func NewTinyLFU[K comparable](capacity int) Policy[K] {
+	return &tinyLFU[K]{lru: newLRU[K](), freq: newSketch[K](capacity)}
+}
+
+func (p *tinyLFU[K]) Kind() Kind { return TinyLFU }
+
+func (p *tinyLFU[K]) Access(key K, hit bool) {
+	p.freq.increment(key)
+	p.lru.Access(key, hit)
+}
+
+func (p *tinyLFU[K]) Admit(candidate, victim K) bool {
+	return p.freq.estimate(candidate) > p.freq.estimate(victim)
+}
+
+func (p *tinyLFU[K]) Reset() {
+	p.lru.Reset()
+	p.freq.reset()
+}
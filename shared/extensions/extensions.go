@@ -0,0 +1,179 @@
+package extensions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+)
+
+const (
+	defaultPersistedQueryCacheSize = 1000
+	defaultMaxQueryDepth           = 10
+	defaultMaxQueryCost            = 1000
+)
+
+var (
+	errMissingCache    = errors.New("persisted query cache can not be nil")
+	errMissingManifest = errors.New("safelist manifest can not be nil")
+)
+
+// Config holds the operation policies applied before execution
+type Config struct {
+	// PersistedQueryCacheSize bounds the automatic persisted query store
+	PersistedQueryCacheSize int
+	// SafelistManifest is the path of the persisted query manifest
+	SafelistManifest string
+	// Strict only lets operations from the manifest run and disables
+	// automatic persisted query registration
+	Strict bool
+	// FederationToken is the gateway credential that lets federation
+	// operations skip the safelist in strict mode, empty for none
+	FederationToken string
+	// MaxQueryDepth and MaxQueryCost bound the operations accepted, 0
+	// disables the check
+	MaxQueryDepth int
+	MaxQueryCost  int
+	// FieldCosts overrides the service defaults, keyed by "Type.field"
+	FieldCosts map[string]FieldCost
+}
+
+// ConfigFromEnv reads APQ_CACHE_SIZE, SAFELIST_MANIFEST, SAFELIST_MODE
+// (off or strict), FEDERATION_TOKEN, MAX_QUERY_DEPTH, MAX_QUERY_COST and
+// QUERY_FIELD_COSTS
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		PersistedQueryCacheSize: defaultPersistedQueryCacheSize,
+		SafelistManifest:        os.Getenv("SAFELIST_MANIFEST"),
+		FederationToken:         os.Getenv("FEDERATION_TOKEN"),
+		MaxQueryDepth:           defaultMaxQueryDepth,
+		MaxQueryCost:            defaultMaxQueryCost,
+	}
+
+	if raw := os.Getenv("APQ_CACHE_SIZE"); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size <= 0 {
+			return cfg, fmt.Errorf("invalid APQ_CACHE_SIZE %q", raw)
+		}
+		cfg.PersistedQueryCacheSize = size
+	}
+
+	switch mode := os.Getenv("SAFELIST_MODE"); mode {
+	case "", "off":
+	case "strict":
+		cfg.Strict = true
+		if cfg.SafelistManifest == "" {
+			return cfg, errors.New("SAFELIST_MODE=strict requires SAFELIST_MANIFEST")
+		}
+	default:
+		return cfg, fmt.Errorf("invalid SAFELIST_MODE %q, expected off or strict", mode)
+	}
+
+	if raw := os.Getenv("MAX_QUERY_DEPTH"); raw != "" {
+		depth, err := strconv.Atoi(raw)
+		if err != nil || depth < 0 {
+			return cfg, fmt.Errorf("invalid MAX_QUERY_DEPTH %q", raw)
+		}
+		cfg.MaxQueryDepth = depth
+	}
+
+	if raw := os.Getenv("MAX_QUERY_COST"); raw != "" {
+		cost, err := strconv.Atoi(raw)
+		if err != nil || cost < 0 {
+			return cfg, fmt.Errorf("invalid MAX_QUERY_COST %q", raw)
+		}
+		cfg.MaxQueryCost = cost
+	}
+
+	if raw := os.Getenv("QUERY_FIELD_COSTS"); raw != "" {
+		costs, err := ParseFieldCosts(raw)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid QUERY_FIELD_COSTS: %w", err)
+		}
+		cfg.FieldCosts = costs
+	}
+
+	return cfg, nil
+}
+
+// ParseFieldCosts reads a comma separated list of Type.field=cost entries,
+// where the cost may name a list argument as in Query._entities=1:representations
+// and then the size used without it, as in Query.productsConnection=1:first|last:20
+func ParseFieldCosts(raw string) (map[string]FieldCost, error) {
+	costs := make(map[string]FieldCost)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.Contains(name, ".") {
+			return nil, fmt.Errorf("entry %q, expected Type.field=cost[:argument[:default size]]", entry)
+		}
+		rawCost, argument, _ := strings.Cut(value, ":")
+		cost, err := strconv.Atoi(rawCost)
+		if err != nil || cost < 0 {
+			return nil, fmt.Errorf("entry %q has an invalid cost", entry)
+		}
+		argument, rawSize, hasSize := strings.Cut(argument, ":")
+		size := 0
+		if hasSize {
+			if size, err = strconv.Atoi(rawSize); err != nil || size < 1 || argument == "" {
+				return nil, fmt.Errorf("entry %q has an invalid default size", entry)
+			}
+		}
+		costs[strings.TrimSpace(name)] = FieldCost{Cost: cost, ListArgument: argument, DefaultSize: size}
+	}
+	return costs, nil
+}
+
+// fieldCosts merges the configured costs over the service defaults
+func (c Config) fieldCosts(defaults map[string]FieldCost) map[string]FieldCost {
+	costs := make(map[string]FieldCost, len(defaults)+len(c.FieldCosts))
+	for name, cost := range defaults {
+		costs[name] = cost
+	}
+	for name, cost := range c.FieldCosts {
+		costs[name] = cost
+	}
+	return costs
+}
+
+// Install adds the operation policies to the GraphQL server, pricing the
+// fields with the service costs unless cfg overrides them
+func Install(srv *handler.Server, service string, costs map[string]FieldCost, cfg Config) error {
+	srv.Use(Limits{
+		Service:    service,
+		MaxDepth:   cfg.MaxQueryDepth,
+		MaxCost:    cfg.MaxQueryCost,
+		FieldCosts: cfg.fieldCosts(costs),
+	})
+
+	if cfg.Strict {
+		manifest, err := LoadManifest(cfg.SafelistManifest)
+		if err != nil {
+			return err
+		}
+		srv.Use(Safelist{Service: service, Manifest: manifest, FederationToken: cfg.FederationToken})
+		return nil
+	}
+
+	cache := lru.New[string](cfg.PersistedQueryCacheSize)
+	if cfg.SafelistManifest != "" {
+		// Outside strict mode the manifest only warms the persisted queries
+		manifest, err := LoadManifest(cfg.SafelistManifest)
+		if err != nil {
+			return err
+		}
+		for _, op := range manifest.Operations {
+			cache.Add(context.Background(), QueryHash(op.Body), op.Body)
+		}
+	}
+	srv.Use(PersistedQueries{Service: service, Cache: cache})
+	return nil
+}
@@ -0,0 +1,315 @@
+package extensions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// testSchema stands in for a service schema, with a list lookup, a
+// connection, a list argument with a default and the federation fields
+var testSchema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+	type Query {
+		item(id: ID!): Item
+		items(ids: [ID!]!): [Item]!
+		itemsConnection(first: Int, last: Int): ItemConnection!
+		search(query: String!, limit: Int = 20): [Item!]!
+		stats: Stats!
+		_service: _Service!
+	}
+	type Item {
+		id: ID!
+		name: String!
+		parent: Item
+	}
+	type ItemConnection {
+		edges: [ItemEdge!]!
+	}
+	type ItemEdge {
+		node: Item!
+	}
+	type Stats {
+		size: Int!
+	}
+	type _Service {
+		sdl: String
+	}
+`})
+
+// testCosts plays the role of a service cost table
+var testCosts = map[string]FieldCost{
+	"Query.items":           {Cost: 1, ListArgument: "ids"},
+	"Query.itemsConnection": {Cost: 1, ListArgument: "first|last", DefaultSize: 20},
+	"Query.search":          {Cost: 1, ListArgument: "limit"},
+}
+
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func newServer(t *testing.T, cfg Config) *httptest.Server {
+	t.Helper()
+
+	// As extensões agem antes da execução, então qualquer resposta serve
+	srv := handler.New(&graphql.ExecutableSchemaMock{
+		SchemaFunc: func() *ast.Schema { return testSchema },
+		ExecFunc: func(ctx context.Context) graphql.ResponseHandler {
+			return graphql.OneShot(&graphql.Response{Data: []byte(`{"ok":true}`)})
+		},
+	})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	if err := Install(srv, "test", testCosts, cfg); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	server := httptest.NewServer(srv)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func post(t *testing.T, server *httptest.Server, body map[string]interface{}) response {
+	t.Helper()
+	return postWithHeader(t, server, body, nil)
+}
+
+func postWithHeader(t *testing.T, server *httptest.Server, body map[string]interface{}, header http.Header) response {
+	t.Helper()
+
+	payload, _ := json.Marshal(body)
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(string(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header = header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var out response
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func persisted(hash string) map[string]interface{} {
+	return map[string]interface{}{
+		"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hash},
+	}
+}
+
+func errorCode(resp response) string {
+	if len(resp.Errors) == 0 {
+		return ""
+	}
+	code, _ := resp.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+func writeManifest(t *testing.T, queries ...string) string {
+	t.Helper()
+
+	manifest := Manifest{Format: "apollo-persisted-query-manifest", Version: 1}
+	for i, query := range queries {
+		manifest.Operations = append(manifest.Operations, ManifestOperation{
+			ID:   QueryHash(query),
+			Name: "Operation" + string(rune('A'+i)),
+			Type: "query",
+			Body: query,
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	data, _ := json.Marshal(manifest)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPersistedQueryHandshake(t *testing.T) {
+	server := newServer(t, Config{PersistedQueryCacheSize: 10})
+
+	query := `{ item(id: "1") { name } }`
+	hash := QueryHash(query)
+
+	// Unknown hash asks the client to send the full query
+	resp := post(t, server, map[string]interface{}{"extensions": persisted(hash)})
+	if code := errorCode(resp); code != errPersistedQueryNotFoundCode {
+		t.Fatalf("first request error code = %q, want %s", code, errPersistedQueryNotFoundCode)
+	}
+	if resp.Errors[0].Message != errPersistedQueryNotFound {
+		t.Errorf("message = %q, clients expect %s", resp.Errors[0].Message, errPersistedQueryNotFound)
+	}
+
+	// Registration
+	resp = post(t, server, map[string]interface{}{"query": query, "extensions": persisted(hash)})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("registration errors: %+v", resp.Errors)
+	}
+
+	// Hash only now resolves
+	resp = post(t, server, map[string]interface{}{"extensions": persisted(hash)})
+	if len(resp.Errors) > 0 || resp.Data["ok"] != true {
+		t.Fatalf("persisted request failed: %+v", resp)
+	}
+}
+
+func TestPersistedQueryHashMismatch(t *testing.T) {
+	server := newServer(t, Config{PersistedQueryCacheSize: 10})
+
+	resp := post(t, server, map[string]interface{}{
+		"query":      `{ items(ids: ["1"]) { id } }`,
+		"extensions": persisted(QueryHash(`{ items(ids: ["1"]) { name } }`)),
+	})
+	if code := errorCode(resp); code != errPersistedQueryInvalidCode {
+		t.Errorf("error code = %q, want %s", code, errPersistedQueryInvalidCode)
+	}
+}
+
+func TestPersistedQueryStoreIsBounded(t *testing.T) {
+	server := newServer(t, Config{PersistedQueryCacheSize: 1})
+
+	first := `{ item(id: "1") { name } }`
+	second := `{ item(id: "2") { name } }`
+	post(t, server, map[string]interface{}{"query": first, "extensions": persisted(QueryHash(first))})
+	post(t, server, map[string]interface{}{"query": second, "extensions": persisted(QueryHash(second))})
+
+	resp := post(t, server, map[string]interface{}{"extensions": persisted(QueryHash(first))})
+	if code := errorCode(resp); code != errPersistedQueryNotFoundCode {
+		t.Errorf("evicted query should need registration again, got code %q", code)
+	}
+}
+
+func TestSafelistStrictMode(t *testing.T) {
+	allowed := `{ items(ids: ["1"]) { id name } }`
+	server := newServer(t, Config{SafelistManifest: writeManifest(t, allowed), Strict: true})
+
+	tests := []struct {
+		name     string
+		body     map[string]interface{}
+		wantCode string
+	}{
+		{"registered query text", map[string]interface{}{"query": allowed}, ""},
+		{"registered hash only", map[string]interface{}{"extensions": persisted(QueryHash(allowed))}, ""},
+		{"unknown query", map[string]interface{}{"query": `{ stats { size } }`}, errOperationNotAllowedCode},
+		{"unknown hash", map[string]interface{}{"extensions": persisted(QueryHash("{ x }"))}, errOperationNotAllowedCode},
+		{"registration is disabled", map[string]interface{}{
+			"query":      `{ stats { size } }`,
+			"extensions": persisted(QueryHash(`{ stats { size } }`)),
+		}, errOperationNotAllowedCode},
+		{"federation query without a token", map[string]interface{}{"query": `query { _service { sdl } }`}, errOperationNotAllowedCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := post(t, server, tt.body)
+			if code := errorCode(resp); code != tt.wantCode {
+				t.Errorf("error code = %q, want %q (%+v)", code, tt.wantCode, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestSafelistFederationToken(t *testing.T) {
+	server := newServer(t, Config{
+		SafelistManifest: writeManifest(t, `{ items(ids: ["1"]) { id name } }`),
+		Strict:           true,
+		FederationToken:  "gateway-secret",
+	})
+	sdl := map[string]interface{}{"query": `query { _service { sdl } }`}
+
+	tests := []struct {
+		name     string
+		body     map[string]interface{}
+		token    string
+		wantCode string
+	}{
+		{"gateway token", sdl, "gateway-secret", ""},
+		{"no token", sdl, "", errOperationNotAllowedCode},
+		{"wrong token", sdl, "guess", errOperationNotAllowedCode},
+		// O token só libera operações de federação, não queries quaisquer
+		{"token on a regular query", map[string]interface{}{"query": `{ stats { size } }`}, "gateway-secret", errOperationNotAllowedCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.token != "" {
+				header.Set(FederationTokenHeader, tt.token)
+			}
+			resp := postWithHeader(t, server, tt.body, header)
+			if code := errorCode(resp); code != tt.wantCode {
+				t.Errorf("error code = %q, want %q (%+v)", code, tt.wantCode, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestLoadManifestRejectsWrongIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	data := `{"format":"apollo-persisted-query-manifest","version":1,"operations":[{"id":"abc","name":"A","type":"query","body":"{ stats { size } }"}]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadManifest(path); err == nil {
+		t.Error("LoadManifest() expected an error for a mismatched id")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("SAFELIST_MODE", "strict")
+	t.Setenv("SAFELIST_MANIFEST", "")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("strict mode without a manifest should fail")
+	}
+
+	t.Setenv("SAFELIST_MODE", "off")
+	t.Setenv("APQ_CACHE_SIZE", "25")
+	t.Setenv("FEDERATION_TOKEN", "")
+	cfg, err := ConfigFromEnv()
+	if err != nil || cfg.PersistedQueryCacheSize != 25 || cfg.Strict || cfg.FederationToken != "" {
+		t.Errorf("ConfigFromEnv() = %+v, %v", cfg, err)
+	}
+	if cfg.MaxQueryDepth != defaultMaxQueryDepth || cfg.MaxQueryCost != defaultMaxQueryCost {
+		t.Errorf("default limits = %d/%d", cfg.MaxQueryDepth, cfg.MaxQueryCost)
+	}
+
+	t.Setenv("MAX_QUERY_DEPTH", "4")
+	t.Setenv("MAX_QUERY_COST", "0")
+	t.Setenv("QUERY_FIELD_COSTS", "Query._entities=2:representations")
+	t.Setenv("FEDERATION_TOKEN", "gateway-secret")
+	cfg, err = ConfigFromEnv()
+	if err != nil || cfg.MaxQueryDepth != 4 || cfg.MaxQueryCost != 0 || cfg.FieldCosts["Query._entities"].Cost != 2 || cfg.FederationToken != "gateway-secret" {
+		t.Errorf("ConfigFromEnv() = %+v, %v", cfg, err)
+	}
+
+	t.Setenv("MAX_QUERY_DEPTH", "deep")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("invalid MAX_QUERY_DEPTH should fail")
+	}
+}
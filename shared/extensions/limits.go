@@ -0,0 +1,230 @@
+package extensions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	errQueryTooDeepCode    = "QUERY_TOO_DEEP"
+	errQueryTooComplexCode = "QUERY_TOO_COMPLEX"
+)
+
+// FieldCost is the cost of resolving a single field
+type FieldCost struct {
+	Cost int
+	// ListArgument names an argument that multiplies the cost of the field
+	// and its selections, by its length for lists or its value for integers.
+	// Alternatives are separated by "|", as in "first|last", and the
+	// largest one given is used.
+	ListArgument string
+	// DefaultSize is the multiplier when the operation sets none of the
+	// list arguments and the schema has no default for them, as for
+	// connections paginated with a default page size. 0 means 1.
+	DefaultSize int
+}
+
+// Limits rejects operations nested deeper or costing more than allowed,
+// before any resolver runs
+type Limits struct {
+	Service string
+	// MaxDepth is the deepest field nesting allowed, 0 disables the check
+	MaxDepth int
+	// MaxCost is the highest operation cost allowed, 0 disables the check
+	MaxCost int
+	// FieldCosts is keyed by "Type.field", fields missing from it cost 1
+	FieldCosts map[string]FieldCost
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = Limits{}
+
+// ExtensionName returns the gqlgen extension name
+func (l Limits) ExtensionName() string {
+	return "Limits"
+}
+
+// Validate checks the extension configuration
+func (l Limits) Validate(schema graphql.ExecutableSchema) error {
+	if l.MaxDepth < 0 || l.MaxCost < 0 {
+		return fmt.Errorf("query limits can not be negative")
+	}
+	for name, cost := range l.FieldCosts {
+		if cost.Cost < 0 || cost.DefaultSize < 0 {
+			return fmt.Errorf("negative cost for %s", name)
+		}
+	}
+	return nil
+}
+
+// MutateOperationContext measures the operation and rejects it when it is
+// over the configured limits
+func (l Limits) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if opCtx.Operation == nil {
+		return nil
+	}
+
+	if l.MaxDepth > 0 {
+		if depth := Depth(opCtx.Operation.SelectionSet); depth > l.MaxDepth {
+			return l.reject("depth", errQueryTooDeepCode, map[string]interface{}{"depth": depth, "maxDepth": l.MaxDepth},
+				"operation has depth %d, the maximum allowed is %d", depth, l.MaxDepth)
+		}
+	}
+
+	if l.MaxCost > 0 {
+		if cost := l.Cost(opCtx.Operation.SelectionSet, opCtx.Variables); cost > l.MaxCost {
+			return l.reject("complexity", errQueryTooComplexCode, map[string]interface{}{"cost": cost, "maxCost": l.MaxCost},
+				"operation has cost %d, the maximum allowed is %d", cost, l.MaxCost)
+		}
+	}
+	return nil
+}
+
+func (l Limits) reject(reason, code string, details map[string]interface{}, format string, args ...interface{}) *gqlerror.Error {
+	recordRejection(l.Service, reason)
+	err := gqlerror.Errorf(format, args...)
+	errcode.Set(err, code)
+	for key, value := range details {
+		err.Extensions[key] = value
+	}
+	return err
+}
+
+// Depth returns how deeply the fields of a selection set are nested.
+// Introspection fields are not counted.
+func Depth(set ast.SelectionSet) int {
+	deepest := 0
+	for _, field := range fields(set) {
+		if depth := 1 + Depth(field.SelectionSet); depth > deepest {
+			deepest = depth
+		}
+	}
+	return deepest
+}
+
+// Cost adds up the cost of every field in a selection set. A field costs
+// its own cost plus its selections, times the length of its list argument.
+func (l Limits) Cost(set ast.SelectionSet, variables map[string]interface{}) int {
+	total := 0
+	for _, field := range fields(set) {
+		cost := FieldCost{Cost: 1}
+		if field.ObjectDefinition != nil {
+			if configured, ok := l.FieldCosts[field.ObjectDefinition.Name+"."+field.Name]; ok {
+				cost = configured
+			}
+		}
+
+		fieldCost := saturatingAdd(cost.Cost, l.Cost(field.SelectionSet, variables))
+		if cost.ListArgument != "" {
+			fieldCost = saturatingMul(fieldCost, listSize(field, cost, variables))
+		}
+		total = saturatingAdd(total, fieldCost)
+	}
+	return total
+}
+
+// fields flattens fragments into the fields they select
+func fields(set ast.SelectionSet) []*ast.Field {
+	var out []*ast.Field
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if !strings.HasPrefix(sel.Name, "__") {
+				out = append(out, sel)
+			}
+		case *ast.InlineFragment:
+			out = append(out, fields(sel.SelectionSet)...)
+		case *ast.FragmentSpread:
+			if sel.Definition != nil {
+				out = append(out, fields(sel.Definition.SelectionSet)...)
+			}
+		}
+	}
+	return out
+}
+
+// listSize reads the multiplier of a field from the largest of its
+// "|" separated arguments, or its default size when none is given
+func listSize(field *ast.Field, cost FieldCost, variables map[string]interface{}) int {
+	size, given := 1, false
+	for _, name := range strings.Split(cost.ListArgument, "|") {
+		n, ok := argumentSize(field, name, variables)
+		if ok && n > size {
+			size = n
+		}
+		given = given || ok
+	}
+	if !given && cost.DefaultSize > size {
+		return cost.DefaultSize
+	}
+	return size
+}
+
+// argumentSize reads the length or integer value of an argument, or of
+// its default value when the operation does not set it. ok is false when
+// neither gives a value.
+func argumentSize(field *ast.Field, name string, variables map[string]interface{}) (size int, ok bool) {
+	var raw *ast.Value
+	if arg := field.Arguments.ForName(name); arg != nil {
+		raw = arg.Value
+	} else if field.Definition != nil {
+		if def := field.Definition.Arguments.ForName(name); def != nil {
+			raw = def.DefaultValue
+		}
+	}
+	if raw == nil {
+		return 1, false
+	}
+	value, err := raw.Value(variables)
+	if err != nil {
+		return 1, true
+	}
+	if value == nil {
+		return 1, false
+	}
+
+	size = 1
+	switch v := value.(type) {
+	case []interface{}:
+		size = len(v)
+	case int:
+		size = v
+	case int64:
+		size = int(v)
+	case float64:
+		size = int(v)
+	case json.Number:
+		if n, err := strconv.Atoi(string(v)); err == nil {
+			size = n
+		}
+	}
+	if size < 1 {
+		return 1, true
+	}
+	return size, true
+}
+
+func saturatingAdd(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+func saturatingMul(a, b int) int {
+	if a != 0 && b > math.MaxInt/a {
+		return math.MaxInt
+	}
+	return a * b
+}
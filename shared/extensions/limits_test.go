@@ -0,0 +1,174 @@
+package extensions
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func ids(n int) []interface{} {
+	out := make([]interface{}, n)
+	for i := range out {
+		out[i] = strconv.Itoa(i + 1)
+	}
+	return out
+}
+
+func TestLimitsCostScalesWithListLength(t *testing.T) {
+	server := newServer(t, Config{PersistedQueryCacheSize: 10, MaxQueryCost: 100})
+	query := `query ($ids: [ID!]!) { items(ids: $ids) { id name parent { id } } }`
+	rejected := testutil.ToFloat64(OperationRejections.WithLabelValues("test", "complexity"))
+
+	// (1 + 2 selections + parent 1 + id 1) * 10 ids
+	resp := post(t, server, map[string]interface{}{"query": query, "variables": map[string]interface{}{"ids": ids(10)}})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("10 ids should be accepted: %+v", resp.Errors)
+	}
+
+	resp = post(t, server, map[string]interface{}{"query": query, "variables": map[string]interface{}{"ids": ids(1000)}})
+	if code := errorCode(resp); code != errQueryTooComplexCode {
+		t.Fatalf("error code = %q, want %s", code, errQueryTooComplexCode)
+	}
+	if cost := resp.Errors[0].Extensions["cost"]; cost != float64(5000) {
+		t.Errorf("reported cost = %v, want 5000", cost)
+	}
+	if got := testutil.ToFloat64(OperationRejections.WithLabelValues("test", "complexity")); got != rejected+1 {
+		t.Errorf("complexity rejections = %v, want %v", got, rejected+1)
+	}
+}
+
+func TestLimitsInlineListArgument(t *testing.T) {
+	server := newServer(t, Config{PersistedQueryCacheSize: 10, MaxQueryCost: 10})
+
+	resp := post(t, server, map[string]interface{}{"query": `{ items(ids: ["1", "2", "3", "4", "5", "6"]) { id } }`})
+	if code := errorCode(resp); code != errQueryTooComplexCode {
+		t.Errorf("error code = %q, want %s", code, errQueryTooComplexCode)
+	}
+}
+
+func TestLimitsConnectionScalesWithPageSize(t *testing.T) {
+	server := newServer(t, Config{PersistedQueryCacheSize: 10, MaxQueryCost: 100})
+
+	// (1 + edges 1 + node 1 + id 1) * page size
+	resp := post(t, server, map[string]interface{}{"query": `{ itemsConnection(first: 20) { edges { node { id } } } }`})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("a page of 20 should be accepted: %+v", resp.Errors)
+	}
+
+	resp = post(t, server, map[string]interface{}{"query": `{ itemsConnection(first: 5, last: 50) { edges { node { id } } } }`})
+	if code := errorCode(resp); code != errQueryTooComplexCode {
+		t.Fatalf("error code = %q, want %s", code, errQueryTooComplexCode)
+	}
+	if cost := resp.Errors[0].Extensions["cost"]; cost != float64(200) {
+		t.Errorf("reported cost = %v, want 200", cost)
+	}
+}
+
+func TestLimitsUseDefaultSizeWithoutListArguments(t *testing.T) {
+	server := newServer(t, Config{PersistedQueryCacheSize: 10, MaxQueryCost: 60})
+
+	// Sem first nem last a conexão devolve a página padrão de 20
+	resp := post(t, server, map[string]interface{}{"query": `{ itemsConnection { edges { node { id } } } }`})
+	if code := errorCode(resp); code != errQueryTooComplexCode {
+		t.Fatalf("error code = %q, want %s", code, errQueryTooComplexCode)
+	}
+	if cost := resp.Errors[0].Extensions["cost"]; cost != float64(80) {
+		t.Errorf("reported cost = %v, want 80", cost)
+	}
+
+	// Um argumento informado, mesmo menor, prevalece sobre o padrão
+	resp = post(t, server, map[string]interface{}{
+		"query":     `query ($first: Int) { itemsConnection(first: $first) { edges { node { id } } } }`,
+		"variables": map[string]interface{}{"first": 5},
+	})
+	if len(resp.Errors) > 0 {
+		t.Errorf("first 5 should be accepted: %+v", resp.Errors)
+	}
+}
+
+func TestLimitsUseArgumentDefaults(t *testing.T) {
+	server := newServer(t, Config{PersistedQueryCacheSize: 10, MaxQueryCost: 30})
+
+	// (1 + id) * limit, which defaults to 20
+	resp := post(t, server, map[string]interface{}{"query": `{ search(query: "x") { id } }`})
+	if code := errorCode(resp); code != errQueryTooComplexCode {
+		t.Fatalf("error code = %q, want %s", code, errQueryTooComplexCode)
+	}
+	if cost := resp.Errors[0].Extensions["cost"]; cost != float64(40) {
+		t.Errorf("reported cost = %v, want 40", cost)
+	}
+
+	resp = post(t, server, map[string]interface{}{"query": `{ search(query: "x", limit: 5) { id } }`})
+	if len(resp.Errors) > 0 {
+		t.Errorf("limit 5 should be accepted: %+v", resp.Errors)
+	}
+}
+
+func TestLimitsDepth(t *testing.T) {
+	server := newServer(t, Config{PersistedQueryCacheSize: 10, MaxQueryDepth: 2})
+
+	resp := post(t, server, map[string]interface{}{"query": `{ item(id: "1") { id } }`})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("depth 2 should be accepted: %+v", resp.Errors)
+	}
+
+	resp = post(t, server, map[string]interface{}{
+		"query": `{ item(id: "1") { ...withParent } } fragment withParent on Item { parent { id } }`,
+	})
+	if code := errorCode(resp); code != errQueryTooDeepCode {
+		t.Errorf("error code = %q, want %s", code, errQueryTooDeepCode)
+	}
+	if depth := resp.Errors[0].Extensions["depth"]; depth != float64(3) {
+		t.Errorf("reported depth = %v, want 3", depth)
+	}
+}
+
+func TestLimitsIgnoreIntrospection(t *testing.T) {
+	server := newServer(t, Config{PersistedQueryCacheSize: 10, MaxQueryDepth: 1, MaxQueryCost: 1})
+
+	resp := post(t, server, map[string]interface{}{"query": `{ __schema { types { fields { type { ofType { name } } } } } }`})
+	if len(resp.Errors) > 0 {
+		t.Errorf("introspection should not count towards the limits: %+v", resp.Errors)
+	}
+}
+
+func TestParseFieldCosts(t *testing.T) {
+	costs, err := ParseFieldCosts("Query.items=5:ids, Item.parent=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := costs["Query.items"]; got != (FieldCost{Cost: 5, ListArgument: "ids"}) {
+		t.Errorf("items cost = %+v", got)
+	}
+	if got := costs["Item.parent"]; got != (FieldCost{Cost: 3}) {
+		t.Errorf("parent cost = %+v", got)
+	}
+
+	costs, err = ParseFieldCosts("Query.itemsConnection=2:first|last:20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := costs["Query.itemsConnection"]; got != (FieldCost{Cost: 2, ListArgument: "first|last", DefaultSize: 20}) {
+		t.Errorf("itemsConnection cost = %+v", got)
+	}
+
+	for _, raw := range []string{"items=1", "Query.items=x", "Query.items=-1", "Query.items=1:ids:0", "Query.items=1::5"} {
+		if _, err := ParseFieldCosts(raw); err == nil {
+			t.Errorf("ParseFieldCosts(%q) expected an error", raw)
+		}
+	}
+}
+
+func TestConfiguredFieldCostOverridesDefault(t *testing.T) {
+	server := newServer(t, Config{
+		PersistedQueryCacheSize: 10,
+		MaxQueryCost:            50,
+		FieldCosts:              map[string]FieldCost{"Query.items": {Cost: 100}},
+	})
+
+	resp := post(t, server, map[string]interface{}{"query": `{ items(ids: ["1"]) { id } }`})
+	if code := errorCode(resp); code != errQueryTooComplexCode {
+		t.Errorf("error code = %q, want %s", code, errQueryTooComplexCode)
+	}
+}
@@ -0,0 +1,36 @@
+package extensions
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The operation policy metrics live with the extensions so they are
+// registered once, whichever services install them
+var (
+	OperationRejections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "graphql_operation_rejections_total",
+			Help: "Total of GraphQL operations rejected before execution by service and reason",
+		},
+		[]string{"service", "reason"},
+	)
+
+	PersistedQueryLookups = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "graphql_persisted_queries_total",
+			Help: "Total of automatic persisted query lookups by service and result",
+		},
+		[]string{"service", "result"},
+	)
+)
+
+// recordRejection - Record an operation rejected before execution
+func recordRejection(serviceName, reason string) {
+	OperationRejections.WithLabelValues(serviceName, reason).Inc()
+}
+
+// recordPersistedQuery - Record a persisted query lookup (hit, miss or registered)
+func recordPersistedQuery(serviceName, result string) {
+	PersistedQueryLookups.WithLabelValues(serviceName, result).Inc()
+}
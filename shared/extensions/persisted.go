@@ -0,0 +1,112 @@
+package extensions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	errPersistedQueryNotFound     = "PersistedQueryNotFound"
+	errPersistedQueryNotFoundCode = "PERSISTED_QUERY_NOT_FOUND"
+	errPersistedQueryInvalidCode  = "PERSISTED_QUERY_INVALID"
+)
+
+// PersistedQueries implements automatic persisted queries: clients send the
+// sha256 of the query, and the full text only when the service answers
+// PersistedQueryNotFound. Queries are kept in a bounded store.
+type PersistedQueries struct {
+	Service string
+	Cache   graphql.Cache[string]
+}
+
+var _ interface {
+	graphql.OperationParameterMutator
+	graphql.HandlerExtension
+} = PersistedQueries{}
+
+// ExtensionName returns the gqlgen extension name
+func (p PersistedQueries) ExtensionName() string {
+	return "PersistedQueries"
+}
+
+// Validate checks the extension configuration
+func (p PersistedQueries) Validate(schema graphql.ExecutableSchema) error {
+	if p.Cache == nil {
+		return errMissingCache
+	}
+	return nil
+}
+
+// MutateOperationParameters resolves the query from its hash, or
+// registers it when the client sends both the hash and the query
+func (p PersistedQueries) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	hash, version, ok := persistedQuery(rawParams)
+	if !ok {
+		return nil
+	}
+
+	if version != 1 {
+		return p.reject("apq_unsupported_version", "unsupported persisted query version")
+	}
+
+	if rawParams.Query == "" {
+		query, found := p.Cache.Get(ctx, hash)
+		if !found {
+			recordPersistedQuery(p.Service, "miss")
+			err := gqlerror.Errorf(errPersistedQueryNotFound)
+			errcode.Set(err, errPersistedQueryNotFoundCode)
+			return err
+		}
+		recordPersistedQuery(p.Service, "hit")
+		rawParams.Query = query
+		return nil
+	}
+
+	if QueryHash(rawParams.Query) != hash {
+		return p.reject("apq_hash_mismatch", "provided persisted query hash does not match query")
+	}
+	p.Cache.Add(ctx, hash, rawParams.Query)
+	recordPersistedQuery(p.Service, "registered")
+	return nil
+}
+
+func (p PersistedQueries) reject(reason, message string) *gqlerror.Error {
+	recordRejection(p.Service, reason)
+	err := gqlerror.Errorf("%s", message)
+	errcode.Set(err, errPersistedQueryInvalidCode)
+	return err
+}
+
+// persistedQuery reads the persistedQuery request extension
+func persistedQuery(rawParams *graphql.RawParams) (hash string, version int, ok bool) {
+	ext, ok := rawParams.Extensions["persistedQuery"].(map[string]interface{})
+	if !ok {
+		return "", 0, false
+	}
+
+	hash, _ = ext["sha256Hash"].(string)
+	switch v := ext["version"].(type) {
+	case json.Number:
+		n, _ := v.Int64()
+		version = int(n)
+	case float64:
+		version = int(v)
+	case int:
+		version = v
+	case int64:
+		version = int(v)
+	}
+	return hash, version, true
+}
+
+// QueryHash returns the hex encoded sha256 of a query, as sent by clients
+func QueryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
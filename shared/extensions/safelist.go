@@ -0,0 +1,169 @@
+package extensions
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+const errOperationNotAllowedCode = "OPERATION_NOT_IN_SAFELIST"
+
+// FederationTokenHeader carries the token the gateway proves itself with
+const FederationTokenHeader = "X-Federation-Token"
+
+// ManifestOperation is a pre-registered operation
+type ManifestOperation struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Body string `json:"body"`
+}
+
+// Manifest is a persisted query manifest, in the format produced by the
+// Apollo tooling: {"format", "version", "operations": [{id, name, type, body}]}
+type Manifest struct {
+	Format     string              `json:"format"`
+	Version    int                 `json:"version"`
+	Operations []ManifestOperation `json:"operations"`
+
+	byHash map[string]string
+}
+
+// LoadManifest reads a manifest file and checks every operation id is the
+// sha256 of its body
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("reading manifest %s: %w", path, err)
+	}
+
+	manifest.byHash = make(map[string]string, len(manifest.Operations))
+	for _, op := range manifest.Operations {
+		hash := QueryHash(op.Body)
+		if op.ID != "" && op.ID != hash {
+			return nil, fmt.Errorf("manifest operation %q: id %s is not the sha256 of its body", op.Name, op.ID)
+		}
+		manifest.byHash[hash] = op.Body
+	}
+	return &manifest, nil
+}
+
+// Lookup returns the body of the operation registered under hash
+func (m *Manifest) Lookup(hash string) (string, bool) {
+	body, ok := m.byHash[hash]
+	return body, ok
+}
+
+// Safelist only lets pre-registered operations run. Clients can send the
+// operation hash alone, the full text of a registered operation, or both.
+type Safelist struct {
+	Service  string
+	Manifest *Manifest
+	// FederationToken lets requests sending it in FederationTokenHeader
+	// run _service and _entities operations, which the gateway builds per
+	// request and cannot register. Empty keeps them out of the safelist.
+	FederationToken string
+}
+
+var _ interface {
+	graphql.OperationParameterMutator
+	graphql.HandlerExtension
+} = Safelist{}
+
+// ExtensionName returns the gqlgen extension name
+func (s Safelist) ExtensionName() string {
+	return "Safelist"
+}
+
+// Validate checks the extension configuration
+func (s Safelist) Validate(schema graphql.ExecutableSchema) error {
+	if s.Manifest == nil {
+		return errMissingManifest
+	}
+	return nil
+}
+
+// MutateOperationParameters rejects operations missing from the manifest
+func (s Safelist) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	hash, _, hasHash := persistedQuery(rawParams)
+
+	if rawParams.Query == "" {
+		if !hasHash {
+			return nil
+		}
+		body, ok := s.Manifest.Lookup(hash)
+		if !ok {
+			return s.reject("operation %s is not in the safelist", hash)
+		}
+		rawParams.Query = body
+		return nil
+	}
+
+	queryHash := QueryHash(rawParams.Query)
+	if hasHash && hash != queryHash {
+		return s.reject("provided persisted query hash does not match query")
+	}
+	if _, ok := s.Manifest.Lookup(queryHash); ok {
+		return nil
+	}
+	if s.fromGateway(rawParams) && isFederationOperation(rawParams.Query) {
+		return nil
+	}
+	return s.reject("operation is not in the safelist")
+}
+
+// fromGateway reports whether the request carries the federation token
+func (s Safelist) fromGateway(rawParams *graphql.RawParams) bool {
+	if s.FederationToken == "" {
+		return false
+	}
+	token := rawParams.Headers.Get(FederationTokenHeader)
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.FederationToken)) == 1
+}
+
+func (s Safelist) reject(format string, args ...interface{}) *gqlerror.Error {
+	recordRejection(s.Service, "safelist")
+	err := gqlerror.Errorf(format, args...)
+	errcode.Set(err, errOperationNotAllowedCode)
+	return err
+}
+
+// isFederationOperation reports whether a query only selects the root
+// fields the gateway uses to compose and resolve entities
+func isFederationOperation(query string) bool {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil || len(doc.Operations) == 0 {
+		return false
+	}
+
+	for _, op := range doc.Operations {
+		if op.Operation != ast.Query {
+			return false
+		}
+		for _, sel := range op.SelectionSet {
+			field, ok := sel.(*ast.Field)
+			if !ok {
+				return false
+			}
+			switch field.Name {
+			case "_service", "_entities", "__typename":
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+// Package fanout runs one task per input with bounded concurrency and
+// returns the results in input order. It is shared by the users and
+// products services.
+package fanout
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Mode decides what a failed task does to the other tasks of a fan-out
+type Mode int
+
+const (
+	// CollectAll runs every task and keeps the error of each one
+	CollectAll Mode = iota
+	// FailFast cancels the tasks still running or waiting for a slot at the
+	// first fatal error
+	FailFast
+)
+
+// Outcomes of a task, as recorded in fanout_task_duration_seconds
+const (
+	OutcomeOK       = "ok"
+	OutcomeError    = "error"
+	OutcomeCanceled = "canceled"
+)
+
+// Limiter bounds how many tasks run at once. Sharing one between
+// fan-outs makes them share a single limit.
+type Limiter interface {
+	Acquire(ctx context.Context) error
+	Release()
+	// MaxCount is how many tasks the limiter lets run at once, Run starts
+	// no more workers than that
+	MaxCount() int
+}
+
+// Options configures a fan-out
+type Options struct {
+	// Service and Name label the latency metrics, Name is usually the
+	// resolver field
+	Service string
+	Name    string
+	// Concurrency is how many tasks may run at once, 0 for no limit. It is
+	// ignored when Limiter is set, which bounds the workers by its MaxCount.
+	Concurrency int
+	Limiter     Limiter
+	Mode        Mode
+	// Fatal reports whether an error stops a FailFast fan-out. By default
+	// every error does.
+	Fatal func(error) bool
+}
+
+// Result is the outcome of one task
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Run calls task for every input concurrently and returns the results in
+// the order of inputs, whatever order the tasks finish in. The tasks run on
+// a pool of workers sized by the concurrency limit, so a long input list
+// does not park one goroutine per input. In FailFast mode the first fatal
+// error cancels the context of the other tasks and is returned; in
+// CollectAll mode the error is always nil and the errors are only in the
+// results.
+func Run[In, Out any](ctx context.Context, opts Options, inputs []In, task func(ctx context.Context, in In) (Out, error)) ([]Result[Out], error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	workers := len(inputs)
+	switch {
+	case opts.Limiter != nil:
+		workers = min(workers, max(opts.Limiter.MaxCount(), 1))
+	case opts.Concurrency > 0:
+		workers = min(workers, opts.Concurrency)
+	}
+
+	// Todos os índices já estão no canal, os workers só leem até ele fechar
+	indexes := make(chan int, len(inputs))
+	for i := range inputs {
+		indexes <- i
+	}
+	close(indexes)
+
+	results := make([]Result[Out], len(inputs))
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		fatal error
+	)
+	// A latência conta desde a criação do fan-out, incluindo a espera na fila
+	start := time.Now()
+	// finish stores the result of a task and, in FailFast mode, cancels the
+	// other tasks when it is the first fatal error
+	finish := func(index int, result Result[Out]) {
+		results[index] = result
+		recordTask(opts.Service, opts.Name, outcome(result.Err), time.Since(start))
+
+		if result.Err != nil && opts.Mode == FailFast && (opts.Fatal == nil || opts.Fatal(result.Err)) {
+			once.Do(func() {
+				fatal = result.Err
+				cancel(result.Err)
+			})
+		}
+	}
+	// runTask runs the task of one input, holding a slot of the limiter
+	runTask := func(index int) {
+		if opts.Limiter != nil {
+			if err := opts.Limiter.Acquire(ctx); err != nil {
+				finish(index, Result[Out]{Err: err})
+				return
+			}
+			// O slot só é liberado depois de um eventual cancelamento, então
+			// nenhuma tarefa na fila começa depois de uma falha fatal
+			defer opts.Limiter.Release()
+		}
+		if err := ctx.Err(); err != nil {
+			finish(index, Result[Out]{Err: err})
+			return
+		}
+		value, err := task(ctx, inputs[index])
+		finish(index, Result[Out]{Value: value, Err: err})
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				runTask(index)
+			}
+		}()
+	}
+	wg.Wait()
+	return results, fatal
+}
+
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return OutcomeCanceled
+	}
+	return OutcomeError
+}
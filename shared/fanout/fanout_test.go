@@ -0,0 +1,251 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var errBoom = errors.New("boom")
+
+// sleepy returns its input doubled after sleeping as many milliseconds
+func sleepy(ctx context.Context, ms int) (int, error) {
+	select {
+	case <-time.After(time.Duration(ms) * time.Millisecond):
+		return ms * 2, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func TestRunKeepsInputOrder(t *testing.T) {
+	// Os primeiros itens terminam por último
+	inputs := []int{50, 40, 30, 20, 10}
+
+	results, err := Run(context.Background(), Options{Service: "test", Name: "order"}, inputs, sleepy)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for i, result := range results {
+		if result.Err != nil || result.Value != inputs[i]*2 {
+			t.Errorf("results[%d] = %+v, want %d", i, result, inputs[i]*2)
+		}
+	}
+}
+
+func TestRunLimitsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	task := func(ctx context.Context, _ int) (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return 0, nil
+	}
+
+	start := time.Now()
+	if _, err := Run(context.Background(), Options{Service: "test", Name: "limit", Concurrency: 2}, make([]int, 6), task); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if peak.Load() != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak.Load())
+	}
+	// 6 tarefas de 20ms, 2 por vez, levam 3 rodadas
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("Run() took %v, want at least 60ms", elapsed)
+	}
+}
+
+// countingLimiter is a Limiter of one slot that counts its acquires
+type countingLimiter struct {
+	slot     chan struct{}
+	acquired atomic.Int32
+}
+
+func (l *countingLimiter) Acquire(ctx context.Context) error {
+	select {
+	case l.slot <- struct{}{}:
+		l.acquired.Add(1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *countingLimiter) Release() { <-l.slot }
+
+func (l *countingLimiter) MaxCount() int { return cap(l.slot) }
+
+func TestRunUsesLimiter(t *testing.T) {
+	limiter := &countingLimiter{slot: make(chan struct{}, 1)}
+
+	_, err := Run(context.Background(), Options{Service: "test", Name: "limiter", Concurrency: 10, Limiter: limiter}, []int{1, 1, 1}, sleepy)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if limiter.acquired.Load() != 3 {
+		t.Errorf("limiter acquired %d times, want 3", limiter.acquired.Load())
+	}
+}
+
+func TestRunBoundsWorkers(t *testing.T) {
+	block := make(chan struct{})
+	task := func(ctx context.Context, _ int) (int, error) {
+		<-block
+		return 0, nil
+	}
+
+	before := runtime.NumGoroutine()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(context.Background(), Options{Service: "test", Name: "workers", Concurrency: 4}, make([]int, 10000), task)
+	}()
+	// Enquanto as tarefas esperam, só os 4 workers (e o Run) estão vivos
+	time.Sleep(50 * time.Millisecond)
+	if extra := runtime.NumGoroutine() - before; extra > 10 {
+		t.Errorf("%d goroutines started for 4 workers", extra)
+	}
+	close(block)
+	<-done
+}
+
+func TestRunCollectAll(t *testing.T) {
+	task := func(ctx context.Context, n int) (int, error) {
+		if n%2 == 0 {
+			return 0, fmt.Errorf("even %d: %w", n, errBoom)
+		}
+		return sleepy(ctx, n)
+	}
+
+	results, err := Run(context.Background(), Options{Service: "test", Name: "collect", Mode: CollectAll}, []int{1, 2, 3, 4}, task)
+	if err != nil {
+		t.Fatalf("Run() error = %v, want nil in CollectAll mode", err)
+	}
+	for i, result := range results {
+		failed := (i+1)%2 == 0
+		if failed != errors.Is(result.Err, errBoom) {
+			t.Errorf("results[%d].Err = %v", i, result.Err)
+		}
+		if !failed && result.Value != (i+1)*2 {
+			t.Errorf("results[%d].Value = %d, want %d", i, result.Value, (i+1)*2)
+		}
+	}
+}
+
+func TestRunFailFastCancelsSiblings(t *testing.T) {
+	task := func(ctx context.Context, n int) (int, error) {
+		if n == 0 {
+			return 0, errBoom
+		}
+		return sleepy(ctx, n)
+	}
+
+	start := time.Now()
+	results, err := Run(context.Background(), Options{Service: "test", Name: "failfast", Mode: FailFast}, []int{1000, 0, 1000, 1000}, task)
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("Run() error = %v, want %v", err, errBoom)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Run() took %v, want the siblings canceled", elapsed)
+	}
+	for _, i := range []int{0, 2, 3} {
+		if !errors.Is(results[i].Err, context.Canceled) {
+			t.Errorf("results[%d].Err = %v, want context.Canceled", i, results[i].Err)
+		}
+	}
+}
+
+func TestRunFailFastSkipsWaitingTasks(t *testing.T) {
+	var calls atomic.Int32
+	task := func(ctx context.Context, _ int) (int, error) {
+		calls.Add(1)
+		return 0, errBoom
+	}
+
+	// Com um slot só, a primeira falha cancela as tarefas que ainda esperam
+	results, err := Run(context.Background(), Options{Service: "test", Name: "skip", Mode: FailFast, Concurrency: 1}, make([]int, 5), task)
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("Run() error = %v, want %v", err, errBoom)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("task ran %d times, want 1", calls.Load())
+	}
+	canceled := 0
+	for _, result := range results {
+		if errors.Is(result.Err, context.Canceled) {
+			canceled++
+		}
+	}
+	if canceled != 4 {
+		t.Errorf("%d results canceled, want 4", canceled)
+	}
+}
+
+func TestRunFailFastIgnoresNonFatalErrors(t *testing.T) {
+	task := func(ctx context.Context, n int) (int, error) {
+		if n == 0 {
+			return 0, errBoom
+		}
+		return sleepy(ctx, n)
+	}
+	fatal := func(err error) bool { return !errors.Is(err, errBoom) }
+
+	results, err := Run(context.Background(), Options{Service: "test", Name: "nonfatal", Mode: FailFast, Fatal: fatal}, []int{0, 10}, task)
+	if err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+	if !errors.Is(results[0].Err, errBoom) || results[1].Value != 20 {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+func TestRunRecordsTaskLatency(t *testing.T) {
+	task := func(ctx context.Context, n int) (int, error) {
+		switch n {
+		case 1:
+			return 0, errBoom
+		case 2:
+			return 0, context.DeadlineExceeded
+		}
+		return n, nil
+	}
+	before := map[string]float64{}
+	for _, outcome := range []string{OutcomeOK, OutcomeError, OutcomeCanceled} {
+		before[outcome] = sampleCount(t, outcome)
+	}
+
+	if _, err := Run(context.Background(), Options{Service: "test", Name: "latency"}, []int{0, 0, 1, 2}, task); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for outcome, want := range map[string]float64{OutcomeOK: 2, OutcomeError: 1, OutcomeCanceled: 1} {
+		if got := sampleCount(t, outcome) - before[outcome]; got != want {
+			t.Errorf("%s tasks recorded = %v, want %v", outcome, got, want)
+		}
+	}
+}
+
+// sampleCount returns how many tasks of the latency fan-out were recorded
+// with outcome
+func sampleCount(t *testing.T, outcome string) float64 {
+	t.Helper()
+
+	var m dto.Metric
+	observer := TaskDuration.WithLabelValues("test", "latency", outcome)
+	if err := observer.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	return float64(m.GetHistogram().GetSampleCount())
+}
@@ -0,0 +1,24 @@
+package fanout
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// TaskDuration lives here instead of each service's metrics package so the
+// histogram is registered once, whichever services import fanout
+var TaskDuration = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "fanout_task_duration_seconds",
+		Help:    "Duration of concurrent resolver tasks in seconds, until their result is known, by service, fan-out and outcome",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"service", "fanout", "outcome"},
+)
+
+// recordTask - Record the latency of a fan-out task and its outcome (ok, error or canceled)
+func recordTask(serviceName, fanout, outcome string, duration time.Duration) {
+	TaskDuration.WithLabelValues(serviceName, fanout, outcome).Observe(duration.Seconds())
+}
@@ -0,0 +1,247 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultPageSize is used when neither first nor last is given
+	DefaultPageSize = 20
+	// MaxPageSize bounds first and last
+	MaxPageSize = 100
+
+	cursorPrefix       = "cursor:"
+	sortedCursorPrefix = "sorted:"
+)
+
+var (
+	// ErrInvalidCursor is returned for cursors this package did not issue
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidPageSize is returned when first or last is out of range
+	ErrInvalidPageSize = errors.New("invalid page size")
+)
+
+// Args are the Relay connection arguments
+type Args struct {
+	First  *int
+	After  *string
+	Last   *int
+	Before *string
+}
+
+// Page is a slice of a connection along with its page info
+type Page[T any] struct {
+	Items           []T
+	Cursors         []string
+	HasNextPage     bool
+	HasPreviousPage bool
+	TotalCount      int
+}
+
+// StartCursor returns the cursor of the first item, nil on an empty page
+func (p *Page[T]) StartCursor() *string {
+	if len(p.Cursors) == 0 {
+		return nil
+	}
+	return &p.Cursors[0]
+}
+
+// EndCursor returns the cursor of the last item, nil on an empty page
+func (p *Page[T]) EndCursor() *string {
+	if len(p.Cursors) == 0 {
+		return nil
+	}
+	return &p.Cursors[len(p.Cursors)-1]
+}
+
+// Cursor is the position a cursor points at. Connections ordered by ID
+// only need the ID; sorted connections also keep the order the cursor was
+// issued for and the sort value of the record, so the position holds even
+// if the record changes or is removed.
+type Cursor struct {
+	Order string `json:"order"`
+	Value string `json:"value"`
+	ID    string `json:"id"`
+}
+
+// Encode returns the opaque form of the cursor
+func (c Cursor) Encode() string {
+	if c.Order == "" {
+		return EncodeCursor(c.ID)
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(append([]byte(sortedCursorPrefix), raw...))
+}
+
+// ParseCursor decodes a cursor issued by EncodeCursor or Cursor.Encode
+func ParseCursor(cursor string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w %q", ErrInvalidCursor, cursor)
+	}
+
+	if id, ok := strings.CutPrefix(string(raw), cursorPrefix); ok {
+		return Cursor{ID: id}, nil
+	}
+	if data, ok := strings.CutPrefix(string(raw), sortedCursorPrefix); ok {
+		var c Cursor
+		if err := json.Unmarshal([]byte(data), &c); err == nil && c.Order != "" {
+			return c, nil
+		}
+	}
+	return Cursor{}, fmt.Errorf("%w %q", ErrInvalidCursor, cursor)
+}
+
+// EncodeCursor builds the opaque cursor of the record with id
+func EncodeCursor(id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + id))
+}
+
+// DecodeCursor returns the record ID a cursor of a connection ordered by
+// ID points at
+func DecodeCursor(cursor string) (string, error) {
+	c, err := ParseCursor(cursor)
+	if err != nil {
+		return "", err
+	}
+	if c.Order != "" {
+		return "", fmt.Errorf("%w %q: issued for the %s order", ErrInvalidCursor, cursor, c.Order)
+	}
+	return c.ID, nil
+}
+
+// Order is the order of a connection sorted by something other than the ID
+type Order[T any] struct {
+	// Name identifies the order in cursors, so a cursor issued for another
+	// order is rejected instead of landing on an unrelated position
+	Name string
+	// Value returns the sort value of an item as kept in its cursor
+	Value func(T) string
+	// At rebuilds the item a cursor points at from its sort value and ID
+	At func(value, id string) (T, error)
+	// Compare orders two items the way the paginated items are sorted, ties
+	// broken by ID
+	Compare func(a, b T) int
+}
+
+// CompareIDs orders numeric IDs by value and anything else as strings
+func CompareIDs(a, b string) int {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return x - y
+	}
+	return strings.Compare(a, b)
+}
+
+// Paginate applies the Relay pagination algorithm to items, which must be
+// sorted by ID. Cursors hold the ID rather than a position, so records
+// inserted or removed while a client pages through do not shift the pages
+// it has not read yet.
+func Paginate[T any](items []T, id func(T) string, args Args) (*Page[T], error) {
+	return PaginateBy(items, id, nil, args)
+}
+
+// PaginateBy paginates items sorted by order, or by ID when order is nil.
+// Cursors hold the sort value and the ID of their item, which keeps pages
+// stable the same way Paginate does.
+func PaginateBy[T any](items []T, id func(T) string, order *Order[T], args Args) (*Page[T], error) {
+	first, last := args.First, args.Last
+	if err := checkSize("first", first); err != nil {
+		return nil, err
+	}
+	if err := checkSize("last", last); err != nil {
+		return nil, err
+	}
+	if first == nil && last == nil {
+		size := DefaultPageSize
+		first = &size
+	}
+
+	start, end := 0, len(items)
+	if args.After != nil {
+		after, err := cursorPosition(*args.After, id, order)
+		if err != nil {
+			return nil, err
+		}
+		for start < end && after(items[start]) <= 0 {
+			start++
+		}
+	}
+	if args.Before != nil {
+		before, err := cursorPosition(*args.Before, id, order)
+		if err != nil {
+			return nil, err
+		}
+		for end > start && before(items[end-1]) >= 0 {
+			end--
+		}
+	}
+
+	page := &Page[T]{
+		TotalCount: len(items),
+		// Records outside the cursors are known to exist
+		HasPreviousPage: start > 0,
+		HasNextPage:     end < len(items),
+	}
+
+	if first != nil && end-start > *first {
+		end = start + *first
+		page.HasNextPage = true
+	}
+	if last != nil && end-start > *last {
+		start = end - *last
+		page.HasPreviousPage = true
+	}
+
+	page.Items = items[start:end]
+	page.Cursors = make([]string, len(page.Items))
+	for i, item := range page.Items {
+		c := Cursor{ID: id(item)}
+		if order != nil {
+			c.Order, c.Value = order.Name, order.Value(item)
+		}
+		page.Cursors[i] = c.Encode()
+	}
+	return page, nil
+}
+
+// cursorPosition decodes cursor and returns a function comparing an item
+// with the position it points at
+func cursorPosition[T any](cursor string, id func(T) string, order *Order[T]) (func(T) int, error) {
+	if order == nil {
+		at, err := DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		return func(item T) int { return CompareIDs(id(item), at) }, nil
+	}
+
+	c, err := ParseCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if c.Order != order.Name {
+		return nil, fmt.Errorf("%w %q: not issued for the %s order", ErrInvalidCursor, cursor, order.Name)
+	}
+	at, err := order.At(c.Value, c.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidCursor, cursor, err)
+	}
+	return func(item T) int { return order.Compare(item, at) }, nil
+}
+
+func checkSize(name string, size *int) error {
+	if size == nil {
+		return nil
+	}
+	if *size < 0 || *size > MaxPageSize {
+		return fmt.Errorf("%w: %s must be between 0 and %d, got %d", ErrInvalidPageSize, name, MaxPageSize, *size)
+	}
+	return nil
+}
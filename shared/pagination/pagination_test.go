@@ -0,0 +1,191 @@
+package pagination
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func ids(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = strconv.Itoa(i + 1)
+	}
+	return out
+}
+
+func identity(id string) string { return id }
+
+func intPtr(n int) *int { return &n }
+
+func strPtr(s string) *string { return &s }
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := EncodeCursor("42")
+	if cursor == "42" {
+		t.Error("cursor should be opaque")
+	}
+	id, err := DecodeCursor(cursor)
+	if err != nil || id != "42" {
+		t.Errorf("DecodeCursor() = %q, %v", id, err)
+	}
+
+	for _, invalid := range []string{"42", "!!!", EncodeCursor("42")[1:]} {
+		if _, err := DecodeCursor(invalid); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", invalid, err)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := ids(10)
+
+	tests := []struct {
+		name         string
+		args         Args
+		want         []string
+		wantPrevious bool
+		wantNext     bool
+	}{
+		{"default page size", Args{}, items, false, false},
+		{"first", Args{First: intPtr(3)}, []string{"1", "2", "3"}, false, true},
+		{"first after", Args{First: intPtr(3), After: strPtr(EncodeCursor("3"))}, []string{"4", "5", "6"}, true, true},
+		{"last", Args{Last: intPtr(2)}, []string{"9", "10"}, true, false},
+		{"last before", Args{Last: intPtr(2), Before: strPtr(EncodeCursor("5"))}, []string{"3", "4"}, true, true},
+		{"after and before", Args{After: strPtr(EncodeCursor("2")), Before: strPtr(EncodeCursor("5"))}, []string{"3", "4"}, true, true},
+		{"after the end", Args{First: intPtr(3), After: strPtr(EncodeCursor("10"))}, []string{}, true, false},
+		{"first zero", Args{First: intPtr(0)}, []string{}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := Paginate(items, identity, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Items) != len(tt.want) {
+				t.Fatalf("items = %v, want %v", page.Items, tt.want)
+			}
+			for i := range tt.want {
+				if page.Items[i] != tt.want[i] {
+					t.Fatalf("items = %v, want %v", page.Items, tt.want)
+				}
+			}
+			if page.HasPreviousPage != tt.wantPrevious || page.HasNextPage != tt.wantNext {
+				t.Errorf("hasPreviousPage = %v, hasNextPage = %v, want %v, %v",
+					page.HasPreviousPage, page.HasNextPage, tt.wantPrevious, tt.wantNext)
+			}
+			if page.TotalCount != len(items) {
+				t.Errorf("totalCount = %d, want %d", page.TotalCount, len(items))
+			}
+		})
+	}
+}
+
+func TestPaginateRejectsInvalidArgs(t *testing.T) {
+	items := ids(3)
+
+	for name, args := range map[string]Args{
+		"negative first": {First: intPtr(-1)},
+		"huge last":      {Last: intPtr(MaxPageSize + 1)},
+	} {
+		if _, err := Paginate(items, identity, args); !errors.Is(err, ErrInvalidPageSize) {
+			t.Errorf("%s: error = %v, want ErrInvalidPageSize", name, err)
+		}
+	}
+	if _, err := Paginate(items, identity, Args{After: strPtr("nope")}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("invalid after: error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestCursorsStableUnderInserts(t *testing.T) {
+	items := ids(6)
+	page, _ := Paginate(items, identity, Args{First: intPtr(2)})
+
+	// Records created after the first page was read land at the end, and
+	// a record from the page already read is deleted
+	items = append(items[1:], "7", "8")
+
+	next, err := Paginate(items, identity, Args{First: intPtr(2), After: page.EndCursor()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Items[0] != "3" || next.Items[1] != "4" {
+		t.Errorf("second page = %v, want [3 4]", next.Items)
+	}
+}
+
+func TestCompareIDs(t *testing.T) {
+	if CompareIDs("9", "10") >= 0 {
+		t.Error("numeric IDs should compare by value")
+	}
+	if CompareIDs("a", "b") >= 0 {
+		t.Error("other IDs should compare as strings")
+	}
+}
+
+type scored struct {
+	id    string
+	score int
+}
+
+// byScore sorts by score, highest first, ties by ID
+var byScore = &Order[scored]{
+	Name:  "score desc",
+	Value: func(s scored) string { return strconv.Itoa(s.score) },
+	At: func(value, id string) (scored, error) {
+		score, err := strconv.Atoi(value)
+		return scored{id: id, score: score}, err
+	},
+	Compare: func(a, b scored) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return CompareIDs(a.id, b.id)
+	},
+}
+
+func TestPaginateBy(t *testing.T) {
+	items := []scored{{"3", 90}, {"1", 50}, {"4", 50}, {"2", 10}, {"5", 10}}
+	id := func(s scored) string { return s.id }
+
+	page, err := PaginateBy(items, id, byScore, Args{First: intPtr(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ParseCursor(*page.EndCursor())
+	if err != nil || c != (Cursor{Order: "score desc", Value: "50", ID: "1"}) {
+		t.Fatalf("ParseCursor() = %+v, %v", c, err)
+	}
+
+	// O item do cursor some, mas a posição continua valendo pelo score e ID
+	items = append(items[:1], items[2:]...)
+	next, err := PaginateBy(items, id, byScore, Args{First: intPtr(2), After: page.EndCursor()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next.Items) != 2 || next.Items[0].id != "4" || next.Items[1].id != "2" || !next.HasPreviousPage {
+		t.Errorf("second page = %+v", next.Items)
+	}
+
+	back, err := PaginateBy(items, id, byScore, Args{Last: intPtr(1), Before: next.StartCursor()})
+	if err != nil || len(back.Items) != 1 || back.Items[0].id != "3" {
+		t.Errorf("backward page = %+v, %v", back.Items, err)
+	}
+}
+
+func TestPaginateByRejectsCursorsOfAnotherOrder(t *testing.T) {
+	items := []scored{{"1", 50}, {"2", 10}}
+	id := func(s scored) string { return s.id }
+	other := Cursor{Order: "score asc", Value: "10", ID: "2"}.Encode()
+	badValue := Cursor{Order: "score desc", Value: "high", ID: "2"}.Encode()
+
+	for name, cursor := range map[string]string{"id order": EncodeCursor("1"), "other order": other, "bad value": badValue} {
+		if _, err := PaginateBy(items, id, byScore, Args{After: &cursor}); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: error = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+	if _, err := Paginate(ids(3), identity, Args{After: &other}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("sorted cursor on the ID order: error = %v, want ErrInvalidCursor", err)
+	}
+}
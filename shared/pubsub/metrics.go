@@ -0,0 +1,31 @@
+package pubsub
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The subscription metrics live with the broker so they are registered
+// once, whichever services import it
+var (
+	EventsDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "graphql_subscription_events_dropped_total",
+			Help: "Total of subscription events dropped because a subscriber buffer was full, by service and subscription",
+		},
+		[]string{"service", "subscription"},
+	)
+
+	ActiveSubscriptions = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "graphql_active_subscriptions",
+			Help: "Number of open subscriptions by service and subscription",
+		},
+		[]string{"service", "subscription"},
+	)
+)
+
+// recordDroppedEvent - Record a subscription event dropped for a slow subscriber
+func recordDroppedEvent(serviceName, subscription string) {
+	EventsDropped.WithLabelValues(serviceName, subscription).Inc()
+}
@@ -0,0 +1,131 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// DefaultBufferSize is how many events a subscriber may fall behind by
+// before new events are dropped for it
+const DefaultBufferSize = 16
+
+// BufferSizeFromEnv reads SUBSCRIPTION_BUFFER_SIZE, defaulting to
+// DefaultBufferSize
+func BufferSizeFromEnv() (int, error) {
+	raw := os.Getenv("SUBSCRIPTION_BUFFER_SIZE")
+	if raw == "" {
+		return DefaultBufferSize, nil
+	}
+	size, err := strconv.Atoi(raw)
+	if err != nil || size < 1 {
+		return 0, fmt.Errorf("invalid SUBSCRIPTION_BUFFER_SIZE %q, expected a positive integer", raw)
+	}
+	return size, nil
+}
+
+// Broker is an in-process publish/subscribe hub for one kind of event.
+// Publishing never blocks: every subscriber has a bounded buffer, and an
+// event that does not fit is dropped for that subscriber and counted in
+// graphql_subscription_events_dropped_total.
+type Broker[T any] struct {
+	service string
+	name    string
+	buffer  int
+
+	mu   sync.RWMutex
+	subs map[string]map[chan T]struct{}
+}
+
+// NewBroker creates a broker. name labels its metrics, usually the
+// subscription field it feeds.
+func NewBroker[T any](service, name string, buffer int) *Broker[T] {
+	if buffer < 1 {
+		buffer = DefaultBufferSize
+	}
+	return &Broker[T]{
+		service: service,
+		name:    name,
+		buffer:  buffer,
+		subs:    make(map[string]map[chan T]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving the events published to topic
+// until ctx is done, when the channel is closed
+func (b *Broker[T]) Subscribe(ctx context.Context, topic string) <-chan T {
+	ch := make(chan T, b.buffer)
+
+	b.mu.Lock()
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[chan T]struct{})
+	}
+	b.subs[topic][ch] = struct{}{}
+	b.mu.Unlock()
+	ActiveSubscriptions.WithLabelValues(b.service, b.name).Inc()
+
+	go func() {
+		<-ctx.Done()
+		b.unsubscribe(topic, ch)
+	}()
+	return ch
+}
+
+// Publish sends event to the subscribers of topic and returns how many
+// received it
+func (b *Broker[T]) Publish(topic string, event T) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	delivered := 0
+	for ch := range b.subs[topic] {
+		select {
+		case ch <- event:
+			delivered++
+		default:
+			recordDroppedEvent(b.service, b.name)
+		}
+	}
+	return delivered
+}
+
+// Subscribers returns the number of subscribers of topic
+func (b *Broker[T]) Subscribers(topic string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs[topic])
+}
+
+// CloseTopic ends every subscription to topic, as when the record it
+// follows is deleted
+func (b *Broker[T]) CloseTopic(topic string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[topic] {
+		b.close(topic, ch)
+	}
+}
+
+func (b *Broker[T]) unsubscribe(topic string, ch chan T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// CloseTopic may have ended the subscription already
+	if _, ok := b.subs[topic][ch]; ok {
+		b.close(topic, ch)
+	}
+}
+
+// close removes a subscriber, the caller holds the write lock. Publish
+// holds the read lock while sending, so this can not race with a send.
+func (b *Broker[T]) close(topic string, ch chan T) {
+	delete(b.subs[topic], ch)
+	if len(b.subs[topic]) == 0 {
+		delete(b.subs, topic)
+	}
+	close(ch)
+	ActiveSubscriptions.WithLabelValues(b.service, b.name).Dec()
+}
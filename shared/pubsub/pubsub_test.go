@@ -0,0 +1,141 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func receive(t *testing.T, ch <-chan int) int {
+	t.Helper()
+	select {
+	case event, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+	return 0
+}
+
+func TestPublishToTopicSubscribers(t *testing.T) {
+	broker := NewBroker[int]("test", "publish", 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a := broker.Subscribe(ctx, "a")
+	also := broker.Subscribe(ctx, "a")
+	b := broker.Subscribe(ctx, "b")
+
+	if n := broker.Publish("a", 1); n != 2 {
+		t.Errorf("Publish(a) delivered to %d subscribers, want 2", n)
+	}
+	if got := receive(t, a); got != 1 {
+		t.Errorf("a received %d, want 1", got)
+	}
+	if got := receive(t, also); got != 1 {
+		t.Errorf("second subscriber received %d, want 1", got)
+	}
+	select {
+	case event := <-b:
+		t.Errorf("b received %d from topic a", event)
+	default:
+	}
+}
+
+func TestSlowSubscriberDropsEvents(t *testing.T) {
+	broker := NewBroker[int]("test", "slow", 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	slow := broker.Subscribe(ctx, "topic")
+	dropped := testutil.ToFloat64(EventsDropped.WithLabelValues("test", "slow"))
+
+	// Publicar nunca bloqueia, mesmo com o buffer cheio
+	for i := 1; i <= 5; i++ {
+		broker.Publish("topic", i)
+	}
+
+	if got := testutil.ToFloat64(EventsDropped.WithLabelValues("test", "slow")); got != dropped+3 {
+		t.Errorf("dropped events = %v, want %v", got, dropped+3)
+	}
+	if first, second := receive(t, slow), receive(t, slow); first != 1 || second != 2 {
+		t.Errorf("buffered events = %d, %d, want the oldest two", first, second)
+	}
+}
+
+func TestCancelClosesSubscription(t *testing.T) {
+	broker := NewBroker[int]("test", "cancel", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	active := testutil.ToFloat64(ActiveSubscriptions.WithLabelValues("test", "cancel"))
+
+	ch := broker.Subscribe(ctx, "topic")
+	if got := testutil.ToFloat64(ActiveSubscriptions.WithLabelValues("test", "cancel")); got != active+1 {
+		t.Errorf("active subscriptions = %v, want %v", got, active+1)
+	}
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("received an event after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+	if n := broker.Subscribers("topic"); n != 0 {
+		t.Errorf("Subscribers() = %d after cancel, want 0", n)
+	}
+	if got := testutil.ToFloat64(ActiveSubscriptions.WithLabelValues("test", "cancel")); got != active {
+		t.Errorf("active subscriptions = %v, want %v", got, active)
+	}
+}
+
+func TestCloseTopic(t *testing.T) {
+	broker := NewBroker[int]("test", "close", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	closed := broker.Subscribe(ctx, "deleted")
+	open := broker.Subscribe(ctx, "other")
+	broker.CloseTopic("deleted")
+
+	if _, ok := <-closed; ok {
+		t.Error("subscription to a closed topic received an event")
+	}
+	broker.Publish("other", 1)
+	if got := receive(t, open); got != 1 {
+		t.Errorf("other topic received %d, want 1", got)
+	}
+
+	// Cancelar depois de CloseTopic não fecha o canal de novo
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestConcurrentPublishAndCancel(t *testing.T) {
+	broker := NewBroker[int]("test", "concurrent", 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		broker.Subscribe(ctx, "topic")
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				broker.Publish("topic", j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			cancel()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrRejected is returned when the registry refuses the schema because it
+// does not compose with the other subgraphs
+var ErrRejected = errors.New("schema rejected by registry")
+
+// pushRequest is the body expected by the schema registry
+type pushRequest struct {
+	URL string `json:"url"`
+	SDL string `json:"sdl"`
+}
+
+// PushResponse is the registry answer for accepted and rejected pushes
+type PushResponse struct {
+	Version   int    `json:"version"`
+	Hash      string `json:"hash"`
+	Created   bool   `json:"created"`
+	Error     string `json:"error"`
+	Conflicts []struct {
+		Message   string   `json:"message"`
+		Locations []string `json:"locations"`
+	} `json:"conflicts"`
+}
+
+// Push registers the subgraph schema with the registry
+func Push(ctx context.Context, client *http.Client, registryURL, subgraph, routingURL, sdl string) (*PushResponse, error) {
+	body, err := json.Marshal(pushRequest{URL: routingURL, SDL: sdl})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimRight(registryURL, "/") + "/subgraphs/" + subgraph + "/schemas"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out PushResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decoding registry response (status %d): %w", resp.StatusCode, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return &out, nil
+	case http.StatusConflict:
+		messages := make([]string, 0, len(out.Conflicts))
+		for _, conflict := range out.Conflicts {
+			messages = append(messages, strings.Join(append(conflict.Locations, conflict.Message), ": "))
+		}
+		return &out, fmt.Errorf("%w: %s", ErrRejected, strings.Join(messages, "; "))
+	default:
+		return &out, fmt.Errorf("registry returned status %d: %s", resp.StatusCode, out.Error)
+	}
+}
+
+// PushWithRetry pushes the schema until the registry accepts or rejects
+// it, backing off between attempts while the registry is unreachable
+func PushWithRetry(ctx context.Context, logger *logrus.Logger, registryURL, subgraph, routingURL, sdl string) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	backoff := time.Second
+
+	for {
+		resp, err := Push(ctx, client, registryURL, subgraph, routingURL, sdl)
+		if err == nil {
+			logger.WithFields(logrus.Fields{
+				"registry": registryURL,
+				"version":  resp.Version,
+				"hash":     resp.Hash,
+				"created":  resp.Created,
+			}).Info("Schema registered")
+			return
+		}
+		if errors.Is(err, ErrRejected) {
+			logger.WithError(err).WithField("registry", registryURL).Error("Schema rejected by registry")
+			return
+		}
+
+		logger.WithError(err).WithFields(logrus.Fields{
+			"registry": registryURL,
+			"retry_in": backoff.String(),
+		}).Warn("Failed to push schema to registry")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
@@ -0,0 +1,40 @@
+package registry
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// SDL returns the subgraph schema as served by _service { sdl }: the
+// sources the schema was loaded from, without the built-in federation ones
+func SDL(schema *ast.Schema) string {
+	seen := make(map[*ast.Source]bool)
+	var sources []*ast.Source
+	add := func(pos *ast.Position) {
+		if pos == nil || pos.Src == nil || pos.Src.BuiltIn || seen[pos.Src] {
+			return
+		}
+		seen[pos.Src] = true
+		sources = append(sources, pos.Src)
+	}
+	for _, def := range schema.Types {
+		add(def.Position)
+		// Campos de "extend type" ficam no arquivo da extensão
+		for _, field := range def.Fields {
+			add(field.Position)
+		}
+	}
+	for _, def := range schema.Directives {
+		add(def.Position)
+	}
+
+	// gqlgen carrega os arquivos do schema em ordem de nome
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+	sdl := make([]string, len(sources))
+	for i, src := range sources {
+		sdl[i] = src.Input
+	}
+	return strings.Join(sdl, "\n")
+}
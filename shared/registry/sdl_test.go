@@ -0,0 +1,21 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestSDLSkipsBuiltInSources(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(
+		&ast.Source{Name: "b.graphqls", Input: "extend type Query { b: String }"},
+		&ast.Source{Name: "a.graphqls", Input: "type Query { a: String }"},
+		&ast.Source{Name: "federation.graphql", Input: "scalar _Any", BuiltIn: true},
+	)
+
+	want := "type Query { a: String }\nextend type Query { b: String }"
+	if got := SDL(schema); got != want {
+		t.Errorf("SDL() = %q, want %q", got, want)
+	}
+}